@@ -0,0 +1,458 @@
+package liquid
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/autopilot3/ap3-types-go/types/date"
+	"github.com/autopilot3/ap3-types-go/types/phone"
+)
+
+// A CheckError is a problem that Engine.Check finds in a template.
+type CheckError struct {
+	// Path is the variable path of the problem.
+	Path string
+	// Line and Column are the position of the variable in the template.
+	Line, Column int
+	Message      string
+}
+
+func (e CheckError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// shapeTypes are the type names of the nested maps that Engine.Check accepts.
+var shapeTypes = map[string]func() *Schema{
+	"string":   func() *Schema { return &Schema{Type: "string"} },
+	"number":   numberSchema,
+	"integer":  func() *Schema { return &Schema{Type: "integer"} },
+	"boolean":  func() *Schema { return &Schema{Type: "boolean"} },
+	"date":     func() *Schema { return &Schema{Type: "string", Format: "date"} },
+	"datetime": dateTimeSchema,
+	"money":    moneySchema,
+	"array":    arraySchema,
+	"object":   func() *Schema { return &Schema{Type: "object"} },
+	"any":      func() *Schema { return &Schema{} },
+}
+
+// Kinds of input that filters accept; see schemaKind.
+var (
+	dateInputs   = []string{"date", "string", "number"} // strings are parsed, numbers are Unix times
+	timeInputs   = []string{"date", "string"}           // strings are parsed
+	numberInputs = []string{"number", "string"}         // strings are converted
+	moneyInputs  = []string{"object"}
+	arrayInputs  = []string{"array"}
+)
+
+// filterInputKinds are the kinds of input that filters accept, for Engine.Check. These
+// differ from the types that Engine.Schema infers from filters: a date filter can be
+// applied to a string, but the value that it's meant for is a date.
+var filterInputKinds = map[string][]string{
+	"date":                    dateInputs,
+	"date_add":                dateInputs,
+	"date_diff":               dateInputs,
+	"start_of":                dateInputs,
+	"end_of":                  dateInputs,
+	"business_days_add":       dateInputs,
+	"time_ago":                dateInputs,
+	"time_until":              dateInputs,
+	"dateTimeFormatOrDefault": timeInputs,
+	"timeInTimezone":          timeInputs,
+	"dateFormatOrDefault":     {"date"},
+	"price":                   moneyInputs,
+	"money":                   moneyInputs,
+	"money_plus":              moneyInputs,
+	"money_times":             moneyInputs,
+	"money_round":             moneyInputs,
+	"convert_currency":        moneyInputs,
+	"abs":                     numberInputs,
+	"ceil":                    numberInputs,
+	"floor":                   numberInputs,
+	"round":                   numberInputs,
+	"plus":                    numberInputs,
+	"minus":                   numberInputs,
+	"times":                   numberInputs,
+	"divided_by":              numberInputs,
+	"modulo":                  numberInputs,
+	"at_least":                numberInputs,
+	"at_most":                 numberInputs,
+	"compactNumber":           numberInputs,
+	"percentage":              numberInputs,
+	"signedNumber":            numberInputs,
+	"signedPercentage":        numberInputs,
+	"significantDigits":       numberInputs,
+	"pluralize":               numberInputs,
+	"ordinal":                 numberInputs,
+	"join":                    arrayInputs,
+	"sort":                    arrayInputs,
+	"sort_natural":            arrayInputs,
+	"uniq":                    arrayInputs,
+	"compact":                 arrayInputs,
+	"map":                     arrayInputs,
+	"phone_format":            {"string"},
+}
+
+// Check checks a template against the shape of the bindings that it's rendered with, and
+// returns the problems that it finds, sorted by position:
+//
+//   - variables and properties that aren't in the shape, such as contact.fist_name
+//   - loops over values that aren't arrays
+//   - filters applied to values of the wrong type, such as dateFormatOrDefault to a string
+//   - comparisons of values of different types, such as contact.age == 'abc'
+//
+// The shape is one of:
+//
+//   - a Go struct value or pointer, or a reflect.Type, whose fields and methods are the
+//     properties that templates can use
+//   - a *Schema, or a JSON Schema document as a []byte or as a map with a "$schema" key
+//   - a nested map of property names to type names (string, number, integer, boolean,
+//     date, datetime, money, array, object or any), maps, one-element []interface{}
+//     slices for arrays, Go values or reflect.Types
+//
+// The properties of an object in the shape are all that it has; an object without
+// properties, such as a Go map, can have any.
+func (e *Engine) Check(tpl *Template, shape interface{}) ([]CheckError, error) {
+	root, err := shapeSchema(shape)
+	if err != nil {
+		return nil, err
+	}
+	var errs []CheckError
+	for _, report := range tpl.AnalyzeVariables() {
+		for _, u := range report.Usages {
+			if err, ok := checkUsage(root, u); ok {
+				errs = append(errs, err)
+			}
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Column < errs[j].Column
+	})
+	return errs, nil
+}
+
+// checkUsage returns the problem with a variable usage, if any.
+func checkUsage(root *Schema, u VariableUsage) (CheckError, bool) {
+	problem := func(format string, a ...interface{}) (CheckError, bool) {
+		return CheckError{Path: u.Path, Line: u.Line, Column: u.Column, Message: fmt.Sprintf(format, a...)}, true
+	}
+	s, unknown := lookupSchema(root, u.Path)
+	switch {
+	case unknown != "" && !strings.Contains(unknown, "."):
+		return problem("unknown variable %s", unknown)
+	case unknown != "":
+		return problem("unknown property %s", unknown)
+	case s == nil || s.Type == "":
+		return CheckError{}, false
+	case u.Loop && s.Type != "array":
+		return problem("%s is %s, not an array", u.Path, describeKind(schemaKind(s)))
+	}
+	if len(u.Filters) > 0 {
+		if kinds, ok := filterInputKinds[u.Filters[0]]; ok && !acceptsKind(kinds, schemaKind(s)) {
+			return problem("%s expects %s, but %s is %s", u.Filters[0], describeKinds(kinds), u.Path, describeKind(schemaKind(s)))
+		}
+	}
+	if u.Operand != nil {
+		operand, name := literalSchema(u.Operand.Literal), fmt.Sprintf("%#v", u.Operand.Literal)
+		if u.Operand.Path != "" {
+			operand, _ = lookupSchema(root, u.Operand.Path)
+			name = u.Operand.Path
+		}
+		if !comparable(u.Operator, s, operand) {
+			return problem("%s is %s, but is compared with %s, which is %s", u.Path, describeKind(schemaKind(s)), name, describeKind(schemaKind(operand)))
+		}
+	}
+	return CheckError{}, false
+}
+
+// lookupSchema returns the schema of a variable path, or nil if it's unknown. If the shape
+// doesn't have a property of the path, it returns the path up to that property.
+// Properties of the elements of an unknown loop source aren't reported; the loop is.
+func lookupSchema(root *Schema, path string) (*Schema, string) {
+	s := root
+	segments := schemaPath(path)
+	for i, segment := range segments {
+		switch {
+		case segment == "size":
+			return &Schema{Type: "integer"}, ""
+		case segment == "[]" || (s.Type == "array" && (segment == "first" || segment == "last")):
+			if s.Type != "array" {
+				return nil, ""
+			}
+			s = s.Items
+		case (s.Type == "" || s.Type == "object") && s.Properties == nil:
+			return nil, ""
+		default:
+			c, ok := s.Properties[segment]
+			if !ok {
+				for _, rest := range segments[i+1:] {
+					if rest == "[]" {
+						return nil, ""
+					}
+				}
+				return nil, strings.ReplaceAll(strings.Join(segments[:i+1], "."), ".[]", "[]")
+			}
+			s = c
+		}
+		if s == nil {
+			return nil, ""
+		}
+	}
+	return s, ""
+}
+
+// schemaKind returns the kind of value of a schema for type checks: a JSON Schema type,
+// with integers as numbers, or "date" for dates and date-times.
+func schemaKind(s *Schema) string {
+	switch {
+	case s == nil:
+		return ""
+	case s.Type == "string" && (s.Format == "date" || s.Format == "date-time"):
+		return "date"
+	case s.Type == "integer":
+		return "number"
+	}
+	return s.Type
+}
+
+func describeKind(kind string) string {
+	switch kind {
+	case "":
+		return "nil"
+	case "array", "object":
+		return "an " + kind
+	}
+	return "a " + kind
+}
+
+// acceptsKind returns true if a kind is one of the kinds that a filter accepts.
+func acceptsKind(kinds []string, kind string) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// describeKinds describes the kinds of input that a filter accepts, as in "a date,
+// a string or a number".
+func describeKinds(kinds []string) string {
+	var d []string
+	for _, k := range kinds {
+		d = append(d, describeKind(k))
+	}
+	if len(d) == 1 {
+		return d[0]
+	}
+	return strings.Join(d[:len(d)-1], ", ") + " or " + d[len(d)-1]
+}
+
+// comparable returns true if values of two schemas can be compared with an operator.
+// Dates are compared as strings, and nil with anything.
+func comparable(op string, a, b *Schema) bool {
+	ka, kb := comparisonKind(a), comparisonKind(b)
+	switch {
+	case ka == "" || kb == "":
+		return true
+	case op == "contains":
+		return ka == "string" || ka == "array" || ka == "object"
+	case op == "==" || op == "!=":
+		return ka == kb
+	}
+	return ka == kb && (ka == "number" || ka == "string")
+}
+
+func comparisonKind(s *Schema) string {
+	if k := schemaKind(s); k != "date" {
+		return k
+	}
+	return "string"
+}
+
+// literalSchema returns the schema of a literal, or nil for nil.
+func literalSchema(v interface{}) *Schema {
+	switch v.(type) {
+	case string:
+		return &Schema{Type: "string"}
+	case int, float64:
+		return numberSchema()
+	case bool:
+		return &Schema{Type: "boolean"}
+	}
+	return nil
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	dateType  = reflect.TypeOf(date.Date(0))
+	phoneType = reflect.TypeOf(phone.International{})
+)
+
+// shapeSchema returns the schema of a shape argument of Engine.Check.
+func shapeSchema(shape interface{}) (*Schema, error) {
+	switch v := shape.(type) {
+	case *Schema:
+		return v, nil
+	case Schema:
+		return &v, nil
+	case []byte:
+		return unmarshalSchema(v)
+	case json.RawMessage:
+		return unmarshalSchema(v)
+	case map[string]interface{}:
+		if _, ok := v["$schema"]; ok {
+			bs, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			return unmarshalSchema(bs)
+		}
+		return mapShapeSchema(v)
+	case reflect.Type:
+		return typeSchema(v, map[reflect.Type]bool{}), nil
+	}
+	t := reflect.TypeOf(shape)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unsupported bindings shape %T", shape)
+	}
+	return typeSchema(t, map[reflect.Type]bool{}), nil
+}
+
+func unmarshalSchema(bs []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(bs, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// mapShapeSchema returns the schema of a value of a nested map shape.
+func mapShapeSchema(shape interface{}) (*Schema, error) {
+	switch v := shape.(type) {
+	case string:
+		if f, ok := shapeTypes[v]; ok {
+			return f(), nil
+		}
+		return nil, fmt.Errorf("unknown type %q", v)
+	case map[string]interface{}:
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema, len(v))}
+		for name, p := range v {
+			ps, err := mapShapeSchema(p)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			s.Properties[name] = ps
+		}
+		return s, nil
+	case []interface{}:
+		switch len(v) {
+		case 0:
+			return arraySchema(), nil
+		case 1:
+			items, err := mapShapeSchema(v[0])
+			if err != nil {
+				return nil, err
+			}
+			return &Schema{Type: "array", Items: items}, nil
+		}
+		return nil, fmt.Errorf("an array shape has one element, the shape of the elements")
+	case *Schema:
+		return v, nil
+	case reflect.Type:
+		return typeSchema(v, map[reflect.Type]bool{}), nil
+	case nil:
+		return &Schema{}, nil
+	}
+	return typeSchema(reflect.TypeOf(shape), map[reflect.Type]bool{}), nil
+}
+
+// typeSchema returns the schema of the values of a Go type, as templates see them.
+// seen holds the struct types that are being converted, for recursive types.
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return dateTimeSchema()
+	case dateType:
+		return &Schema{Type: "string", Format: "date"}
+	case phoneType:
+		return &Schema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return numberSchema()
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string"}
+		}
+		return &Schema{Type: "array", Items: typeSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Func:
+		if t.NumIn() == 0 && t.NumOut() > 0 {
+			return typeSchema(t.Out(0), seen)
+		}
+	case reflect.Struct:
+		if seen[t] {
+			return &Schema{Type: "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		addStructProperties(s, t, seen)
+		return s
+	}
+	return &Schema{}
+}
+
+// addStructProperties adds the fields and methods of a struct type that templates can
+// use, as values.structValue finds them, to the properties of a schema.
+func addStructProperties(s *Schema, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			addStructProperties(s, f.Type, seen)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("liquid"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+		s.Properties[name] = typeSchema(f.Type, seen)
+	}
+	for _, mt := range []reflect.Type{t, reflect.PtrTo(t)} {
+		for i := 0; i < mt.NumMethod(); i++ {
+			m := mt.Method(i)
+			if m.Type.NumIn() == 1 && m.Type.NumOut() > 0 && m.Type.NumOut() <= 2 {
+				if _, ok := s.Properties[m.Name]; !ok {
+					s.Properties[m.Name] = typeSchema(m.Type.Out(0), seen)
+				}
+			}
+		}
+	}
+}
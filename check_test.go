@@ -0,0 +1,110 @@
+package liquid
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type checkContact struct {
+	FirstName string `liquid:"first_name"`
+	Age       int    `liquid:"age"`
+	Tags      []string
+	Secret    string `liquid:"-"`
+	Joined    time.Time
+}
+
+func (c checkContact) FullName() string { return c.FirstName }
+
+const checkTemplate = `Hi {{ contact.fist_name }} {{ contact.FullName }}
+{% if contact.age == 'old' %}{{ contact.Secret }}{% endif %}
+{% for tag in contact.first_name %}{{ tag }}{% endfor %}
+{{ contact.first_name | dateFormatOrDefault: 'mdy', '' }} {{ contact.Joined | date: '%Y' }}
+{% for tag in contact.Tags %}{{ tag | upcase }}{% endfor %}{{ contact.Tags.size }}`
+
+func TestEngine_Check(t *testing.T) {
+	engine := NewEngine()
+	tpl, err := engine.ParseString(checkTemplate)
+	require.NoError(t, err)
+	expected := []CheckError{
+		{Path: "contact.fist_name", Line: 1, Column: 7, Message: "unknown property contact.fist_name"},
+		{Path: "contact.age", Line: 2, Column: 7, Message: `contact.age is a number, but is compared with "old", which is a string`},
+		{Path: "contact.Secret", Line: 2, Column: 33, Message: "unknown property contact.Secret"},
+		{Path: "contact.first_name", Line: 3, Column: 15, Message: "contact.first_name is a string, not an array"},
+		{Path: "contact.first_name", Line: 4, Column: 4, Message: "dateFormatOrDefault expects a date, but contact.first_name is a string"},
+	}
+
+	for _, shape := range []interface{}{
+		checkContact{},
+		&checkContact{},
+		reflect.TypeOf(checkContact{}),
+	} {
+		errs, err := engine.Check(tpl, map[string]interface{}{"contact": shape})
+		require.NoError(t, err)
+		require.Equal(t, expected, errs)
+	}
+
+	nested := map[string]interface{}{
+		"contact": map[string]interface{}{
+			"first_name": "string",
+			"FullName":   "string",
+			"age":        "integer",
+			"Tags":       []interface{}{"string"},
+			"Joined":     "datetime",
+		},
+	}
+	errs, cerr := engine.Check(tpl, nested)
+	require.NoError(t, cerr)
+	require.Equal(t, expected, errs)
+
+	errs, cerr = engine.Check(tpl, []byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"contact": {
+				"type": "object",
+				"properties": {
+					"first_name": {"type": ["string", "null"]},
+					"FullName": {"type": "string"},
+					"age": {"type": "integer"},
+					"Tags": {"type": "array", "items": {"type": "string"}},
+					"Joined": {"type": "string", "format": "date-time"}
+				}
+			}
+		}
+	}`))
+	require.NoError(t, cerr)
+	require.Equal(t, expected, errs)
+	require.Equal(t, "line 1, column 7: unknown property contact.fist_name", errs[0].Error())
+
+	// Unknown variables, open objects and unknown loop sources
+	tpl, err = engine.ParseString(`{{ user.name }}{{ meta.anything }}{% for x in items %}{{ x.name }}{% endfor %}`)
+	require.NoError(t, err)
+	errs, cerr = engine.Check(tpl, map[string]interface{}{"meta": "object"})
+	require.NoError(t, cerr)
+	require.Equal(t, []CheckError{
+		{Path: "user.name", Line: 1, Column: 4, Message: "unknown variable user"},
+		{Path: "items", Line: 1, Column: 47, Message: "unknown variable items"},
+	}, errs)
+
+	_, cerr = engine.Check(tpl, map[string]interface{}{"meta": "strng"})
+	require.EqualError(t, cerr, `meta: unknown type "strng"`)
+	_, cerr = engine.Check(tpl, 1)
+	require.EqualError(t, cerr, "unsupported bindings shape int")
+
+	// Filters accept the kinds of input that they convert, such as date strings and Unix times
+	tpl, err = engine.ParseString(`{{ c.s | date: '%Y' }}{{ c.n | time_ago }}{{ c.s | date_add: 1, 'day' }}{{ c.s | plus: 1 }}
+{{ c.b | date: '%Y' }}{{ c.s | dateFormatOrDefault: 'mdy', '' }}{{ c.n | join }}`)
+	require.NoError(t, err)
+	errs, cerr = engine.Check(tpl, map[string]interface{}{
+		"c": map[string]interface{}{"s": "string", "n": "integer", "b": "boolean"},
+	})
+	require.NoError(t, cerr)
+	require.Equal(t, []CheckError{
+		{Path: "c.b", Line: 2, Column: 4, Message: "date expects a date, a string or a number, but c.b is a boolean"},
+		{Path: "c.s", Line: 2, Column: 26, Message: "dateFormatOrDefault expects a date, but c.s is a string"},
+		{Path: "c.n", Line: 2, Column: 68, Message: "join expects an array, but c.n is a number"},
+	}, errs)
+}
@@ -16,6 +16,14 @@ const (
 	TagDisplayTypePhone     string = "phone"
 )
 
+// The format options of phone tags, besides "hide", that select a phone_format style.
+const (
+	PhoneFormatInternational string = "international"
+	PhoneFormatNational      string = "national"
+	PhoneFormatE164          string = "e164"
+	PhoneFormatRFC3966       string = "rfc3966"
+)
+
 type TagType string
 
 const (
@@ -61,6 +69,10 @@ func (t *Tag) GetLiquidString() (string, error) {
 		case TagDisplayTypeCurrency, TagDisplayTypeDecimal, TagDisplayTypeAggregate:
 			return fmt.Sprintf(`{{ %s | decimal: "%s", "%s" }}`, t.LiquidName, t.FormatOption, t.DefaultValue), nil
 		case TagDisplayTypePhone:
+			switch t.FormatOption {
+			case PhoneFormatInternational, PhoneFormatNational, PhoneFormatE164, PhoneFormatRFC3966:
+				return fmt.Sprintf(`{{ %s | phone_format: "%s", "", "%s" }}`, t.LiquidName, t.FormatOption, t.DefaultValue), nil
+			}
 			willHide := false
 			if t.FormatOption == "hide" {
 				willHide = true
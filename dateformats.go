@@ -0,0 +1,43 @@
+package liquid
+
+import (
+	"context"
+
+	"github.com/autopilot3/liquid/render"
+)
+
+// DateFormats is a registry of the named formats of the dateFormatOrDefault,
+// dateTimeFormatOrDefault and timeInTimezone filters. See Engine.DateFormats.
+type DateFormats = render.DateFormats
+
+// A DateTimeFormat is a named datetime format.
+type DateTimeFormat = render.DateTimeFormat
+
+// An HourCycle is a preference for 12-hour or 24-hour times.
+type HourCycle = render.HourCycle
+
+// The hour cycles.
+const (
+	// HourCycleDefault uses the hour cycle of each format.
+	HourCycleDefault = render.HourCycleDefault
+	// HourCycle12 uses the 12-hour variant of a format, such as mdy12 for mdy24.
+	HourCycle12 = render.HourCycle12
+	// HourCycle24 uses the 24-hour variant of a format, such as mdy24 for mdy12.
+	HourCycle24 = render.HourCycle24
+)
+
+// NewDateFormats returns a registry of the built-in formats.
+func NewDateFormats() *DateFormats {
+	return render.NewDateFormats()
+}
+
+var builtinDateFormats = NewDateFormats()
+
+// contextDateFormats returns the date formats of the render of a filter's context, or the
+// built-in formats outside a render.
+func contextDateFormats(ctx context.Context) *DateFormats {
+	if cfg := render.ContextConfig(ctx); cfg != nil && cfg.DateFormats != nil {
+		return cfg.DateFormats
+	}
+	return builtinDateFormats
+}
@@ -0,0 +1,73 @@
+package liquid
+
+import (
+	"testing"
+	"time"
+
+	"github.com/autopilot3/ap3-types-go/types/date"
+	"github.com/stretchr/testify/require"
+)
+
+var germanMonths = [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"}
+var germanShortMonths = [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."}
+var germanDays = [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"}
+var germanShortDays = [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."}
+
+func TestDateFormats(t *testing.T) {
+	tm := time.Date(2024, time.March, 4, 15, 15, 0, 0, time.UTC)
+	d := date.MustNewFromTime(tm)
+
+	f := NewDateFormats().
+		AddDateFormat("iso", "2006-01-02").
+		AddDateTimeFormat("short", DateTimeFormat{Layout: "02.01. 3:04PM", LowerMeridiem: true})
+	require.Equal(t, "2024-03-04", f.FormatDate(d, "iso"))
+	require.Equal(t, "04.03. 3:15pm", f.FormatDateTime(tm, "short"))
+	require.Equal(t, "March 4, 2024", f.FormatDate(d, "mdya"))
+
+	f.SetMonthNames(germanMonths, germanShortMonths).SetDayNames(germanDays, germanShortDays)
+	require.Equal(t, "Montag, 4 März, 2024", f.FormatDate(d, "dmyaw"))
+	require.Equal(t, "04 März 2024 15:15", f.FormatDateTime(tm, "dmy24"))
+	f.AddDateTimeFormat("weekday", DateTimeFormat{Layout: "Mon, 2. Jan"})
+	require.Equal(t, "Mo., 4. März", f.FormatDateTime(tm, "weekday"))
+
+	f.SetHourCycle(HourCycle24)
+	require.Equal(t, "15:15 Montag, 4 März, 2024", f.FormatDateTime(tm, "dmy12aw"))
+	require.Equal(t, "04.03. 3:15pm", f.FormatDateTime(tm, "short"))
+	f.SetHourCycle(HourCycle12)
+	require.Equal(t, "Mar 04 2024 3:15 PM", NewDateFormats().SetHourCycle(HourCycle12).FormatDateTime(tm, "mdy24"))
+
+	require.Equal(t, "2024-03-04", f.FormatDate(d, "unknown"))
+	require.Equal(t, tm.String(), f.FormatDateTime(tm, "unknown"))
+	f.SetFallback("02/01/2006", "02/01/2006 15:04")
+	require.Equal(t, "04/03/2024", f.FormatDate(d, "unknown"))
+	require.Equal(t, "04/03/2024 15:15", f.FormatDateTime(tm, "unknown"))
+
+	c := f.Clone().AddDateFormat("iso", "20060102")
+	require.Equal(t, "20240304", c.FormatDate(d, "iso"))
+	require.Equal(t, "2024-03-04", f.FormatDate(d, "iso"))
+}
+
+func TestEngine_DateFormats(t *testing.T) {
+	engine := NewEngine()
+	engine.DateFormats().AddDateFormat("iso", "2006-01-02").SetHourCycle(HourCycle24)
+	bindings := map[string]any{
+		"day":     date.MustNewFromTime(time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)),
+		"created": time.Date(2024, time.March, 4, 15, 15, 0, 0, time.UTC),
+	}
+	tenant := engine.DateFormats().Clone().
+		AddDateFormat("iso", "02.01.2006").
+		SetMonthNames(germanMonths, germanShortMonths)
+
+	str, err := engine.ParseAndRenderString(`{{ day | dateFormatOrDefault: 'iso', '-' }} {{ created | dateTimeFormatOrDefault: 'mdy12', '-' }}`, bindings)
+	require.NoError(t, err)
+	require.Equal(t, "2024-03-04 Mar 04 2024 15:15", str)
+
+	str, err = engine.ParseAndRenderString(`{{ day | dateFormatOrDefault: 'iso', '-' }} {{ created | timeInTimezone: 'UTC', 'dmya' }}`, bindings, WithDateFormats(tenant))
+	require.NoError(t, err)
+	require.Equal(t, "04.03.2024 4 März, 2024", str)
+
+	// other engines keep the built-in formats
+	str, err = NewEngine().ParseAndRenderString(`{{ day | dateFormatOrDefault: 'iso', '-' }}`, bindings)
+	require.NoError(t, err)
+	require.Equal(t, "2024-03-04", str)
+}
@@ -3,8 +3,8 @@ package liquid
 import (
 	"context"
 	"fmt"
-	"html"
 	"io"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
@@ -14,12 +14,14 @@ import (
 	"github.com/autopilot3/ap3-types-go/types/date"
 	"github.com/autopilot3/ap3-types-go/types/phone"
 	"github.com/autopilot3/liquid/filters"
+	"github.com/autopilot3/liquid/lint"
 	"github.com/autopilot3/liquid/render"
 	"github.com/autopilot3/liquid/tags"
+	"github.com/autopilot3/liquid/values"
 
-	"github.com/bojanz/currency"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
+	"golang.org/x/text/number"
 )
 
 // An Engine parses template source into renderable text.
@@ -27,117 +29,6 @@ import (
 // An engine can be configured with additional filters and tags.
 type Engine struct{ cfg render.Config }
 
-type timeFormatSpec struct {
-	template      string
-	lowerMeridiem bool
-}
-
-var dateFormats = map[string]string{
-	"mdy":   "01/02/2006",
-	"dmy":   "02/01/2006",
-	"ymd":   "2006/01/02",
-	"ydm":   "2006/02/01",
-	"mdyaw": "Monday, January 2, 2006",
-	"mdya":  "January 2, 2006",
-	"mdys":  "1/2/06",
-	"dmyaw": "Monday, 2 January, 2006",
-	"dmya":  "2 January, 2006",
-	"dmys":  "2/1/06",
-	"d":     "2",
-	"dd":    "02",
-	"m":     "1",
-	"mm":    "01",
-	"yy":    "06",
-	"yyyy":  "2006",
-}
-
-var dateTimeFormats = map[string]timeFormatSpec{
-	"mdy12":   {template: "Jan 02 2006 3:04 PM"},
-	"mdy24":   {template: "Jan 02 2006 15:04"},
-	"dmy12":   {template: "02 Jan 2006 3:04 PM"},
-	"dmy24":   {template: "02 Jan 2006 15:04"},
-	"ymd12":   {template: "2006 Jan 02 3:04 PM"},
-	"ymd24":   {template: "2006 Jan 02 15:04"},
-	"ydm12":   {template: "2006 02 Jan 3:04 PM"},
-	"ydm24":   {template: "2006 02 Jan 15:04"},
-	"mdy24aw": {template: "15:04 Monday, January 2, 2006"},
-	"mdy12aw": {template: "3:04PM Monday, January 2, 2006", lowerMeridiem: true},
-	"mdyaw":   {template: "Monday, January 2, 2006"},
-	"mdy24a":  {template: "15:04 January 2, 2006"},
-	"mdy12a":  {template: "3:04PM January 2, 2006", lowerMeridiem: true},
-	"mdya":    {template: "January 2, 2006"},
-	"mdy24n":  {template: "15:04 01/02/2006"},
-	"mdy12n":  {template: "3:04PM 01/02/2006", lowerMeridiem: true},
-	"mdy24nd": {template: "01/02/2006 15:04"},
-	"mdy12nd": {template: "01/02/2006 3:04PM", lowerMeridiem: true},
-	"mdy":     {template: "01/02/2006"},
-	"mdys24":  {template: "15:04 1/2/06"},
-	"mdys12":  {template: "3:04PM 1/2/06", lowerMeridiem: true},
-	"mdys24d": {template: "1/2/06 15:04"},
-	"mdys12d": {template: "1/2/06 3:04PM", lowerMeridiem: true},
-	"mdys":    {template: "1/2/06"},
-	"dmy24aw": {template: "15:04 Monday, 2 January, 2006"},
-	"dmy12aw": {template: "3:04PM Monday, 2 January, 2006", lowerMeridiem: true},
-	"dmyaw":   {template: "Monday, 2 January, 2006"},
-	"dmy24a":  {template: "15:04 2 January, 2006"},
-	"dmy12a":  {template: "3:04PM 2 January, 2006", lowerMeridiem: true},
-	"dmya":    {template: "2 January, 2006"},
-	"dmy24n":  {template: "15:04 02/01/2006"},
-	"dmy12n":  {template: "3:04PM 02/01/2006", lowerMeridiem: true},
-	"dmy24nd": {template: "02/01/2006 15:04"},
-	"dmy12nd": {template: "02/01/2006 3:04PM", lowerMeridiem: true},
-	"dmy":     {template: "02/01/2006"},
-	"dmys24":  {template: "15:04 2/1/06"},
-	"dmys12":  {template: "3:04PM 2/1/06", lowerMeridiem: true},
-	"dmys24d": {template: "2/1/06 15:04"},
-	"dmys12d": {template: "2/1/06 3:04PM", lowerMeridiem: true},
-	"dmys":    {template: "2/1/06"},
-	"h24":     {template: "15"},
-	"h12":     {template: "3"},
-	"min":     {template: "04"},
-	"p":       {template: "PM", lowerMeridiem: true},
-	"d":       {template: "2"},
-	"dd":      {template: "02"},
-	"dow":     {template: "Monday"},
-	"m":       {template: "1"},
-	"mm":      {template: "01"},
-	"mon":     {template: "January"},
-	"yy":      {template: "06"},
-	"yyyy":    {template: "2006"},
-}
-
-func formatDate(d date.Date, format string) string {
-	t, err := d.Time()
-	if err != nil {
-		return d.String()
-	}
-
-	if layout, ok := dateFormats[format]; ok {
-		return t.Format(layout)
-	}
-
-	return d.String()
-}
-
-func priceFromLiquid(v interface{}) (amount int64, currencyCode string, ok bool) {
-	m, mok := v.(map[string]any)
-	if !mok {
-		return 0, "", false
-	}
-	currencyCode, _ = m["currency"].(string)
-	switch a := m["amount"].(type) {
-	case int64:
-		amount = a
-	case int:
-		amount = int64(a)
-	case float64:
-		amount = int64(a)
-	default:
-		return 0, "", false
-	}
-	return amount, currencyCode, true
-}
-
 func priceMaxDigits(format string) uint8 {
 	switch format {
 	case "whole":
@@ -166,32 +57,203 @@ func priceFormatNumber(num float64, format string, loc string) string {
 	return message.NewPrinter(tag).Sprintf(formatTemplate, num)
 }
 
-func lowerMeridiem(value string) string {
-	value = strings.ReplaceAll(value, "AM", "am")
-	return strings.ReplaceAll(value, "PM", "pm")
+// numberPrinter returns the message printer of a locale, or of English if the locale isn't valid.
+func numberPrinter(loc string) *message.Printer {
+	tag, err := language.Parse(loc)
+	if err != nil {
+		tag = language.English
+	}
+	return message.NewPrinter(tag)
+}
+
+// compactSuffixes are the CLDR short compact notation suffixes of thousands, millions,
+// billions and trillions, by language. An empty suffix means that the numbers
+// of that size aren't abbreviated. Other languages use the English suffixes.
+var compactSuffixes = map[string][]string{
+	"en": {"K", "M", "B", "T"},
+	"de": {"", "\u00a0Mio.", "\u00a0Mrd.", "\u00a0Bio."},
+	"fr": {"\u00a0k", "\u00a0M", "\u00a0Md", "\u00a0Bn"},
+	"es": {"\u00a0mil", "\u00a0M", "\u00a0mil\u00a0M", "\u00a0B"},
+	"it": {"", "\u00a0Mln", "\u00a0Mrd", "\u00a0Bln"},
+	"nl": {"K", "\u00a0mln.", "\u00a0mld.", "\u00a0bln."},
+	"pt": {"\u00a0mil", "\u00a0mi", "\u00a0bi", "\u00a0tri"},
+	"sv": {"\u00a0tn", "\u00a0mn", "\u00a0md", "\u00a0bn"},
 }
 
-func formatDateTime(t time.Time, format string) string {
-	if spec, ok := dateTimeFormats[format]; ok {
-		formatted := t.Format(spec.template)
-		if spec.lowerMeridiem {
-			return lowerMeridiem(formatted)
-		}
-		return formatted
+// compactFractionDigits returns the maximum fraction digits of compact notation.
+// It is one unless the format is "whole" or "two".
+func compactFractionDigits(format string) int {
+	switch format {
+	case "whole":
+		return 0
+	case "two":
+		return 2
+	default:
+		return 1
+	}
+}
+
+// roundTo rounds num to digits fraction digits.
+func roundTo(num float64, digits int) float64 {
+	scale := math.Pow(10, float64(digits))
+	return math.Round(num*scale) / scale
+}
+
+// formatCompactNumber formats a number in short compact notation, such as 1.2K in English
+// and 3,4 Mio. in German.
+func formatCompactNumber(num float64, format string, loc string) string {
+	p := numberPrinter(loc)
+	base, _ := language.Make(loc).Base()
+	suffixes, ok := compactSuffixes[base.String()]
+	if !ok {
+		suffixes = compactSuffixes["en"]
+	}
+	digits := compactFractionDigits(format)
+	power := 0
+	for power < len(suffixes) && math.Abs(num) >= math.Pow(1000, float64(power+1)) {
+		power++
 	}
+	scaled := roundTo(num/math.Pow(1000, float64(power)), digits)
+	if math.Abs(scaled) >= 1000 && power < len(suffixes) {
+		// 999,950 rounds to 1000K, which is 1M.
+		power++
+		scaled = roundTo(num/math.Pow(1000, float64(power)), digits)
+	}
+	switch {
+	case power == 0:
+		return p.Sprint(number.Decimal(num, number.MaxFractionDigits(digits)))
+	case suffixes[power-1] == "":
+		return p.Sprint(number.Decimal(num, number.MaxFractionDigits(0), number.NoSeparator()))
+	}
+	return p.Sprint(number.Decimal(scaled, number.MaxFractionDigits(digits))) + suffixes[power-1]
+}
+
+// formatPercentage formats a ratio as a percentage, such as 12.5% for 0.125.
+// The format is the maximum number of fraction digits.
+func formatPercentage(num float64, format string, loc string) string {
+	return numberPrinter(loc).Sprint(number.Percent(num, number.MaxFractionDigits(int(priceMaxDigits(format)))))
+}
 
-	return t.String()
+// signed returns a formatted number with a plus sign if the number, rounded
+// to the fraction digits of format, is positive.
+func signed(num float64, format string, formatted string) string {
+	if roundTo(num, int(priceMaxDigits(format))) > 0 {
+		return "+" + formatted
+	}
+	return formatted
+}
+
+// formatSignificant formats a number rounded to a number of significant digits,
+// keeping trailing zeros: 1,230 and 1.50 with three significant digits.
+func formatSignificant(num float64, digits int, loc string) string {
+	if digits < 1 {
+		digits = 1
+	}
+	e := strconv.FormatFloat(num, 'e', digits-1, 64)
+	rounded, _ := strconv.ParseFloat(e, 64)
+	exp, _ := strconv.Atoi(e[strings.IndexByte(e, 'e')+1:])
+	frac := digits - 1 - exp
+	if frac < 0 {
+		frac = 0
+	}
+	return numberPrinter(loc).Sprint(number.Decimal(rounded, number.MinFractionDigits(frac), number.MaxFractionDigits(frac)))
 }
 
+// SetAllowedTags makes rendering partial: only the variable paths in allowedTags, such as
+// "account" or "people.name", and their properties are known. Expressions whose variables
+// are all known are evaluated; the rest of the template is written as a residual
+// template, that renders the same output when it's rendered with the other variables.
+//
+// Conditions that are known remove the if, elsif and case branches that aren't taken.
+// The blocks that are left keep their tags and trim flags, with their bodies partially
+// rendered. Loops over known values are unrolled, and known assignments are kept in the
+// residual template with their values. The known values that residual expressions use
+// are written into them; arrays and maps are written with the parse_json filter. Known
+// output that contains Liquid delimiters is written as raw text.
+//
+// A loop whose unrolled body can't be written, because a residual expression uses an
+// element that can't be written in its place or a residual part has a loop control tag,
+// is left to the residual template. A known value that can't be written, such as a time,
+// can only be used by the expressions that are evaluated: rendering returns an error if
+// a residual expression uses it, rather than a residual template that renders differently.
 func (e *Engine) SetAllowedTags(allowedTags map[string]struct{}) *Engine {
 	e.cfg.AllowedTags = allowedTags
 	return e
 }
+
+// AllowedTagsWithDefault also evaluates the {{ objects }} of a partial render whose
+// unknown variables all have a default filter.
 func (e *Engine) AllowedTagsWithDefault() *Engine {
 	e.cfg.AllowTagsWithDefault = true
 	return e
 }
 
+// SetAutoEscape sets whether the output of each {{ object }} is escaped for its HTML context:
+// element text, an attribute value, a URL attribute value, or the content of a <script> or <style> element.
+//
+// Use the raw or safe filter, or a SafeHTML value, to write trusted markup verbatim.
+// The output of the escape and escape_once filters is not escaped again, and neither is a
+// captured variable, unless a filter that can change its markup, such as upcase, is applied
+// to it. The strip filters keep it.
+func (e *Engine) SetAutoEscape(enabled bool) *Engine {
+	e.cfg.AutoEscape = enabled
+	filters.AddEscapeFilters(&e.cfg, enabled)
+	return e
+}
+
+// SetLinkRewriter sets the LinkRewriter that rewrites the input of the trackURL filter,
+// for example to replace it with a click-tracking URL. Use WithLinkData to pass per-render
+// data, such as the recipient ID, to the rewriter.
+//
+// Without a LinkRewriter, trackURL writes a $$TRACK_ME:url$$ marker instead.
+func (e *Engine) SetLinkRewriter(r LinkRewriter) *Engine {
+	e.cfg.LinkRewriter = r
+	return e
+}
+
+// RewriteAllLinks sets whether the LinkRewriter also rewrites the href of every <a> element
+// in the rendered output that has an http or https URL, and that wasn't already rewritten by trackURL.
+func (e *Engine) RewriteAllLinks(enabled bool) *Engine {
+	e.cfg.RewriteAllLinks = enabled
+	return e
+}
+
+// SetLocale sets the default locale, a BCP 47 language tag such as "fr-CA", of the filters
+// that format numbers and prices. It applies when a template omits the locale argument.
+// Use WithLocale to override it for a render.
+func (e *Engine) SetLocale(locale string) *Engine {
+	e.cfg.SetContext(values.WithLocale(e.cfg.Context(), locale))
+	return e
+}
+
+// SetLocation sets the default time zone of the filters that parse and format dates and times.
+// It applies when a template omits the time zone argument, and to date strings without a time zone.
+// Use WithLocation to override it for a render.
+func (e *Engine) SetLocation(loc *time.Location) *Engine {
+	e.cfg.SetContext(values.WithLocation(e.cfg.Context(), loc))
+	return e
+}
+
+// DateFormats returns the engine's registry of the named formats of the dateFormatOrDefault,
+// dateTimeFormatOrDefault and timeInTimezone filters, to add formats to or configure.
+func (e *Engine) DateFormats() *DateFormats {
+	return e.cfg.DateFormats
+}
+
+// SetClock sets the clock for "now" and "today" and for the filters that compute times
+// relative to the current time, such as time_ago. The default is time.Now.
+func (e *Engine) SetClock(c Clock) *Engine {
+	e.cfg.SetContext(values.WithClock(e.cfg.Context(), c))
+	return e
+}
+
+// SetMoneyDisplay sets how the money, price and decimalWithDelimiter filters display
+// amounts of money with a currency.
+func (e *Engine) SetMoneyDisplay(d MoneyDisplay) *Engine {
+	e.cfg.MoneyDisplay = d
+	return e
+}
+
 // NewEngine returns a new Engine.
 func NewEngine() *Engine {
 	return NewEngineWithContext(context.Background())
@@ -200,6 +262,7 @@ func NewEngine() *Engine {
 // NewEngineWithContext returns a new Engine with the provided context
 func NewEngineWithContext(ctx context.Context) *Engine {
 	engine := &Engine{render.NewConfigWitchContext(ctx)}
+	engine.cfg.DateFormats = NewDateFormats()
 	filters.AddStandardFilters(&engine.cfg)
 	tags.AddStandardTags(engine.cfg)
 	engine.RegisterFilter("hideCountryCodeAndDefault", func(v interface{}, hide bool, defaultValue string) string {
@@ -216,27 +279,36 @@ func NewEngineWithContext(ctx context.Context) *Engine {
 		return s.String()
 	})
 
-	engine.RegisterFilter("timeInTimezone", func(s time.Time, timezone string, format string) string {
-		tz, err := time.LoadLocation(timezone)
-		if err != nil {
-			return ""
+	engine.RegisterFilter("timeInTimezone", func(ctx context.Context, s time.Time, timezone string, format string) string {
+		tz := values.Location(ctx)
+		if timezone != "" || tz == nil {
+			var err error
+			tz, err = time.LoadLocation(timezone)
+			if err != nil {
+				return ""
+			}
 		}
 		st := s.In(tz)
-		return formatDateTime(st, format)
+		return contextDateFormats(ctx).FormatDateTime(st, format)
 	})
 
 	engine.RegisterFilter("rawPhone", func(s phone.International) string {
 		return s.CountryCode.String() + s.Number.String()
 	})
 
-	engine.RegisterFilter("dateTimeFormatOrDefault", func(s time.Time, format string, defaultValue string) string {
+	engine.RegisterFilter("phone_format", phoneFormat)
+
+	engine.RegisterFilter("dateTimeFormatOrDefault", func(ctx context.Context, s time.Time, format string, defaultValue string) string {
 		if s.IsZero() {
 			return defaultValue
 		}
-		return formatDateTime(s, format)
+		if loc := values.Location(ctx); loc != nil {
+			s = s.In(loc)
+		}
+		return contextDateFormats(ctx).FormatDateTime(s, format)
 	})
 
-	engine.RegisterFilter("dateFormatOrDefault", func(s interface{}, format string, defaultValue string) string {
+	engine.RegisterFilter("dateFormatOrDefault", func(ctx context.Context, s interface{}, format string, defaultValue string) string {
 		var (
 			d   date.Date
 			err error
@@ -253,32 +325,24 @@ func NewEngineWithContext(ctx context.Context) *Engine {
 		if d.IsZero() {
 			return defaultValue
 		}
-		return formatDate(d, format)
+		return contextDateFormats(ctx).FormatDate(d, format)
 	})
 
-	engine.RegisterFilter("decimal", func(s string, format string, currency string) string {
+	engine.RegisterFilter("decimal", func(ctx context.Context, v interface{}, format string, currency string) string {
+		if amount, _, ok := priceFromLiquid(v); ok {
+			value := formatDecimal(amount, priceMaxDigits(format), values.Locale(ctx))
+			return currency + value
+		}
+		s := filterString(v)
 		if s == "" {
 			return s
 		}
-		num, err := strconv.ParseFloat(s, 64)
+		num, err := thousandths(s)
 		if err != nil {
 			logger.Warnw(engine.cfg.Context(), fmt.Sprintf("failed to parse field value %s to decimal: %s", s, err.Error()), "lqiuid", "filter")
 			return s
 		}
-		var formatTemplate string
-		switch format {
-		case "whole":
-			formatTemplate = "%.0f"
-		case "one":
-			formatTemplate = "%.1f"
-		case "two":
-			formatTemplate = "%.2f"
-		default:
-			formatTemplate = "%.2f"
-		}
-
-		p := message.NewPrinter(language.English)
-		value := p.Sprintf(formatTemplate, float64(num)/1000)
+		value := formatDecimal(num, priceMaxDigits(format), values.Locale(ctx))
 		if currency != "" {
 			return currency + value
 		}
@@ -286,66 +350,45 @@ func NewEngineWithContext(ctx context.Context) *Engine {
 		return value
 	})
 
-	engine.RegisterFilter("decimalWithDelimiter", func(s string, format string, currencyCode string, loc string) string {
-		if s == "" {
-			return s
+	engine.RegisterFilter("decimalWithDelimiter", func(ctx context.Context, v interface{}, format string, currencyCode string, loc string) string {
+		loc = localeOrDefault(ctx, loc)
+		if m, err := moneyFromLiquid(v); err == nil {
+			return formatMoney(m, priceMaxDigits(format), loc, contextMoneyDisplay(ctx))
 		}
-		num, err := strconv.ParseFloat(s, 64)
-		if err != nil {
-			logger.Warnw(engine.cfg.Context(), fmt.Sprintf("failed to parse field value %s to decimal: %s", s, err.Error()), "lqiuid", "filter")
-			return s
+		num, _, ok := priceFromLiquid(v)
+		s := filterString(v)
+		if !ok {
+			if s == "" {
+				return s
+			}
+			var err error
+			if num, err = thousandths(s); err != nil {
+				logger.Warnw(engine.cfg.Context(), fmt.Sprintf("failed to parse field value %s to decimal: %s", s, err.Error()), "lqiuid", "filter")
+				return s
+			}
 		}
 
 		if isoCode := strings.TrimSpace(currencyCode); len(isoCode) == 3 { // iso code
-			amount, err := currency.NewAmount(fmt.Sprintf("%.3f", num/1000), isoCode)
+			m, err := ratMoney(num, isoCode)
 			if err == nil {
-				locale := currency.NewLocale(loc)
-				formatter := currency.NewFormatter(locale)
-
-				switch format {
-				case "whole":
-					formatter.MaxDigits = 0
-				case "one":
-					formatter.MaxDigits = 1
-				case "two":
-					formatter.MaxDigits = 2
-				default:
-					formatter.MaxDigits = 2
-				}
-				return formatter.Format(amount)
+				return formatMoney(m, priceMaxDigits(format), loc, contextMoneyDisplay(ctx))
 			} else {
 				// log and fallback to the previous logic
 				logger.Warnw(engine.cfg.Context(), fmt.Sprintf("failed to parse field value %s with currency code %s to decimal: %s", s, isoCode, err.Error()), "lqiuid", "filter")
 			}
 		}
-		var formatTemplate string
-		switch format {
-		case "whole":
-			formatTemplate = "%.0f"
-		case "one":
-			formatTemplate = "%.1f"
-		case "two":
-			formatTemplate = "%.2f"
-		default:
-			formatTemplate = "%.2f"
-		}
-
-		tag, err := language.Parse(loc)
-		if err != nil {
-			tag = language.English
-		}
-		p := message.NewPrinter(tag)
-		val := p.Sprintf(formatTemplate, num/1000)
+		val := formatDecimal(num, priceMaxDigits(format), loc)
 		if currencyCode != "" {
 			return currencyCode + val
 		}
 		return val
 	})
 
-	engine.RegisterFilter("numberWithDelimiter", func(s string, loc string, format string) string {
+	engine.RegisterFilter("numberWithDelimiter", func(ctx context.Context, s string, loc string, format string) string {
 		if s == "" {
 			return s
 		}
+		loc = localeOrDefault(ctx, loc)
 		num, err := strconv.ParseFloat(s, 64)
 		if err != nil {
 			logger.Warnw(engine.cfg.Context(), fmt.Sprintf("failed to parse field value %s to decimal: %s", s, err.Error()), "lqiuid", "filter")
@@ -373,32 +416,82 @@ func NewEngineWithContext(ctx context.Context) *Engine {
 		return value
 	})
 
-	engine.RegisterFilter("price", func(v interface{}, mode string, format string, loc string) string {
+	engine.RegisterFilter("compactNumber", func(ctx context.Context, s string, loc string, format string) string {
+		num, ok := engine.parseNumber(s)
+		if !ok {
+			return s
+		}
+		return formatCompactNumber(num, format, localeOrDefault(ctx, loc))
+	})
+
+	engine.RegisterFilter("percentage", func(ctx context.Context, s string, loc string, format string) string {
+		num, ok := engine.parseNumber(s)
+		if !ok {
+			return s
+		}
+		return formatPercentage(num, format, localeOrDefault(ctx, loc))
+	})
+
+	engine.RegisterFilter("signedNumber", func(ctx context.Context, s string, loc string, format string) string {
+		num, ok := engine.parseNumber(s)
+		if !ok {
+			return s
+		}
+		if roundTo(num, int(priceMaxDigits(format))) == 0 {
+			num = 0 // no -0.00
+		}
+		return signed(num, format, priceFormatNumber(num, format, localeOrDefault(ctx, loc)))
+	})
+
+	engine.RegisterFilter("signedPercentage", func(ctx context.Context, s string, loc string, format string) string {
+		num, ok := engine.parseNumber(s)
+		if !ok {
+			return s
+		}
+		if roundTo(num*100, int(priceMaxDigits(format))) == 0 {
+			num = 0
+		}
+		return signed(num*100, format, formatPercentage(num, format, localeOrDefault(ctx, loc)))
+	})
+
+	engine.RegisterFilter("significantDigits", func(ctx context.Context, s string, digits int, loc string) string {
+		num, ok := engine.parseNumber(s)
+		if !ok {
+			return s
+		}
+		return formatSignificant(num, digits, localeOrDefault(ctx, loc))
+	})
+
+	engine.RegisterFilter("price", func(ctx context.Context, v interface{}, mode string, format string, loc string) string {
 		amount, currencyCode, ok := priceFromLiquid(v)
 		if !ok {
 			return ""
 		}
+		loc = localeOrDefault(ctx, loc)
 		switch mode {
 		case "currency":
 			return currencyCode
 		case "value":
-			return priceFormatNumber(float64(amount)/1000, format, loc)
+			return formatDecimal(amount, priceMaxDigits(format), loc)
 		default: // "currency_value"
-			num := float64(amount) / 1000
 			isoCode := strings.TrimSpace(currencyCode)
 			if len(isoCode) == 3 {
-				am, err := currency.NewAmount(fmt.Sprintf("%.3f", num), isoCode)
+				m, err := ratMoney(amount, isoCode)
 				if err == nil {
-					formatter := currency.NewFormatter(currency.NewLocale(loc))
-					formatter.MaxDigits = priceMaxDigits(format)
-					return formatter.Format(am)
+					return formatMoney(m, priceMaxDigits(format), loc, contextMoneyDisplay(ctx))
 				}
-				logger.Warnw(engine.cfg.Context(), fmt.Sprintf("failed to format price %f with currency code %s: %s", num, isoCode, err), "liquid", "filter")
+				logger.Warnw(engine.cfg.Context(), fmt.Sprintf("failed to format price %s with currency code %s: %s", amount.FloatString(3), isoCode, err), "liquid", "filter")
 			}
-			return currencyCode + priceFormatNumber(num, format, loc)
+			return currencyCode + formatDecimal(amount, priceMaxDigits(format), loc)
 		}
 	})
 
+	engine.RegisterFilter("money", money)
+	engine.RegisterFilter("money_plus", moneyPlus)
+	engine.RegisterFilter("money_times", moneyTimes)
+	engine.RegisterFilter("money_round", moneyRound)
+	engine.RegisterFilter("convert_currency", convertCurrency)
+
 	engine.RegisterFilter("booleanFormat", func(s string, format string) string {
 		if s == "" {
 			return ""
@@ -499,10 +592,13 @@ func NewEngineWithContext(ctx context.Context) *Engine {
 		return false
 	})
 
-	// trackURL here is a dummy filter, it is used to avoid error when parsing liquid template. Services support this filter will replace it with real filter
-	engine.RegisterFilter("trackURL", func(s string) string {
-		return "$$TRACK_ME:" + html.UnescapeString(s) + "$$"
-	})
+	// trackURL rewrites a URL with the engine's LinkRewriter. See Engine.SetLinkRewriter.
+	engine.RegisterFilter("trackURL", trackURL)
+
+	// t translates a message key with the engine's catalog. See Engine.SetCatalog.
+	engine.RegisterFilter("t", translate)
+	engine.RegisterFilter("pluralize", pluralize)
+	engine.RegisterFilter("ordinal", ordinal)
 
 	engine.RegisterFilter("startsWith", func(s string, prefix string) bool {
 		return strings.HasPrefix(s, prefix)
@@ -515,6 +611,29 @@ func NewEngineWithContext(ctx context.Context) *Engine {
 	return engine
 }
 
+// filterString returns the string value of a filter input, as for a string parameter.
+func filterString(v interface{}) string {
+	s, err := values.Convert(v, reflect.TypeOf(""))
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return s.(string)
+}
+
+// parseNumber parses the value of a number formatting filter. It reports false if the
+// value is empty or isn't a number, and logs a warning in the second case.
+func (e *Engine) parseNumber(s string) (float64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	num, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		logger.Warnw(e.cfg.Context(), fmt.Sprintf("failed to parse field value %s to decimal: %s", s, err.Error()), "liquid", "filter")
+		return 0, false
+	}
+	return num, true
+}
+
 // RegisterBlock defines a block e.g. {% tag %}…{% endtag %}.
 func (e *Engine) RegisterBlock(name string, td Renderer) {
 	e.cfg.AddBlock(name).Renderer(func(w io.Writer, ctx render.Context) error {
@@ -541,6 +660,20 @@ func (e *Engine) RegisterFilter(name string, fn interface{}) {
 	e.cfg.AddFilter(name, fn)
 }
 
+// DeprecateFilter marks a filter as deprecated, in favor of replacement if that isn't
+// empty. Deprecated filters still work; Lint reports their use.
+func (e *Engine) DeprecateFilter(name, replacement string) {
+	e.cfg.DeprecateFilter(name, replacement)
+}
+
+// Lint checks a template for mistakes that rendering only reports on the path that it
+// takes, if at all, with the engine's filters. See the lint package for the checks.
+//
+// Lines are numbered from the line passed to ParseTemplateLocation, or from 1.
+func (e *Engine) Lint(t *Template) []LintFinding {
+	return lint.Lint(t.root, &e.cfg, t.line)
+}
+
 // RegisterTag defines a tag e.g. {% tag %}.
 //
 // Further examples are in https://github.com/osteele/gojekyll/blob/master/tags/tags.go
@@ -579,17 +712,17 @@ func (e *Engine) ParseTemplateLocation(source []byte, path string, line int) (*T
 }
 
 // ParseAndRender parses and then renders the template.
-func (e *Engine) ParseAndRender(source []byte, b Bindings) ([]byte, SourceError) {
+func (e *Engine) ParseAndRender(source []byte, b Bindings, opts ...RenderOption) ([]byte, SourceError) {
 	tpl, err := e.ParseTemplate(source)
 	if err != nil {
 		return nil, err
 	}
-	return tpl.Render(b)
+	return tpl.Render(b, opts...)
 }
 
 // ParseAndRenderString is a convenience wrapper for ParseAndRender, that takes string input and returns a string.
-func (e *Engine) ParseAndRenderString(source string, b Bindings) (string, SourceError) {
-	bs, err := e.ParseAndRender([]byte(source), b)
+func (e *Engine) ParseAndRenderString(source string, b Bindings, opts ...RenderOption) (string, SourceError) {
+	bs, err := e.ParseAndRender([]byte(source), b, opts...)
 	if err != nil {
 		return "", err
 	}
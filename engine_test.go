@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/autopilot3/ap3-types-go/types/date"
+	"github.com/autopilot3/liquid/lint"
 	"github.com/stretchr/testify/require"
 )
 
@@ -122,6 +123,134 @@ func TestCustomFilter(t *testing.T) {
 	require.Equal(t, "Dec 11 2022 10:02 PM", str)
 }
 
+func TestEngine_SetAutoEscape(t *testing.T) {
+	params := map[string]interface{}{
+		"s":    `<b>"x"</b>`,
+		"js":   "javascript:alert(1)",
+		"list": []string{"a<b", "c&d"},
+	}
+	tests := []struct{ in, expected string }{
+		{`<p>{{ s }}</p>`, `<p>&lt;b&gt;&#34;x&#34;&lt;/b&gt;</p>`},
+		{`<p>{{ s | raw }}</p>`, `<p><b>"x"</b></p>`},
+		{`<p>{{ s | safe }}</p>`, `<p><b>"x"</b></p>`},
+		{`<p>{{ s | escape }}</p>`, `<p>&lt;b&gt;&#34;x&#34;&lt;/b&gt;</p>`},
+		{`<p>{{ s | escape_once }}</p>`, `<p>&lt;b&gt;&#34;x&#34;&lt;/b&gt;</p>`},
+		{`{% capture c %}<i>{{ s }}</i>{% endcapture %}<p>{{ c }}</p>`, `<p><i>&lt;b&gt;&#34;x&#34;&lt;/b&gt;</i></p>`},
+		// captures stay safe through filters that keep their markup, but not others
+		{"{% capture c %} <i>a</i>\n{% endcapture %}{{ c | strip }}|{{ c | strip_newlines }}", "<i>a</i>| <i>a</i>"},
+		{`{% capture c %}<i>a</i>{% endcapture %}{{ c | upcase }}`, `&lt;I&gt;A&lt;/I&gt;`},
+		{`{% raw %}<a href="{% endraw %}{{ js }}">`, `<a href="about:invalid#zLiquidz">`},
+		{`{% for i in list %}<i title="{{ i }}">{% endfor %}`, `<i title="a&lt;b"><i title="c&amp;d">`},
+		{`{% if true %}<a href="{% endif %}{{ js }}">`, `<a href="about:invalid#zLiquidz">`},
+	}
+	engine := NewEngine().SetAutoEscape(true)
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i+1), func(t *testing.T) {
+			str, err := engine.ParseAndRenderString(test.in, params)
+			require.NoErrorf(t, err, test.in)
+			require.Equalf(t, test.expected, str, test.in)
+		})
+	}
+
+	str, err := NewEngine().ParseAndRenderString(`<p>{{ s }}</p>`, params)
+	require.NoError(t, err)
+	require.Equal(t, `<p><b>"x"</b></p>`, str)
+
+	// strip_html decodes character references, and auto-escaping escapes its output
+	str, err = NewEngine().SetAutoEscape(true).ParseAndRenderString(`{{ "&lt;script&gt;alert(1)&lt;/script&gt; & <b>x</b>" | strip_html }}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, `&lt;script&gt;alert(1)&lt;/script&gt; &amp; x`, str)
+}
+
+func TestEngine_SetAllowedTags(t *testing.T) {
+	account := map[string]interface{}{
+		"name":  "Acme",
+		"vip":   true,
+		"trial": false,
+		"plan":  "basic",
+		"tags":  []string{"a", "b"},
+		"list":  []int{1, 2},
+		"pairs": [][]string{{"a", "b"}},
+		"quote": `it's "x"`,
+		"note":  "{{ people.secret }}",
+		"trick": "{{ x {% endraw %}",
+		"n":     3,
+		"t":     time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	people := map[string]interface{}{
+		"name":        "bob",
+		"name_suffix": "Jr",
+		"age":         30,
+		"vip":         false,
+		"email":       "bob@example.com",
+		"plan":        "pro",
+		"orders":      []map[string]interface{}{{"id": 1}, {"id": 2}},
+		"tags":        []string{"b"},
+		"secret":      "SECRET",
+	}
+	tests := []struct{ in, expected string }{
+		{`{{ people.name }} {{ people.name_suffix }}`, `bob {{ people.name_suffix }}`},
+		{`{% if account.vip %}VIP{% else %}{{ people.name }}{% endif %}`, `VIP`},
+		{`{% if account.trial %}trial{% else %}{{ people.email }}{% endif %}`, `{{ people.email }}`},
+		{`{% if people.age > 18 %}adult{% else %}{{ account.name }}{% endif %}`, `{% if people.age > 18 %}adult{% else %}Acme{% endif %}`},
+		{`{% if people.age > 18 %}adult{% elsif account.vip %}vip{% else %}other{% endif %}`, `{% if people.age > 18 %}adult{% else %}vip{% endif %}`},
+		{`{% if account.trial %}trial{%- elsif people.vip -%}vip{% endif %}`, `{%- if people.vip -%}vip{% endif %}`},
+		{`{%- if people.vip -%} yes {%- else -%} no {%- endif -%}`, `{%- if people.vip -%} yes {%- else -%} no {%- endif -%}`},
+		{`{% unless people.vip %}{{ account.name }}{% endunless %}`, `{% unless people.vip %}Acme{% endunless %}`},
+		{`{% case people.plan %}{% when 'pro' %}{{ account.name }}{% else %}-{% endcase %}`, `{% case people.plan %}{% when 'pro' %}Acme{% else %}-{% endcase %}`},
+		{`{% case account.plan %}{% when 'pro' %}pro{% else %}{{ people.name_suffix }}{% endcase %}`, `{{ people.name_suffix }}`},
+		{`{% for t in account.tags %}{{ t }}{{ people.email }},{% endfor %}`, `a{{ people.email }},b{{ people.email }},`},
+		{`{% for o in people.orders %}{{ o.id }}{{ account.name }}{% endfor %}`, `{% for o in people.orders %}{{ o.id }}Acme{% endfor %}`},
+		{`{% assign n = account.name | upcase %}{{ n }} {{ n | append: people.name_suffix }}`, `{% assign n = 'ACME' %}ACME {{ n | append: people.name_suffix }}`},
+		{`{% assign e = people.email %}{{ e }}`, `{% assign e = people.email %}{{ e }}`},
+		{`{% if people.vip %}{% assign n = 1 %}{% endif %}{{ n }}`, `{% if people.vip %}{% assign n = 1 %}{% endif %}{{ n }}`},
+		{`{% raw %}{{ account.name }}{% endraw %}`, `{% raw %}{{ account.name }}{% endraw %}`},
+		{`{{ account.name | append: people.email }}`, `{{ 'Acme' | append: people.email }}`},
+		{`{% for t in account.tags %}{{ t | append: people.email }};{% endfor %}`, `{{ 'a' | append: people.email }};{{ 'b' | append: people.email }};`},
+		{`{% for t in account.tags %}{{ forloop.index | plus: people.age }},{% endfor %}`, `{{ 1 | plus: people.age }},{{ 2 | plus: people.age }},`},
+		{`{% for t in account.tags %}{% if people.tags contains t %}{{ t }}{% endif %}{% endfor %}`, `{% if people.tags contains 'a' %}a{% endif %}{% if people.tags contains 'b' %}b{% endif %}`},
+		{`{% for p in account.pairs %}{{ people.name_suffix | append: p }}{% endfor %}`, `{% for p in '[["a","b"]]' | parse_json %}{{ people.name_suffix | append: p }}{% endfor %}`},
+		{`{% assign l = account.list %}{{ l | join: people.email }}`, `{% assign l = '[1,2]' | parse_json %}{{ l | join: people.email }}`},
+		{`{% assign q = account.quote %}{{ q | append: people.email }}`, `{% assign q = '"it\u0027s \"x\""' | parse_json %}{{ q | append: people.email }}`},
+		{`{% if people.vip %}{% for t in account.tags %}{{t}}{% break %}{% endfor %}{% endif %}`, `{% if people.vip %}a{% endif %}`},
+		{`{% for t in account.tags %}{% if people.vip %}{% break %}{% endif %}{{ t }}{% endfor %}`, `{% for t in '["a","b"]' | parse_json %}{% if people.vip %}{% break %}{% endif %}{{ t }}{% endfor %}`},
+		{`{{ account.note }} {{ people.name_suffix }}`, `{% raw %}{{ people.secret }}{% endraw %} {{ people.name_suffix }}`},
+		{`{{ account.trick }} {{ people.name_suffix }}`, `{{ '{' }}{{ '{' }} x {{ '{' }}% endraw %} {{ people.name_suffix }}`},
+		{`{% capture c %}{{ account.note }}{% endcapture %}{{ c | size }}{{ c | append: people.email }}`, `{% capture c %}{% raw %}{{ people.secret }}{% endraw %}{% endcapture %}19{{ c | append: people.email }}`},
+		{`{% assign x = people.age %}{% assign y = x | plus: account.n %}{{ y }}`, `{% assign x = people.age %}{% assign y = x | plus: 3 %}{{ y }}`},
+		{`{% assign d = account.t %}{{ d | date: '%Y' }}{{ people.email }}`, `2024{{ people.email }}`},
+	}
+	engine := NewEngine().SetAllowedTags(map[string]struct{}{"people.name": {}, "account": {}})
+	all := map[string]interface{}{"account": account, "people": people}
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i+1), func(t *testing.T) {
+			residual, err := engine.ParseAndRenderString(test.in, map[string]interface{}{
+				"account": account,
+				"people":  map[string]interface{}{"name": people["name"]},
+			})
+			require.NoErrorf(t, err, test.in)
+			require.Equalf(t, test.expected, residual, test.in)
+
+			// Rendering the residual template with the other variables renders the template.
+			expected, err := NewEngine().ParseAndRenderString(test.in, all)
+			require.NoError(t, err)
+			actual, err := NewEngine().ParseAndRenderString(residual, map[string]interface{}{"people": people})
+			require.NoError(t, err)
+			require.Equalf(t, expected, actual, test.in)
+		})
+	}
+
+	// A residual expression can't use a known value that can't be written.
+	for _, in := range []string{
+		`{{ people.email | append: account.t }}`,
+		`{% assign d = account.t %}{{ d | date: people.plan }}`,
+		`{% if people.vip %}{% assign d = account.t %}{% endif %}`,
+	} {
+		_, err := engine.ParseAndRenderString(in, map[string]interface{}{"account": account})
+		require.Errorf(t, err, in)
+	}
+}
+
 func TestDateFilter(t *testing.T) {
 	engine := NewEngine()
 	template := `{% assign vardays = 30 | times: 24 | times: 60 | times: 60 %}{{ 'now' | date: "%s" | plus: vardays | date: "%d/%m/%Y" }}`
@@ -221,6 +350,129 @@ func TestPriceFilters(t *testing.T) {
 	}
 }
 
+func TestEngine_SetLocaleAndLocation(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	require.NoError(t, err)
+	engine := NewEngine().SetLocale("de").SetLocation(sydney)
+	bindings := map[string]any{
+		"eur":     map[string]any{"amount": int64(1234500), "currency": "EUR"},
+		"created": time.Date(2022, 12, 11, 14, 2, 3, 0, time.UTC),
+	}
+	tests := []struct {
+		name          string
+		liquid        string
+		opts          []RenderOption
+		expectedValue string
+	}{
+		{"numberWithDelimiter", `{{ 1234567 | numberWithDelimiter }}`, nil, "1.234.567,00"},
+		{"numberWithDelimiter argument", `{{ 1234567 | numberWithDelimiter: 'en' }}`, nil, "1,234,567.00"},
+		{"decimal", `{{ 1234567 | decimal }}`, nil, "1.234,57"},
+		{"decimalWithDelimiter", `{{ 12345 | decimalWithDelimiter: 'one', 'EUR' }}`, nil, "12,3\u00a0€"},
+		{"price", `{{ eur | price: 'currency_value', 'two' }}`, nil, "1.234,50\u00a0€"},
+		{"price render locale", `{{ eur | price: 'currency_value', 'two' }}`, []RenderOption{WithLocale("en")}, "€1,234.50"},
+		{"date", `{{ created | date: '%H:%M' }}`, nil, "01:02"},
+		{"date string", `{{ '2022-12-11 10:00' | date: '%H:%M %z' }}`, nil, "10:00 +1100"},
+		{"date render location", `{{ created | date: '%H:%M' }}`, []RenderOption{WithLocation(time.UTC)}, "14:02"},
+		{"timeInTimezone", `{{ created | timeInTimezone: '', 'mdy24' }}`, nil, "Dec 12 2022 01:02"},
+		{"timeInTimezone argument", `{{ created | timeInTimezone: 'Asia/Shanghai', 'mdy24' }}`, nil, "Dec 11 2022 22:02"},
+		{"dateTimeFormatOrDefault", `{{ created | dateTimeFormatOrDefault: 'mdy24', '-' }}`, nil, "Dec 12 2022 01:02"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			str, err := engine.ParseAndRenderString(test.liquid, bindings, test.opts...)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedValue, str)
+		})
+	}
+
+	// without defaults
+	str, err := NewEngine().ParseAndRenderString(`{{ created | timeInTimezone: '', 'mdy24' }} {{ 1234567 | decimal }}`, bindings)
+	require.NoError(t, err)
+	require.Equal(t, "Dec 11 2022 14:02 1,234.57", str)
+}
+
+func TestEngine_SetClock(t *testing.T) {
+	sendTime := time.Date(2024, 6, 12, 15, 30, 0, 0, time.UTC)
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	engine := NewEngine().SetLocation(time.UTC).SetClock(func() time.Time { return sendTime })
+	bindings := map[string]any{
+		"trial_end": time.Date(2024, 6, 17, 9, 0, 0, 0, time.UTC),
+		"sent_on":   time.Date(2024, 6, 12, 0, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name          string
+		liquid        string
+		opts          []RenderOption
+		expectedValue string
+	}{
+		{"now", `{{ 'now' | date: '%F %R' }}`, nil, "2024-06-12 15:30"},
+		{"today", `{{ 'today' | date: '%F %R' }}`, nil, "2024-06-12 00:00"},
+		{"time parameter", `{{ 'now' | timeInTimezone: 'Asia/Shanghai', 'mdy24' }}`, nil, "Jun 12 2024 23:30"},
+		{"time_until", `{{ trial_end | time_until }}`, nil, "in 5 days"},
+		{"date_diff", `{{ trial_end | date_diff: 'today', 'days' }}`, nil, "5"},
+		{"render clock", `{{ 'now' | date: '%F' }}`, []RenderOption{WithClock(func() time.Time { return sendTime.AddDate(0, 0, 3) })}, "2024-06-15"},
+		{"render clock time_until", `{{ trial_end | time_until }}`, []RenderOption{WithClock(func() time.Time { return sendTime.AddDate(0, 0, 3) })}, "in 2 days"},
+		{"comparison", `{% if trial_end > 'now' %}active{% else %}expired{% endif %}`, nil, "active"},
+		{"render clock comparison", `{% if trial_end > 'now' %}active{% else %}expired{% endif %}`, []RenderOption{WithClock(func() time.Time { return sendTime.AddDate(0, 0, 10) })}, "expired"},
+		{"comparison time zone", `{% if trial_end < '2024-06-17 10:00:00' %}before{% else %}after{% endif %}`, nil, "before"},
+		{"render location comparison", `{% if trial_end < '2024-06-17 10:00:00' %}before{% else %}after{% endif %}`, []RenderOption{WithLocation(shanghai)}, "after"},
+		{"case", `{% case sent_on %}{% when 'today' %}sent today{% endcase %}`, nil, "sent today"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			str, err := engine.ParseAndRenderString(test.liquid, bindings, test.opts...)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedValue, str)
+		})
+	}
+}
+
+func TestNumberFormatFilters(t *testing.T) {
+	engine := NewEngine()
+	tests := []struct {
+		name          string
+		liquid        string
+		expectedValue string
+	}{
+		{"compactNumber", `{{ 1234 | compactNumber }}`, "1.2K"},
+		{"compactNumber small", `{{ 999.27 | compactNumber }}`, "999.3"},
+		{"compactNumber whole", `{{ 1000 | compactNumber }}`, "1K"},
+		{"compactNumber rounds up", `{{ 999950 | compactNumber }}`, "1M"},
+		{"compactNumber negative", `{{ -2500000000 | compactNumber: 'en', 'two' }}`, "-2.5B"},
+		{"compactNumber trillions", `{{ 1234000000000000 | compactNumber }}`, "1,234T"},
+		{"compactNumber de", `{{ 3400000 | compactNumber: 'de' }}`, "3,4\u00a0Mio."},
+		{"compactNumber de thousands", `{{ 12345 | compactNumber: 'de' }}`, "12345"},
+		{"compactNumber fr", `{{ 1500 | compactNumber: 'fr' }}`, "1,5\u00a0k"},
+		{"compactNumber unknown language", `{{ 1500 | compactNumber: 'ja' }}`, "1.5K"},
+		{"percentage", `{{ 0.125 | percentage }}`, "12.5%"},
+		{"percentage whole", `{{ 0.125 | percentage: 'en', 'whole' }}`, "12%"},
+		{"percentage de", `{{ 0.125 | percentage: 'de' }}`, "12,5\u00a0%"},
+		{"signedNumber", `{{ 1234.5 | signedNumber }}`, "+1,234.50"},
+		{"signedNumber negative", `{{ -3 | signedNumber: 'en', 'whole' }}`, "-3"},
+		{"signedNumber zero", `{{ -0.001 | signedNumber }}`, "0.00"},
+		{"signedPercentage", `{{ 0.125 | signedPercentage: 'en', 'one' }}`, "+12.5%"},
+		{"signedPercentage negative", `{{ -0.04 | signedPercentage }}`, "-4%"},
+		{"signedPercentage zero", `{{ 0.00001 | signedPercentage }}`, "0%"},
+		{"significantDigits", `{{ 1234.5678 | significantDigits: 3 }}`, "1,230"},
+		{"significantDigits fraction", `{{ 1.5 | significantDigits: 3 }}`, "1.50"},
+		{"significantDigits small", `{{ 0.000123456 | significantDigits: 2, 'de' }}`, "0,00012"},
+		{"invalid", `{{ 'abc' | compactNumber }}`, "abc"},
+		{"empty", `{{ '' | percentage }}`, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			str, err := engine.ParseAndRenderString(test.liquid, nil)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedValue, str)
+		})
+	}
+
+	str, err := engine.ParseAndRenderString(`{{ 0.125 | percentage }}`, nil, WithLocale("fr"))
+	require.NoError(t, err)
+	require.Equal(t, "12,5\u00a0%", str)
+}
+
 func TestFindVariables(t *testing.T) {
 	engine := NewEngine()
 
@@ -249,6 +501,117 @@ func TestFindVariables(t *testing.T) {
 	{% endfor %}`,
 			expectedVars: `{"people.companies":{"Loop":true,"Attributes":{"instances":{"Loop":true,"Attributes":{"name":{"Loop":false,"Attributes":null}}},"name":{"Loop":false,"Attributes":null}}}}`,
 		},
+		{
+			name: "3 levels loop",
+			liquid: `{% for line in order.lines %}
+		{% for component in line.components %}
+			{% for attribute in component.attributes %}
+				{{ line.sku }}
+				{{ attribute.name }}
+			{% endfor %}
+		{% endfor %}
+	{% endfor %}`,
+			expectedVars: `{"order.lines":{"Loop":true,"Attributes":{"components":{"Loop":true,"Attributes":{"attributes":{"Loop":true,"Attributes":{"name":{"Loop":false,"Attributes":null}}}}},"sku":{"Loop":false,"Attributes":null}}}}`,
+		},
+		{
+			name: "3 levels loop which uses var of grandparent loop",
+			liquid: `{% for bundle in order.bundles %}
+		{% for line in bundle.lines %}
+			{% for option in bundle.options %}
+				{{ line.sku }}
+				{{ option.label }}
+			{% endfor %}
+		{% endfor %}
+	{% endfor %}`,
+			expectedVars: `{"order.bundles":{"Loop":true,"Attributes":{"lines":{"Loop":true,"Attributes":{"sku":{"Loop":false,"Attributes":null}}},"options":{"Loop":true,"Attributes":{"label":{"Loop":false,"Attributes":null}}}}}}`,
+		},
+		{
+			name: "nested loop var shadows outer loop var",
+			liquid: `{% for item in order.lines %}
+		{% for item in item.components %}
+			{{ item.name }}
+		{% endfor %}
+	{% endfor %}`,
+			expectedVars: `{"order.lines":{"Loop":true,"Attributes":{"components":{"Loop":true,"Attributes":{"name":{"Loop":false,"Attributes":null}}}}}}`,
+		},
+		{
+			name: "loop over an array of arrays",
+			liquid: `{% for m in matrix %}
+		{% for c in m %}
+			{{ c.v }}
+		{% endfor %}
+	{% endfor %}`,
+			expectedVars: `{"matrix":{"Loop":true,"Attributes":{"":{"Loop":true,"Attributes":{"v":{"Loop":false,"Attributes":null}}}}}}`,
+		},
+		{
+			name: "loop over values",
+			liquid: `{% for tag in contact.tags %}
+		{{ tag }}
+	{% endfor %}`,
+			expectedVars: `{"contact.tags":{"Loop":true,"Attributes":{}}}`,
+		},
+		{
+			name: "loop over assigned variable",
+			liquid: `{% assign offers = contact.custom.offers | default: '' %}
+	{% for o in offers %}
+		{{ o.title }}
+	{% endfor %}`,
+			expectedVars: `{"contact.custom.offers":{"Loop":true,"Attributes":{"title":{"Loop":false,"Attributes":null}}}}`,
+		},
+		{
+			name: "assigned variable with a filter argument",
+			liquid: `{% assign items = order.items | default: fallback.items %}
+	{% for item in items %}
+		{{ item.sku }}
+	{% endfor %}`,
+			expectedVars: `{"fallback.items":{"Loop":false,"Attributes":null},"order.items":{"Loop":true,"Attributes":{"sku":{"Loop":false,"Attributes":null}}}}`,
+		},
+		{
+			name: "assigned variable in a loop",
+			liquid: `{% for line in order.lines %}
+		{% assign product = line.product %}
+		{{ product.name }}
+	{% endfor %}`,
+			expectedVars: `{"order.lines":{"Loop":true,"Attributes":{"product.name":{"Loop":false,"Attributes":null}}}}`,
+		},
+		{
+			name: "assigned variable",
+			liquid: `{% assign product = order.product %}
+	{{ product.name }}`,
+			expectedVars: `{"order.product.name":{"Loop":false,"Attributes":null}}`,
+		},
+		{
+			name: "assigned variable used before",
+			liquid: `{{ order.product }}{% assign product = order.product %}
+	{{ product.name }}`,
+			expectedVars: `{"order.product":{"Loop":false,"Attributes":null},"order.product.name":{"Loop":false,"Attributes":null}}`,
+		},
+		{
+			name:         "variable assigned through a filter",
+			liquid:       `{% assign x = a | split: ',' %}{% for y in x %}{{ y.z }}{% endfor %}`,
+			expectedVars: `{"a":{"Loop":false,"Attributes":null}}`,
+		},
+		{
+			name:         "variable assigned from the first element",
+			liquid:       `{% assign first = order.lines | first %}{{ first.sku }}`,
+			expectedVars: `{"order.lines":{"Loop":true,"Attributes":{"sku":{"Loop":false,"Attributes":null}}}}`,
+		},
+		{
+			name:         "variable assigned in branches",
+			liquid:       `{% if a %}{% assign n = contact.a %}{% else %}{% assign n = contact.b %}{% endif %}{{ n.c }}`,
+			expectedVars: `{"a":{"Loop":false,"Attributes":null},"contact.a.c":{"Loop":false,"Attributes":null},"contact.b.c":{"Loop":false,"Attributes":null}}`,
+		},
+		{
+			name:         "variable reassigned",
+			liquid:       `{% assign n = contact.a %}{% assign n = contact.b %}{{ n.c }}`,
+			expectedVars: `{"contact.b.c":{"Loop":false,"Attributes":null}}`,
+		},
+		{
+			name: "captured variable",
+			liquid: `{% capture greeting %}Hi {{ contact.name }}{% endcapture %}
+	{{ greeting }}`,
+			expectedVars: `{"contact.name":{"Loop":false,"Attributes":null}}`,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -542,18 +905,38 @@ func TestFormatDate_UnknownFormatFallsBackToDateString(t *testing.T) {
 	d, err := date.New(2024, 6, 4, "UTC")
 	require.NoError(t, err)
 
-	require.Equal(t, d.String(), formatDate(d, "unknown-format"))
+	require.Equal(t, d.String(), NewDateFormats().FormatDate(d, "unknown-format"))
 }
 
 func TestFormatDateTime_UnknownFormatFallsBackToTimeString(t *testing.T) {
 	tm := time.Date(2024, time.June, 4, 15, 15, 0, 0, time.UTC)
 
-	require.Equal(t, tm.String(), formatDateTime(tm, "unknown-format"))
+	require.Equal(t, tm.String(), NewDateFormats().FormatDateTime(tm, "unknown-format"))
 }
 
 func TestFormatDateTime_LowerMeridiemIsAppliedSelectively(t *testing.T) {
 	tm := time.Date(2024, time.June, 4, 15, 15, 0, 0, time.UTC)
 
-	require.Equal(t, "Jun 04 2024 3:15 PM", formatDateTime(tm, "mdy12"))
-	require.Equal(t, "3:15pm June 4, 2024", formatDateTime(tm, "mdy12a"))
+	require.Equal(t, "Jun 04 2024 3:15 PM", NewDateFormats().FormatDateTime(tm, "mdy12"))
+	require.Equal(t, "3:15pm June 4, 2024", NewDateFormats().FormatDateTime(tm, "mdy12a"))
+}
+
+func TestEngine_Lint(t *testing.T) {
+	engine := NewEngine()
+	engine.DeprecateFilter("rawPhone", "phone_format")
+	tpl, err := engine.ParseString("Call {{ contact.phone | rawPhone }}\n{{ contact.name | upcase: 1, 2 }}\n" +
+		"{{ contact.created | dateTimeFormatOrDefault: 'mdy12', '' }} {{ contact.url | trackURL: 1 }}")
+	require.NoError(t, err)
+	require.Equal(t, []LintFinding{
+		{Severity: lint.Info, Rule: lint.DeprecatedFilter, Line: 1, Column: 25, Message: "rawPhone is deprecated; use phone_format instead"},
+		{Severity: lint.Error, Rule: lint.FilterArguments, Line: 2, Column: 19, Message: "upcase takes 1 argument, but is given 2"},
+		{Severity: lint.Error, Rule: lint.FilterArguments, Line: 3, Column: 79, Message: "trackURL takes 0 arguments, but is given 1"},
+	}, engine.Lint(tpl))
+
+	// Lines in messages are numbered like the lines of findings.
+	tpl, err = engine.ParseString("{% if a %}a{% else %}b\n{% elsif c %}c{% endif %}")
+	require.NoError(t, err)
+	require.Equal(t, []LintFinding{
+		{Severity: lint.Error, Rule: lint.BranchOrder, Line: 2, Column: 1, Message: "elsif after the else on line 1 is never reached"},
+	}, engine.Lint(tpl))
 }
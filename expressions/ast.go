@@ -0,0 +1,103 @@
+package expressions
+
+// A Span is the position of a node in the source of an expression, as byte offsets of its
+// start and end.
+type Span struct {
+	Start, End int
+}
+
+// Position returns the span; it makes a Span a Node's position.
+func (s Span) Position() Span { return s }
+
+// A Node is a node of an expression tree: a *Literal, *Variable, *Property, *Index,
+// *Filter, *Comparison, *Logical or *Range.
+type Node interface {
+	// Position returns the position of the node in the expression source.
+	Position() Span
+}
+
+// A Literal is a string, number, boolean or nil.
+type Literal struct {
+	Span
+	Value interface{}
+}
+
+// A Variable is a reference to a variable, such as a in a.b.
+type Variable struct {
+	Span
+	Name string
+}
+
+// A Property is a property of a value, such as b in a.b.
+type Property struct {
+	Span
+	Object Node
+	Name   string
+}
+
+// An Index is an indexed value, such as a[0] or a["b"].
+type Index struct {
+	Span
+	Sequence, Index Node
+}
+
+// A Filter is an application of a filter to an input, such as a | plus: 1.
+type Filter struct {
+	Span
+	Input Node
+	Name  string
+	// NameSpan is the position of the filter name, without the colon of its arguments.
+	NameSpan Span
+	Args     []Node
+	Named    []NamedArg
+}
+
+// A NamedArg is a named filter argument, such as name: a in a | t: name: a.
+type NamedArg struct {
+	Span
+	Name  string
+	Value Node
+}
+
+// A Comparison compares two values with ==, !=, <, >, <=, >= or contains.
+type Comparison struct {
+	Span
+	Op          string
+	Left, Right Node
+}
+
+// A Logical combines two conditions with and or or.
+type Logical struct {
+	Span
+	Op          string
+	Left, Right Node
+}
+
+// A Range is a range of integers, such as (1..n), that a loop iterates over.
+type Range struct {
+	Span
+	Start, End Node
+}
+
+// ParseTree parses an expression string into an expression tree.
+func ParseTree(source string) (Node, error) {
+	p, err := parse("", source)
+	if err != nil {
+		return nil, err
+	}
+	return p.val, nil
+}
+
+// Path returns the variable path of a node that is a variable or a property of one,
+// such as "a.b.c" for a.b.c.
+func Path(n Node) (string, bool) {
+	switch n := n.(type) {
+	case *Variable:
+		return n.Name, true
+	case *Property:
+		if p, ok := Path(n.Object); ok {
+			return p + "." + n.Name, true
+		}
+	}
+	return "", false
+}
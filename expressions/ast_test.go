@@ -0,0 +1,56 @@
+package expressions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTree(t *testing.T) {
+	n, err := ParseTree(`a.b[0] | t: 'x', n: 2 | upcase`)
+	require.NoError(t, err)
+	require.Equal(t, &Filter{
+		Span: Span{0, 30},
+		Input: &Filter{
+			Span: Span{0, 21},
+			Input: &Index{
+				Span: Span{0, 6},
+				Sequence: &Property{
+					Span:   Span{0, 3},
+					Object: &Variable{Span{0, 1}, "a"},
+					Name:   "b",
+				},
+				Index: &Literal{Span{4, 5}, 0},
+			},
+			Name:     "t",
+			NameSpan: Span{9, 10},
+			Args:     []Node{&Literal{Span{12, 15}, "x"}},
+			Named:    []NamedArg{{Span{17, 21}, "n", &Literal{Span{20, 21}, 2}}},
+		},
+		Name:     "upcase",
+		NameSpan: Span{24, 30},
+	}, n)
+
+	n, err = ParseTree(`x > 1 and (y contains "z" or nil)`)
+	require.NoError(t, err)
+	require.Equal(t, &Logical{
+		Span: Span{0, 33},
+		Op:   "and",
+		Left: &Comparison{Span{0, 5}, ">", &Variable{Span{0, 1}, "x"}, &Literal{Span{4, 5}, 1}},
+		Right: &Logical{
+			Span:  Span{11, 32},
+			Op:    "or",
+			Left:  &Comparison{Span{11, 25}, "contains", &Variable{Span{11, 12}, "y"}, &Literal{Span{22, 25}, "z"}},
+			Right: &Literal{Span{29, 32}, nil},
+		},
+	}, n)
+
+	path, ok := Path(&Property{Object: &Property{Object: &Variable{Name: "a"}, Name: "b"}, Name: "c"})
+	require.True(t, ok)
+	require.Equal(t, "a.b.c", path)
+	_, ok = Path(&Literal{Value: 1})
+	require.False(t, ok)
+
+	_, err = ParseTree("a syntax error")
+	require.Error(t, err)
+}
@@ -18,7 +18,35 @@ func makeContainsExpr(e1, e2 func(Context) values.Value) func(Context) values.Va
 	}
 }
 
-func makeFilter(fn valueFn, name string, args []valueFn) valueFn {
+// A filterParam is a filter argument. Named arguments have a name.
+type filterParam struct {
+	name  string
+	value valueFn
+}
+
+// makeFilter returns a function that applies the named filter. Named arguments
+// are collected into a NamedArgs map, that is passed after the positional arguments.
+func makeFilter(fn valueFn, name string, params []filterParam) valueFn {
+	var (
+		args  []valueFn
+		named []filterParam
+	)
+	for _, p := range params {
+		if p.name == "" {
+			args = append(args, p.value)
+		} else {
+			named = append(named, p)
+		}
+	}
+	if len(named) > 0 {
+		args = append(args, func(ctx Context) values.Value {
+			m := NamedArgs{}
+			for _, p := range named {
+				m[p.name] = p.value(ctx).Interface()
+			}
+			return values.ValueOf(m)
+		})
+	}
 	return func(ctx Context) values.Value {
 		result, err := ctx.ApplyFilter(name, fn, args)
 		if err != nil {
@@ -40,10 +68,6 @@ func makeIndexExpr(sequenceFn, indexFn func(Context) values.Value) func(Context)
 func makeObjectPropertyExpr(objFn func(Context) values.Value, name string) func(Context) values.Value {
 	index := values.ValueOf(name)
 	return func(ctx Context) values.Value {
-		k, ok := ctx.(*varsContext)
-		if ok {
-			k.BuildVar(name)
-		}
 		return objFn(ctx).PropertyValue(index)
 	}
 }
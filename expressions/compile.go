@@ -0,0 +1,81 @@
+package expressions
+
+import (
+	"fmt"
+
+	"github.com/autopilot3/liquid/values"
+)
+
+// Compile compiles an expression tree into an Expression. The tree shouldn't be modified
+// afterwards; Rewrite returns a modified copy of a tree.
+func Compile(n Node) Expression {
+	return &expression{compile(n), n}
+}
+
+// compile returns the evaluator of an expression tree.
+func compile(n Node) valueFn {
+	switch n := n.(type) {
+	case *Literal:
+		val := n.Value
+		return func(Context) values.Value { return values.ValueOf(val) }
+	case *Variable:
+		name := n.Name
+		return func(ctx Context) values.Value { return values.ValueOf(ctx.Get(name)) }
+	case *Property:
+		return makeObjectPropertyExpr(compile(n.Object), n.Name)
+	case *Index:
+		return makeIndexExpr(compile(n.Sequence), compile(n.Index))
+	case *Filter:
+		var params []filterParam
+		for _, a := range n.Args {
+			params = append(params, filterParam{value: compile(a)})
+		}
+		for _, a := range n.Named {
+			params = append(params, filterParam{a.Name, compile(a.Value)})
+		}
+		return makeFilter(compile(n.Input), n.Name, params)
+	case *Comparison:
+		return makeComparisonExpr(n.Op, compile(n.Left), compile(n.Right))
+	case *Logical:
+		return makeLogicalExpr(n.Op, compile(n.Left), compile(n.Right))
+	case *Range:
+		return makeRangeExpr(compile(n.Start), compile(n.End))
+	}
+	panic(fmt.Errorf("unknown expression node %T", n))
+}
+
+func makeComparisonExpr(op string, fa, fb valueFn) valueFn {
+	if op == "contains" {
+		return makeContainsExpr(fa, fb)
+	}
+	return func(ctx Context) values.Value {
+		// A string compared with a time is parsed with the time zone and clock of the render.
+		c := goContext(ctx)
+		a, b := fa(ctx).Interface(), fb(ctx).Interface()
+		switch op {
+		case "==":
+			return values.ValueOf(values.EqualContext(c, a, b))
+		case "!=":
+			return values.ValueOf(!values.EqualContext(c, a, b))
+		case ">":
+			return values.ValueOf(values.LessContext(c, b, a))
+		case "<":
+			return values.ValueOf(values.LessContext(c, a, b))
+		case ">=":
+			return values.ValueOf(values.LessContext(c, b, a) || values.EqualContext(c, a, b))
+		case "<=":
+			return values.ValueOf(values.LessContext(c, a, b) || values.EqualContext(c, a, b))
+		}
+		panic(fmt.Errorf("unknown comparison %q", op))
+	}
+}
+
+// makeLogicalExpr returns the evaluator of an and or or.
+func makeLogicalExpr(op string, fa, fb valueFn) valueFn {
+	return func(ctx Context) values.Value {
+		if op == "and" {
+			return values.ValueOf(fa(ctx).Test() && fb(ctx).Test())
+		}
+		return values.ValueOf(fa(ctx).Test() || fb(ctx).Test())
+	}
+}
@@ -4,14 +4,20 @@ import gocontext "context"
 
 // Config holds configuration information for expression interpretation.
 type Config struct {
-	filters map[string]interface{}
-	ctx     gocontext.Context
+	filters    map[string]interface{}
+	deprecated map[string]string
+	ctx        gocontext.Context
 }
 
 func (c *Config) Context() gocontext.Context {
 	return c.ctx
 }
 
+// SetContext sets the context that is passed to filters that take a context.Context.
+func (c *Config) SetContext(ctx gocontext.Context) {
+	c.ctx = ctx
+}
+
 // NewConfig creates a new Config.
 func NewConfig(ctx gocontext.Context) Config {
 	return Config{ctx: ctx}
@@ -44,6 +44,16 @@ func (c closure) Evaluate() (interface{}, error) {
 
 type expression struct {
 	evaluator func(Context) values.Value
+	tree      Node
+}
+
+// Tree returns the expression tree that an Expression is compiled from, or nil if it
+// isn't compiled from one, as with Constant and Not.
+func Tree(e Expression) Node {
+	if e, ok := e.(*expression); ok {
+		return e.tree
+	}
+	return nil
 }
 
 func (e expression) Evaluate(ctx Context) (out interface{}, err error) {
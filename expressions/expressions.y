@@ -3,7 +3,6 @@ package expressions
 import (
 	"fmt"
 	"math"
-	"github.com/autopilot3/liquid/values"
 )
 
 func init() {
@@ -16,19 +15,20 @@ func init() {
 %union {
    name     string
    val      interface{}
-   f        func(Context) values.Value
+   pos, end int
+   node     Node
+   nodes    []Node
+   filter   *Filter
    s        string
    ss       []string
-   exprs    []Expression
    cycle    Cycle
    cyclefn  func(string) Cycle
    loop     Loop
    loopmods loopModifiers
-   filter_params []valueFn
 }
-%type <f> expr rel filtered cond int_or_var loop_expr
-%type<filter_params> filter_params
-%type<exprs> exprs expr2
+%type <node> expr rel filtered cond int_or_var loop_expr
+%type<filter> filter_params
+%type<nodes> exprs expr2
 %type<cycle> cycle
 %type<cyclefn> cycle2
 %type<ss> cycle3
@@ -45,11 +45,17 @@ func init() {
 start:
   cond ';' { yylex.(*lexer).val = $1 }
 | ASSIGN IDENTIFIER '=' filtered ';' {
-	yylex.(*lexer).Assignment = Assignment{$2, &expression{$4}}
+	yylex.(*lexer).Assignment = Assignment{$2, Compile($4), $4}
 }
 | CYCLE cycle ';' { yylex.(*lexer).Cycle = $2 }
 | LOOP loop ';'   { yylex.(*lexer).Loop = $2 }
-| WHEN exprs ';'  { yylex.(*lexer).When = When{$2} }
+| WHEN exprs ';'  {
+	exprs := make([]Expression, len($2))
+	for i, n := range $2 {
+		exprs[i] = Compile(n)
+	}
+	yylex.(*lexer).When = When{exprs, $2}
+}
 ;
 
 cycle: string cycle2 { $$ = $2($1) };
@@ -70,10 +76,10 @@ cycle3:
 | ',' string cycle3 { $$ = append([]string{$2}, $3...) }
 ;
 
-exprs: expr expr2 { $$ = append([]Expression{&expression{$1}}, $2...) } ;
+exprs: expr expr2 { $$ = append([]Node{$1}, $2...) } ;
 expr2:
-  /* empty */    { $$ = []Expression{} }
-| ',' expr expr2 { $$ = append([]Expression{&expression{$2}}, $3...) }
+  /* empty */    { $$ = []Node{} }
+| ',' expr expr2 { $$ = append([]Node{$2}, $3...) }
 ;
 
 string: LITERAL {
@@ -86,20 +92,21 @@ string: LITERAL {
 
 loop: IDENTIFIER IN loop_expr loop_modifiers {
 	name, expr, mods := $1, $3, $4
-	$$ = Loop{name, &expression{expr}, mods}
+	$$ = Loop{name, Compile(expr), expr, mods}
 }
 ;
 
 loop_expr : '(' int_or_var DOTDOT int_or_var ')' {
-  $$ = makeRangeExpr($2, $4)
+  $$ = &Range{Span{$<pos>1, $<end>5}, $2, $4}
+  $<end>$ = $<end>5
 }
 | filtered
 ;
 
 // TODO DRY w/ expr
 int_or_var:
-  LITERAL { val := $1; $$ = func(Context) values.Value { return values.ValueOf(val) } }
-| IDENTIFIER { name := $1; $$ = func(ctx Context) values.Value { return values.ValueOf(ctx.Get(name)) } }
+  LITERAL { $$ = &Literal{Span{$<pos>1, $<end>1}, $1} }
+| IDENTIFIER { $$ = &Variable{Span{$<pos>1, $<end>1}, $1} }
 ;
 
 loop_modifiers: /* empty */ { $$ = loopModifiers{Cols: math.MaxUint32} }
@@ -139,94 +146,60 @@ loop_modifiers: /* empty */ { $$ = loopModifiers{Cols: math.MaxUint32} }
 }
 ;
 
+// The pos and end of an expression are the extent of its source, which includes the
+// parentheses around it and its operands; its span doesn't include its own parentheses.
 expr:
-  LITERAL { val := $1; $$ = func(Context) values.Value { return values.ValueOf(val) } }
-| IDENTIFIER { name := $1; $$ = func(ctx Context) values.Value { return values.ValueOf(ctx.Get(name)) } }
-| expr PROPERTY { $$ = makeObjectPropertyExpr($1, $2) }
-| expr '[' expr ']' { $$ = makeIndexExpr($1, $3) }
-| '(' cond ')' { $$ = $2 }
+  LITERAL { $$ = &Literal{Span{$<pos>1, $<end>1}, $1} }
+| IDENTIFIER { $$ = &Variable{Span{$<pos>1, $<end>1}, $1} }
+| expr PROPERTY { $$ = &Property{Span{$<pos>1, $<end>2}, $1, $2}; $<end>$ = $<end>2 }
+| expr '[' expr ']' { $$ = &Index{Span{$<pos>1, $<end>4}, $1, $3}; $<end>$ = $<end>4 }
+| '(' cond ')' { $$ = $2; $<end>$ = $<end>3 }
 ;
 
 filtered:
   expr
-| filtered '|' IDENTIFIER { $$ = makeFilter($1, $3, nil) }
-| filtered '|' KEYWORD filter_params { $$ = makeFilter($1, $3, $4) }
+| filtered '|' IDENTIFIER {
+	$$ = &Filter{Span: Span{$<pos>1, $<end>3}, Input: $1, Name: $3, NameSpan: Span{$<pos>3, $<end>3}}
+	$<end>$ = $<end>3
+}
+| filtered '|' KEYWORD filter_params {
+	f := $4
+	f.Span, f.Input, f.Name, f.NameSpan = Span{$<pos>1, $<end>4}, $1, $3, Span{$<pos>3, $<end>3 - 1}
+	$$ = f
+	$<end>$ = $<end>4
+}
 ;
 
 filter_params:
-  expr { $$ = []valueFn{$1} }
-| filter_params ',' expr
-  { $$ = append($1, $3) }
-
-rel:
-  filtered
-| expr EQ expr {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(a.Equal(b))
-	}
-}
-| expr NEQ expr {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(!a.Equal(b))
-	}
+  expr { $$ = &Filter{Args: []Node{$1}} }
+| KEYWORD expr {
+	$$ = &Filter{Named: []NamedArg{{Span{$<pos>1, $<end>2}, $1, $2}}}
+	$<end>$ = $<end>2
 }
-| expr '>' expr {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(b.Less(a))
-	}
-}
-| expr '<' expr {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(a.Less(b))
-	}
-}
-| expr GE expr {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(b.Less(a) || a.Equal(b))
-	}
+| filter_params ',' expr {
+	$1.Args = append($1.Args, $3)
+	$$ = $1
+	$<end>$ = $<end>3
 }
-| expr LE expr {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-		a, b := fa(ctx), fb(ctx)
-		return values.ValueOf(a.Less(b) || a.Equal(b))
-	}
+| filter_params ',' KEYWORD expr {
+	$1.Named = append($1.Named, NamedArg{Span{$<pos>3, $<end>4}, $3, $4})
+	$$ = $1
+	$<end>$ = $<end>4
 }
-| expr CONTAINS expr { $$ = makeContainsExpr($1, $3) }
+
+rel:
+  filtered
+| expr EQ expr { $$ = &Comparison{Span{$<pos>1, $<end>3}, "==", $1, $3}; $<end>$ = $<end>3 }
+| expr NEQ expr { $$ = &Comparison{Span{$<pos>1, $<end>3}, "!=", $1, $3}; $<end>$ = $<end>3 }
+| expr '>' expr { $$ = &Comparison{Span{$<pos>1, $<end>3}, ">", $1, $3}; $<end>$ = $<end>3 }
+| expr '<' expr { $$ = &Comparison{Span{$<pos>1, $<end>3}, "<", $1, $3}; $<end>$ = $<end>3 }
+| expr GE expr { $$ = &Comparison{Span{$<pos>1, $<end>3}, ">=", $1, $3}; $<end>$ = $<end>3 }
+| expr LE expr { $$ = &Comparison{Span{$<pos>1, $<end>3}, "<=", $1, $3}; $<end>$ = $<end>3 }
+| expr CONTAINS expr { $$ = &Comparison{Span{$<pos>1, $<end>3}, "contains", $1, $3}; $<end>$ = $<end>3 }
 ;
 
 cond:
   rel
-| cond AND rel {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-        if _, ok := ctx.(*varsContext); ok {
-            a := fa(ctx).Test()
-            b := fb(ctx).Test()
-            return values.ValueOf(a && b)
-        }
-        return values.ValueOf(fa(ctx).Test() && fb(ctx).Test())
-	}
-}
-| cond OR rel {
-	fa, fb := $1, $3
-	$$ = func(ctx Context) values.Value {
-        if _, ok := ctx.(*varsContext); ok {
-            a := fa(ctx).Test()
-            b := fb(ctx).Test()
-            return values.ValueOf(a || b)
-        }
-        return values.ValueOf(fa(ctx).Test() || fb(ctx).Test())
-	}
-}
+| cond AND rel { $$ = &Logical{Span{$<pos>1, $<end>3}, "and", $1, $3}; $<end>$ = $<end>3 }
+| cond OR rel { $$ = &Logical{Span{$<pos>1, $<end>3}, "or", $1, $3}; $<end>$ = $<end>3 }
 ;
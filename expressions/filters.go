@@ -1,6 +1,7 @@
 package expressions
 
 import (
+	gocontext "context"
 	"fmt"
 	"reflect"
 
@@ -33,13 +34,21 @@ func (e FilterError) Error() string {
 
 type valueFn func(Context) values.Value
 
+// NamedArgs holds the named arguments of a filter application, such as
+// {{ 'key' | t: name: user.name }}. A filter that accepts named arguments
+// declares a final parameter of this type.
+type NamedArgs map[string]interface{}
+
 // AddFilter adds a filter to the filter dictionary.
+//
+// If the filter's first parameter is a context.Context, the filter is called with
+// the Config's context, followed by the filter input and arguments.
 func (c *Config) AddFilter(name string, fn interface{}) {
 	rf := reflect.ValueOf(fn)
 	switch {
 	case rf.Kind() != reflect.Func:
 		panic(fmt.Errorf("a filter must be a function"))
-	case rf.Type().NumIn() < 1:
+	case rf.Type().NumIn() < 1 || takesContext(rf.Type()) && rf.Type().NumIn() < 2:
 		panic(fmt.Errorf("a filter function must have at least one input"))
 	case rf.Type().NumOut() < 1 || 2 < rf.Type().NumOut():
 		panic(fmt.Errorf("a filter must be have one or two outputs"))
@@ -52,6 +61,27 @@ func (c *Config) AddFilter(name string, fn interface{}) {
 	c.filters[name] = fn
 }
 
+// Filter returns the function of a filter, if it's defined.
+func (c *Config) Filter(name string) (interface{}, bool) {
+	fn, ok := c.filters[name]
+	return fn, ok
+}
+
+// DeprecateFilter marks a filter as deprecated, in favor of a replacement if that isn't
+// empty. Deprecated filters still work.
+func (c *Config) DeprecateFilter(name, replacement string) {
+	if c.deprecated == nil {
+		c.deprecated = map[string]string{}
+	}
+	c.deprecated[name] = replacement
+}
+
+// FilterDeprecation returns true if a filter is deprecated, and its replacement.
+func (c *Config) FilterDeprecation(name string) (replacement string, deprecated bool) {
+	replacement, deprecated = c.deprecated[name]
+	return
+}
+
 var closureType = reflect.TypeOf(closure{})
 var interfaceType = reflect.TypeOf([]interface{}{}).Elem()
 
@@ -59,15 +89,41 @@ func isClosureInterfaceType(t reflect.Type) bool {
 	return closureType.ConvertibleTo(t) && !interfaceType.ConvertibleTo(t)
 }
 
+var contextType = reflect.TypeOf((*gocontext.Context)(nil)).Elem()
+
+// takesContext reports whether a filter's first parameter is a context.Context.
+func takesContext(t reflect.Type) bool {
+	return t.NumIn() > 0 && t.In(0) == contextType
+}
+
+// goContext returns the context.Context of an evaluation context, which holds the
+// default time zone and the clock of the render.
+func goContext(ctx Context) gocontext.Context {
+	if c, ok := ctx.(interface{ Context() gocontext.Context }); ok && c.Context() != nil {
+		return c.Context()
+	}
+	return gocontext.Background()
+}
+
 func (ctx *context) ApplyFilter(name string, receiver valueFn, params []valueFn) (interface{}, error) {
-	filter, ok := ctx.filters[name]
+	return applyFilter(ctx, &ctx.Config, name, receiver, params)
+}
+
+func applyFilter(ctx Context, cfg *Config, name string, receiver valueFn, params []valueFn) (interface{}, error) {
+	filter, ok := cfg.filters[name]
 	if !ok {
 		panic(UndefinedFilter(name))
 	}
 	fr := reflect.ValueOf(filter)
-	args := []interface{}{receiver(ctx).Interface()}
+	var args []interface{}
+	offset := 1 // the index of the receiver parameter
+	if takesContext(fr.Type()) {
+		args = append(args, cfg.Context())
+		offset++
+	}
+	args = append(args, receiver(ctx).Interface())
 	for i, param := range params {
-		if i+1 < fr.Type().NumIn() && isClosureInterfaceType(fr.Type().In(i+1)) {
+		if i+offset < fr.Type().NumIn() && isClosureInterfaceType(fr.Type().In(i+offset)) {
 			expr, err := Parse(param(ctx).Interface().(string))
 			if err != nil {
 				panic(err)
@@ -77,10 +133,11 @@ func (ctx *context) ApplyFilter(name string, receiver valueFn, params []valueFn)
 			args = append(args, param(ctx).Interface())
 		}
 	}
-	out, err := values.Call(fr, args)
+	// Strings are converted to times with the time zone and clock of the render.
+	out, err := values.CallContext(goContext(ctx), fr, args)
 	if err != nil {
 		if e, ok := err.(*values.CallParityError); ok {
-			err = &values.CallParityError{NumArgs: e.NumArgs - 1, NumParams: e.NumParams - 1}
+			err = &values.CallParityError{NumArgs: e.NumArgs - offset, NumParams: e.NumParams - offset}
 		}
 		return nil, err
 	}
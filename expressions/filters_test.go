@@ -18,6 +18,8 @@ func TestContext_AddFilter(t *testing.T) {
 	// require.Panics(t, func() { cfg.AddFilter("f", func(int) (a int, b int) { return }) })
 	require.Panics(t, func() { cfg.AddFilter("f", func(int) (a int, b int, e error) { return }) })
 	require.Panics(t, func() { cfg.AddFilter("f", 10) })
+	require.NotPanics(t, func() { cfg.AddFilter("f", func(gocontext.Context, int) int { return 0 }) })
+	require.Panics(t, func() { cfg.AddFilter("f", func(gocontext.Context) int { return 0 }) })
 }
 
 func TestContext_runFilter(t *testing.T) {
@@ -70,4 +72,42 @@ func TestContext_runFilter(t *testing.T) {
 	out, err = ctx.ApplyFilter("closure", receiver, []valueFn{constant("x |add: y")})
 	require.NoError(t, err)
 	require.Equal(t, "(self, 11)", out)
+
+	// context
+	type key struct{}
+	cfg.SetContext(gocontext.WithValue(gocontext.Background(), key{}, "ctx"))
+	cfg.AddFilter("with_context", func(c gocontext.Context, a, b string) string {
+		return fmt.Sprintf("(%v, %s, %s)", c.Value(key{}), a, b)
+	})
+	ctx = NewContext(map[string]interface{}{"x": 10}, cfg)
+	out, err = ctx.ApplyFilter("with_context", receiver, []valueFn{constant("arg")})
+	require.NoError(t, err)
+	require.Equal(t, "(ctx, self, arg)", out)
+	_, err = ctx.ApplyFilter("with_context", receiver, []valueFn{constant(1), constant(2)})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "given 2")
+	require.Contains(t, err.Error(), "expected 1")
+}
+
+func TestNamedFilterArgs(t *testing.T) {
+	cfg := NewConfig(gocontext.Background())
+	cfg.AddFilter("named", func(s string, args NamedArgs) string {
+		return fmt.Sprintf("%s %v", s, map[string]interface{}(args))
+	})
+	cfg.AddFilter("mixed", func(s string, n int, args NamedArgs) string {
+		return fmt.Sprintf("%s %d %v", s, n, map[string]interface{}(args))
+	})
+	ctx := NewContext(map[string]interface{}{"x": 10, "user": map[string]interface{}{"name": "Ann"}}, cfg)
+	tests := []struct{ in, expected string }{
+		{`"s" | named`, "s map[]"},
+		{`"s" | named: a: 1`, "s map[a:1]"},
+		{`"s" | named: name: user.name, n: x`, "s map[n:10 name:Ann]"},
+		{`"s" | mixed: 2, a: 1`, "s 2 map[a:1]"},
+		{`"s" | mixed: 2, a: 1, b: "two" | named: c: 3`, "s 2 map[a:1 b:two] map[c:3]"},
+	}
+	for _, test := range tests {
+		out, err := EvaluateString(test.in, ctx)
+		require.NoErrorf(t, err, test.in)
+		require.Equalf(t, test.expected, out, test.in)
+	}
 }
@@ -0,0 +1,273 @@
+package expressions
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format returns the source of an expression tree. Operands are parenthesized where the
+// grammar requires it; the source of a parsed tree is otherwise normalized, as in
+// a | plus: 1 for a|plus:1.
+func Format(n Node) string {
+	return formatNode(n, Format)
+}
+
+// FormatRewrite returns the source of a tree that is rewritten, as by Rewrite, from the
+// tree of source. The source of the parts of the tree that aren't changed is kept as it
+// is, as is the source of the nodes that are moved, such as reordered filter arguments.
+// The nodes that are new, or that are replaced by nodes of another type, are formatted
+// as by Format.
+//
+// A node of the rewritten tree is the node of the tree with the same span; the nodes
+// that are created for a rewritten tree should have empty spans.
+func FormatRewrite(source string, tree, rewritten Node) string {
+	f := rewriteFormatter{source, map[Span]Node{}}
+	Walk(tree, func(n Node) bool {
+		f.nodes[n.Position()] = n
+		return true
+	})
+	return f.format(rewritten)
+}
+
+// formatNode returns the source of a node, with the children that sub returns the sources
+// of.
+func formatNode(n Node, sub func(Node) string) string {
+	var b strings.Builder
+	switch n := n.(type) {
+	case *Literal:
+		b.WriteString(formatLiteral(n.Value))
+	case *Variable:
+		b.WriteString(n.Name)
+	case *Property:
+		b.WriteString(operand(n.Object, sub))
+		b.WriteString("." + n.Name)
+	case *Index:
+		b.WriteString(operand(n.Sequence, sub) + "[" + operand(n.Index, sub) + "]")
+	case *Filter:
+		b.WriteString(filterInput(n.Input, sub))
+		b.WriteString(" | " + n.Name)
+		b.WriteString(filterArgs(n, sub))
+	case *Comparison:
+		b.WriteString(operand(n.Left, sub) + " " + n.Op + " " + operand(n.Right, sub))
+	case *Logical:
+		b.WriteString(sub(n.Left) + " " + n.Op + " " + logicalRight(n.Right, sub))
+	case *Range:
+		b.WriteString("(" + sub(n.Start) + ".." + sub(n.End) + ")")
+	default:
+		panic(fmt.Errorf("unknown expression node %T", n))
+	}
+	return b.String()
+}
+
+// operand returns the source of an operand of a property, index, comparison or filter
+// argument, which is parenthesized unless it's a primary expression.
+func operand(n Node, sub func(Node) string) string {
+	if needsParens(n) {
+		return "(" + sub(n) + ")"
+	}
+	return sub(n)
+}
+
+func needsParens(n Node) bool {
+	switch n.(type) {
+	case *Filter, *Comparison, *Logical:
+		return true
+	}
+	return false
+}
+
+func filterInput(n Node, sub func(Node) string) string {
+	if isCondition(n) {
+		return "(" + sub(n) + ")"
+	}
+	return sub(n)
+}
+
+func logicalRight(n Node, sub func(Node) string) string {
+	if _, ok := n.(*Logical); ok {
+		return "(" + sub(n) + ")"
+	}
+	return sub(n)
+}
+
+func isCondition(n Node) bool {
+	switch n.(type) {
+	case *Comparison, *Logical:
+		return true
+	}
+	return false
+}
+
+// filterArgs returns the source of the arguments of a filter, with the colon that
+// precedes them.
+func filterArgs(n *Filter, sub func(Node) string) string {
+	var args []string
+	for _, a := range n.Args {
+		args = append(args, operand(a, sub))
+	}
+	for _, a := range n.Named {
+		args = append(args, a.Name+": "+operand(a.Value, sub))
+	}
+	if len(args) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(args, ", ")
+}
+
+// formatLiteral returns the source of a literal value. A string that contains both
+// kinds of quote can't be written as a literal; it's written with single quotes.
+func formatLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	case string:
+		if strings.Contains(v, "'") && !strings.Contains(v, `"`) {
+			return `"` + v + `"`
+		}
+		return "'" + v + "'"
+	}
+	return fmt.Sprint(value)
+}
+
+// A rewriteFormatter formats a rewritten tree, keeping the source of the nodes of the
+// original tree, which are indexed by span.
+type rewriteFormatter struct {
+	source string
+	nodes  map[Span]Node
+}
+
+// A replacement replaces a span of the source.
+type replacement struct {
+	Span
+	text string
+}
+
+func (f rewriteFormatter) format(n Node) string {
+	if orig, ok := f.nodes[n.Position()]; ok && reflect.TypeOf(orig) == reflect.TypeOf(n) {
+		return f.patch(orig, n)
+	}
+	return formatNode(n, f.format)
+}
+
+// patch returns the source of the node orig, with the changes of n, a node of the same
+// type, applied.
+func (f rewriteFormatter) patch(orig, n Node) string {
+	var rs []replacement
+	// child replaces the source of a child, parenthesizing it if it needs it and the
+	// original child doesn't
+	child := func(orig, n Node, parens func(Node) bool) {
+		text := f.format(n)
+		if parens(n) && !parens(orig) {
+			text = "(" + text + ")"
+		}
+		if text != f.text(orig.Position()) {
+			rs = append(rs, replacement{orig.Position(), text})
+		}
+	}
+	none := func(Node) bool { return false }
+	// op replaces an operator between two operands
+	op := func(left, right Node, from, to string) {
+		if from == to {
+			return
+		}
+		start := left.Position().End
+		if i := strings.Index(f.source[start:right.Position().Start], from); i >= 0 {
+			rs = append(rs, replacement{Span{start + i, start + i + len(from)}, to})
+		}
+	}
+	switch o := orig.(type) {
+	case *Literal:
+		if n := n.(*Literal); !reflect.DeepEqual(n.Value, o.Value) {
+			rs = append(rs, replacement{o.Span, formatLiteral(n.Value)})
+		}
+	case *Variable:
+		if n := n.(*Variable); n.Name != o.Name {
+			rs = append(rs, replacement{o.Span, n.Name})
+		}
+	case *Property:
+		n := n.(*Property)
+		child(o.Object, n.Object, needsParens)
+		if n.Name != o.Name {
+			rs = append(rs, replacement{Span{o.End - len(o.Name), o.End}, n.Name})
+		}
+	case *Index:
+		n := n.(*Index)
+		child(o.Sequence, n.Sequence, needsParens)
+		child(o.Index, n.Index, needsParens)
+	case *Filter:
+		n := n.(*Filter)
+		child(o.Input, n.Input, isCondition)
+		if n.Name != o.Name {
+			rs = append(rs, replacement{o.NameSpan, n.Name})
+		}
+		if sameArgs(o, n) {
+			for i, a := range o.Args {
+				child(a, n.Args[i], needsParens)
+			}
+			for i, a := range o.Named {
+				child(a.Value, n.Named[i].Value, needsParens)
+			}
+		} else {
+			rs = append(rs, replacement{Span{o.NameSpan.End, o.End}, filterArgs(n, f.format)})
+		}
+	case *Comparison:
+		n := n.(*Comparison)
+		child(o.Left, n.Left, needsParens)
+		op(o.Left, o.Right, o.Op, n.Op)
+		child(o.Right, n.Right, needsParens)
+	case *Logical:
+		n := n.(*Logical)
+		child(o.Left, n.Left, none)
+		op(o.Left, o.Right, o.Op, n.Op)
+		child(o.Right, n.Right, func(n Node) bool {
+			_, ok := n.(*Logical)
+			return ok
+		})
+	case *Range:
+		n := n.(*Range)
+		child(o.Start, n.Start, none)
+		child(o.End, n.End, none)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Start < rs[j].Start })
+	span := orig.Position()
+	var b strings.Builder
+	p := span.Start
+	for _, r := range rs {
+		b.WriteString(f.source[p:r.Start])
+		b.WriteString(r.text)
+		p = r.End
+	}
+	b.WriteString(f.source[p:span.End])
+	return b.String()
+}
+
+func (f rewriteFormatter) text(s Span) string {
+	return f.source[s.Start:s.End]
+}
+
+// sameArgs returns true if two filters have the same number of positional arguments, and
+// the same named arguments in the same order.
+func sameArgs(a, b *Filter) bool {
+	if len(a.Args) != len(b.Args) || len(a.Named) != len(b.Named) {
+		return false
+	}
+	for i := range a.Named {
+		if a.Named[i].Name != b.Named[i].Name {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package expressions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var formatTests = []struct{ in, expected string }{
+	{`a`, `a`},
+	{`a.b[0]["c"]`, `a.b[0]['c']`},
+	{`a|plus:1,2`, `a | plus: 1, 2`},
+	{`'k' | t: name: a.b, count: 2.0`, `'k' | t: name: a.b, count: 2.0`},
+	{`a | default: "it's"`, `a | default: "it's"`},
+	{`a==1 and b!=nil or c contains 'x'`, `a == 1 and b != nil or c contains 'x'`},
+	{`a and (b or c)`, `a and (b or c)`},
+	{`(a | size) > 2`, `(a | size) > 2`},
+	{`a[(b|first)]`, `a[(b | first)]`},
+	{`x >= -1.5`, `x >= -1.5`},
+}
+
+func TestFormat(t *testing.T) {
+	for _, test := range formatTests {
+		t.Run(test.in, func(t *testing.T) {
+			n, err := ParseTree(test.in)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, Format(n))
+			// The formatted source parses to the same tree, apart from positions
+			reparsed, err := ParseTree(Format(n))
+			require.NoError(t, err)
+			require.Equal(t, Format(reparsed), Format(n))
+		})
+	}
+
+	stmt, err := ParseStatement(LoopStatementSelector, "i in (1..n)")
+	require.NoError(t, err)
+	require.Equal(t, "(1..n)", Format(stmt.Loop.Collection))
+}
+
+func TestFormatRewrite(t *testing.T) {
+	rewrite := func(source string, fn func(Node) Node) string {
+		n, err := ParseTree(source)
+		require.NoError(t, err)
+		return FormatRewrite(source, n, Rewrite(n, fn))
+	}
+	same := func(n Node) Node { return n }
+	require.Equal(t, `a.b  |plus:1 ,(c|x)`, rewrite(`a.b  |plus:1 ,(c|x)`, same))
+
+	// A new node that needs parentheses gets them
+	require.Equal(t, `a == (b | upcase)`, rewrite(`a == b`, func(n Node) Node {
+		if v, ok := n.(*Variable); ok && v.Name == "b" {
+			return &Filter{Input: &Variable{Name: "b"}, Name: "upcase"}
+		}
+		return n
+	}))
+
+	// Operators and moved nodes
+	require.Equal(t, `(b)  or  a>1`, rewrite(`(a>1)  and  b`, func(n Node) Node {
+		if l, ok := n.(*Logical); ok {
+			return &Logical{l.Span, "or", l.Right, l.Left}
+		}
+		return n
+	}))
+}
@@ -6,8 +6,6 @@ package expressions
 
 import (
 	"fmt"
-
-	"github.com/autopilot3/liquid/values"
 )
 
 type parseValue struct {
@@ -15,7 +13,7 @@ type parseValue struct {
 	Cycle
 	Loop
 	When
-	val func(Context) values.Value
+	val Node
 }
 
 // SyntaxError represents a syntax error. The yacc-generated compiler
@@ -24,16 +22,19 @@ type SyntaxError string
 
 func (e SyntaxError) Error() string { return string(e) }
 
-// Parse parses an expression string into an Expression.
+// Parse parses an expression string into an expression tree, and compiles it into an
+// Expression.
 func Parse(source string) (expr Expression, err error) {
-	p, err := parse(source)
+	p, err := parse("", source)
 	if err != nil {
 		return nil, err
 	}
-	return &expression{p.val}, nil
+	return Compile(p.val), nil
 }
 
-func parse(source string) (p *parseValue, err error) {
+// parse parses a statement selector and the source that follows it. The positions of the
+// expression trees are offsets in source.
+func parse(sel, source string) (p *parseValue, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			switch e := r.(type) {
@@ -47,7 +48,8 @@ func parse(source string) (p *parseValue, err error) {
 		}
 	}()
 	// FIXME hack to recognize EOF
-	lex := newLexer([]byte(source + ";"))
+	lex := newLexer([]byte(sel + source + ";"))
+	lex.base = len(sel)
 	n := yyParse(lex)
 	if n != 0 {
 		return nil, SyntaxError(fmt.Errorf("syntax error in %q", source).Error())
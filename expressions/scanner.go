@@ -216,6 +216,8 @@ type lexer struct {
 	data        []byte
 	p, pe, cs   int
 	ts, te, act int
+	// base is the offset of the expression in data, after a statement selector
+	base int
 }
 
 func (l *lexer) token() string {
@@ -650,6 +652,7 @@ func (lex *lexer) Lex(out *yySymType) int {
 
 //line scanner.rl:119
 
+	out.pos, out.end = lex.ts-lex.base, lex.te-lex.base
 	return tok
 }
 
@@ -15,6 +15,8 @@ type lexer struct {
     data []byte
     p, pe, cs int
     ts, te, act int
+    // base is the offset of the expression in data, after a statement selector
+    base int
 }
 
 func (l* lexer) token() string {
@@ -118,6 +120,7 @@ func (lex *lexer) Lex(out *yySymType) int {
 		write exec;
 	}%%
 
+	out.pos, out.end = lex.ts-lex.base, lex.te-lex.base
 	return tok
 }
 
@@ -18,6 +18,8 @@ type Statement struct{ parseValue }
 type Assignment struct {
 	Variable string
 	ValueFn  Expression
+	// Value is the expression tree of ValueFn.
+	Value Node
 }
 
 // A Cycle is a parse of an {% assign %} statement
@@ -30,6 +32,8 @@ type Cycle struct {
 type Loop struct {
 	Variable string
 	Expr     Expression
+	// Collection is the expression tree of Expr.
+	Collection Node
 	loopModifiers
 }
 
@@ -43,12 +47,14 @@ type loopModifiers struct {
 // A When is a parse of a {% when %} clause
 type When struct {
 	Exprs []Expression
+	// Values are the expression trees of Exprs.
+	Values []Node
 }
 
 // ParseStatement parses an statement into an Expression that can evaluated to return a
 // structure specific to the statement.
 func ParseStatement(sel, source string) (*Statement, error) {
-	p, err := parse(sel + source)
+	p, err := parse(sel, source)
 	if err != nil {
 		return nil, err
 	}
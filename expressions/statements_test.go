@@ -10,6 +10,7 @@ func TestParseStatement(t *testing.T) {
 	stmt, err := ParseStatement(AssignStatementSelector, "a = b")
 	require.NoError(t, err)
 	require.Equal(t, "a", stmt.Assignment.Variable)
+	require.Equal(t, &Variable{Span{4, 5}, "b"}, stmt.Assignment.Value)
 
 	stmt, err = ParseStatement(CycleStatementSelector, "'a', 'b'")
 	require.NoError(t, err)
@@ -30,8 +31,14 @@ func TestParseStatement(t *testing.T) {
 	require.Equal(t, 2, stmt.Loop.Offset)
 	require.NotNil(t, stmt.Loop.Limit)
 	require.Equal(t, 3, *stmt.Loop.Limit)
+	require.Equal(t, &Variable{Span{5, 10}, "array"}, stmt.Loop.Collection)
+
+	stmt, err = ParseStatement(LoopStatementSelector, "i in (1..n)")
+	require.NoError(t, err)
+	require.Equal(t, &Range{Span{5, 11}, &Literal{Span{6, 7}, 1}, &Variable{Span{9, 10}, "n"}}, stmt.Loop.Collection)
 
 	stmt, err = ParseStatement(WhenStatementSelector, "a, b")
 	require.NoError(t, err)
 	require.Len(t, stmt.When.Exprs, 2)
+	require.Equal(t, []Node{&Variable{Span{0, 1}, "a"}, &Variable{Span{3, 4}, "b"}}, stmt.When.Values)
 }
@@ -0,0 +1,115 @@
+package expressions
+
+// Walk calls fn for a node and its descendants, in source order. If fn returns false,
+// Walk doesn't visit the node's children.
+func Walk(n Node, fn func(Node) bool) {
+	if n == nil || !fn(n) {
+		return
+	}
+	for _, c := range children(n) {
+		Walk(c, fn)
+	}
+}
+
+// children returns the children of a node, in source order.
+func children(n Node) []Node {
+	switch n := n.(type) {
+	case *Property:
+		return []Node{n.Object}
+	case *Index:
+		return []Node{n.Sequence, n.Index}
+	case *Filter:
+		nodes := append([]Node{n.Input}, n.Args...)
+		for _, a := range n.Named {
+			nodes = append(nodes, a.Value)
+		}
+		return nodes
+	case *Comparison:
+		return []Node{n.Left, n.Right}
+	case *Logical:
+		return []Node{n.Left, n.Right}
+	case *Range:
+		return []Node{n.Start, n.End}
+	}
+	return nil
+}
+
+// Rewrite returns a copy of a tree in which each node is replaced by the result of
+// calling fn on it. The children of a node are rewritten before the node, so fn sees
+// them rewritten. The nodes of the tree aren't modified.
+func Rewrite(n Node, fn func(Node) Node) Node {
+	switch n := n.(type) {
+	case *Literal:
+		c := *n
+		return fn(&c)
+	case *Variable:
+		c := *n
+		return fn(&c)
+	case *Property:
+		c := *n
+		c.Object = Rewrite(n.Object, fn)
+		return fn(&c)
+	case *Index:
+		c := *n
+		c.Sequence, c.Index = Rewrite(n.Sequence, fn), Rewrite(n.Index, fn)
+		return fn(&c)
+	case *Filter:
+		c := *n
+		c.Input = Rewrite(n.Input, fn)
+		c.Args = make([]Node, len(n.Args))
+		for i, a := range n.Args {
+			c.Args[i] = Rewrite(a, fn)
+		}
+		c.Named = make([]NamedArg, len(n.Named))
+		for i, a := range n.Named {
+			a.Value = Rewrite(a.Value, fn)
+			c.Named[i] = a
+		}
+		if len(n.Args) == 0 {
+			c.Args = nil
+		}
+		if len(n.Named) == 0 {
+			c.Named = nil
+		}
+		return fn(&c)
+	case *Comparison:
+		c := *n
+		c.Left, c.Right = Rewrite(n.Left, fn), Rewrite(n.Right, fn)
+		return fn(&c)
+	case *Logical:
+		c := *n
+		c.Left, c.Right = Rewrite(n.Left, fn), Rewrite(n.Right, fn)
+		return fn(&c)
+	case *Range:
+		c := *n
+		c.Start, c.End = Rewrite(n.Start, fn), Rewrite(n.End, fn)
+		return fn(&c)
+	}
+	return fn(n)
+}
+
+// Fold returns a copy of a tree in which the comparisons of literals, and the ands and
+// ors that are decided by a literal on their left, are replaced by their values. Filters
+// aren't applied, since their results can depend on the context.
+func Fold(n Node) Node {
+	return Rewrite(n, func(n Node) Node {
+		switch n := n.(type) {
+		case *Comparison:
+			_, a := n.Left.(*Literal)
+			_, b := n.Right.(*Literal)
+			if a && b {
+				return &Literal{n.Span, compile(n)(nil).Interface()}
+			}
+		case *Logical:
+			left, ok := n.Left.(*Literal)
+			if !ok {
+				break
+			}
+			truthy := left.Value != nil && left.Value != false
+			if _, ok := n.Right.(*Literal); ok || truthy == (n.Op == "or") {
+				return &Literal{n.Span, compile(n)(nil).Interface()}
+			}
+		}
+		return n
+	})
+}
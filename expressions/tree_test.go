@@ -0,0 +1,76 @@
+package expressions
+
+import (
+	gocontext "context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalk(t *testing.T) {
+	n, err := ParseTree(`a.b | plus: c[0], n: d and e`)
+	require.NoError(t, err)
+	var paths []string
+	Walk(n, func(n Node) bool {
+		if p, ok := Path(n); ok {
+			paths = append(paths, p)
+			return false
+		}
+		return true
+	})
+	require.Equal(t, []string{"a.b", "c", "d", "e"}, paths)
+}
+
+func TestRewrite(t *testing.T) {
+	n, err := ParseTree(`(contact.custom.x | plus: 1) > y`)
+	require.NoError(t, err)
+	source := Format(n)
+	rewritten := Rewrite(n, func(n Node) Node {
+		switch n := n.(type) {
+		case *Property:
+			if p, _ := Path(n); p == "contact.custom" {
+				return &Property{n.Span, n.Object, "fields"}
+			}
+		case *Literal:
+			return &Literal{n.Span, 2}
+		}
+		return n
+	})
+	require.Equal(t, "(contact.fields.x | plus: 2) > y", Format(rewritten))
+	require.Equal(t, source, Format(n))
+
+	cfg := NewConfig(gocontext.Background())
+	cfg.AddFilter("plus", func(a, b int) int { return a + b })
+	ctx := NewContext(map[string]interface{}{
+		"contact": map[string]interface{}{"fields": map[string]interface{}{"x": 1}},
+		"y":       2,
+	}, cfg)
+	value, err := Compile(rewritten).Evaluate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, true, value)
+}
+
+func TestFold(t *testing.T) {
+	for _, test := range []struct{ in, expected string }{
+		{`1 == 1`, `true`},
+		{`1 > 2 or a`, `false or a`},
+		{`a and 1 < 2`, `a and true`},
+		{`false and a`, `false`},
+		{`'x' or a`, `true`},
+		{`(1 == 1 and 'a' contains 'b') or a`, `false or a`},
+		{`('a' | upcase) == 'A'`, `('a' | upcase) == 'A'`},
+	} {
+		t.Run(test.in, func(t *testing.T) {
+			n, err := ParseTree(test.in)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, Format(Fold(n)))
+		})
+	}
+}
+
+func TestTree(t *testing.T) {
+	expr, err := Parse(`a.b`)
+	require.NoError(t, err)
+	require.Equal(t, &Property{Span{0, 3}, &Variable{Span{0, 1}, "a"}, "b"}, Tree(expr))
+	require.Nil(t, Tree(Constant(1)))
+}
@@ -8,7 +8,6 @@ import __yyfmt__ "fmt"
 //line expressions.y:2
 import (
 	"fmt"
-	"github.com/autopilot3/liquid/values"
 	"math"
 )
 
@@ -18,20 +17,21 @@ func init() {
 	_ = fmt.Sprint("")
 }
 
-//line expressions.y:16
+//line expressions.y:15
 type yySymType struct {
-	yys           int
-	name          string
-	val           interface{}
-	f             func(Context) values.Value
-	s             string
-	ss            []string
-	exprs         []Expression
-	cycle         Cycle
-	cyclefn       func(string) Cycle
-	loop          Loop
-	loopmods      loopModifiers
-	filter_params []valueFn
+	yys      int
+	name     string
+	val      interface{}
+	pos, end int
+	node     Node
+	nodes    []Node
+	filter   *Filter
+	s        string
+	ss       []string
+	cycle    Cycle
+	cyclefn  func(string) Cycle
+	loop     Loop
+	loopmods loopModifiers
 }
 
 const LITERAL = 57346
@@ -98,63 +98,65 @@ var yyExca = [...]int8{
 	-1, 1,
 	1, -1,
 	-2, 0,
-	-1, 75,
+	-1, 76,
 	20, 18,
 	-2, 23,
-	-1, 76,
+	-1, 77,
 	20, 19,
 	-2, 24,
 }
 
 const yyPrivate = 57344
 
-const yyLast = 104
+const yyLast = 114
 
 var yyAct = [...]int8{
-	9, 74, 46, 41, 8, 87, 78, 23, 14, 15,
+	9, 75, 46, 41, 8, 91, 79, 23, 40, 42,
 	18, 10, 11, 25, 42, 3, 4, 5, 6, 25,
-	37, 58, 10, 11, 40, 42, 45, 50, 51, 52,
-	53, 54, 55, 56, 57, 43, 12, 26, 60, 38,
-	81, 24, 59, 26, 69, 60, 25, 12, 66, 65,
-	68, 61, 24, 62, 44, 70, 25, 75, 76, 10,
-	11, 27, 28, 31, 32, 71, 72, 47, 33, 77,
-	26, 7, 30, 29, 79, 80, 21, 14, 15, 82,
-	26, 16, 12, 84, 64, 13, 35, 36, 48, 49,
-	85, 86, 83, 19, 34, 2, 1, 73, 20, 39,
+	37, 10, 11, 85, 45, 43, 38, 50, 51, 52,
+	53, 54, 55, 56, 57, 25, 12, 26, 60, 10,
+	11, 69, 59, 26, 70, 60, 12, 24, 66, 65,
+	68, 61, 83, 62, 44, 25, 47, 10, 11, 26,
+	27, 28, 31, 32, 12, 72, 73, 33, 21, 78,
+	80, 30, 29, 16, 14, 15, 76, 77, 24, 26,
+	84, 71, 12, 10, 11, 87, 90, 58, 14, 15,
+	7, 81, 82, 48, 49, 86, 13, 88, 89, 19,
+	1, 12, 34, 2, 74, 35, 36, 20, 64, 39,
 	17, 22, 67, 63,
 }
 
 var yyPact = [...]int16{
-	7, -1000, 60, 76, 89, 71, 18, -1000, 19, 49,
-	-1000, -1000, 18, -1000, 18, 18, -6, 14, -3, -1000,
-	10, 38, 1, 39, 83, -1000, 18, 18, 18, 18,
-	18, 18, 18, 18, -9, -1000, -1000, 18, -1000, -1000,
-	89, -1000, 89, -1000, 55, -1000, -1000, 18, -1000, 18,
-	12, 6, 6, 6, 6, 6, 6, 6, -1000, 30,
-	6, -14, -14, -1000, 53, 19, 39, -22, 6, -1000,
-	-1000, -1000, -1000, 69, 20, -1000, -1000, -1000, 18, -1000,
-	88, 86, 6, -1000, -25, -1000, -1000, -1000,
+	7, -1000, 71, 68, 95, 63, 53, -1000, 25, 48,
+	-1000, -1000, 53, -1000, 53, 53, -6, 1, -19, -1000,
+	0, 38, -1, 28, 88, -1000, 53, 53, 53, 53,
+	53, 53, 53, 53, 57, -1000, -1000, 53, -1000, -1000,
+	95, -1000, 95, -1000, 79, -1000, -1000, 53, -1000, 35,
+	12, 6, 6, 6, 6, 6, 6, 6, -1000, 56,
+	6, -14, -14, -1000, 72, 25, 28, -22, 6, 53,
+	-1000, -1000, -1000, -1000, 86, 32, -1000, -1000, -1000, 17,
+	6, -1000, 91, 93, 6, 53, -1000, -25, -1000, -1000,
+	6, -1000,
 }
 
 var yyPgo = [...]int8{
-	0, 0, 71, 4, 94, 1, 103, 102, 101, 2,
-	100, 99, 3, 98, 97, 10, 96,
+	0, 0, 90, 4, 102, 1, 113, 112, 111, 2,
+	110, 109, 3, 107, 104, 10, 100,
 }
 
 var yyR1 = [...]int8{
 	0, 16, 16, 16, 16, 16, 10, 11, 11, 12,
 	12, 8, 9, 9, 15, 13, 6, 6, 5, 5,
 	14, 14, 14, 1, 1, 1, 1, 1, 3, 3,
-	3, 7, 7, 2, 2, 2, 2, 2, 2, 2,
-	2, 4, 4, 4,
+	3, 7, 7, 7, 7, 2, 2, 2, 2, 2,
+	2, 2, 2, 4, 4, 4,
 }
 
 var yyR2 = [...]int8{
 	0, 2, 5, 3, 3, 3, 2, 3, 1, 0,
 	3, 2, 0, 3, 1, 4, 5, 1, 1, 1,
 	0, 2, 3, 1, 1, 2, 4, 3, 1, 3,
-	4, 1, 3, 1, 3, 3, 3, 3, 3, 3,
-	3, 1, 3, 3,
+	4, 1, 2, 3, 4, 1, 3, 3, 3, 3,
+	3, 3, 3, 1, 3, 3,
 }
 
 var yyChk = [...]int16{
@@ -164,21 +166,23 @@ var yyChk = [...]int16{
 	23, 14, 15, 19, -4, -2, -2, 26, 25, -11,
 	27, -12, 28, 25, 16, 25, -9, 28, 5, 6,
 	-1, -1, -1, -1, -1, -1, -1, -1, 30, -3,
-	-1, -15, -15, -6, 29, -3, -1, -7, -1, 32,
-	25, -12, -12, -14, -5, 4, 5, -9, 28, 5,
-	6, 20, -1, 4, -5, 4, 5, 30,
+	-1, -15, -15, -6, 29, -3, -1, -7, -1, 6,
+	32, 25, -12, -12, -14, -5, 4, 5, -9, 28,
+	-1, 5, 6, 20, -1, 6, 4, -5, 4, 5,
+	-1, 30,
 }
 
 var yyDef = [...]int8{
-	0, -2, 0, 0, 0, 0, 0, 41, 33, 28,
+	0, -2, 0, 0, 0, 0, 0, 43, 35, 28,
 	23, 24, 0, 1, 0, 0, 0, 0, 9, 14,
 	0, 0, 0, 12, 0, 25, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 42, 43, 0, 3, 6,
+	0, 0, 0, 0, 0, 44, 45, 0, 3, 6,
 	0, 8, 0, 4, 0, 5, 11, 0, 29, 0,
-	0, 34, 35, 36, 37, 38, 39, 40, 27, 0,
-	28, 9, 9, 20, 0, 17, 12, 30, 31, 26,
-	2, 7, 10, 15, 0, -2, -2, 13, 0, 21,
-	0, 0, 32, 22, 0, 18, 19, 16,
+	0, 36, 37, 38, 39, 40, 41, 42, 27, 0,
+	28, 9, 9, 20, 0, 17, 12, 30, 31, 0,
+	26, 2, 7, 10, 15, 0, -2, -2, 13, 0,
+	32, 21, 0, 0, 33, 0, 22, 0, 18, 19,
+	34, 16,
 }
 
 var yyTok1 = [...]int8{
@@ -547,13 +551,13 @@ yydefault:
 		yyDollar = yyS[yypt-2 : yypt+1]
 //line expressions.y:46
 		{
-			yylex.(*lexer).val = yyDollar[1].f
+			yylex.(*lexer).val = yyDollar[1].node
 		}
 	case 2:
 		yyDollar = yyS[yypt-5 : yypt+1]
 //line expressions.y:47
 		{
-			yylex.(*lexer).Assignment = Assignment{yyDollar[2].name, &expression{yyDollar[4].f}}
+			yylex.(*lexer).Assignment = Assignment{yyDollar[2].name, Compile(yyDollar[4].node), yyDollar[4].node}
 		}
 	case 3:
 		yyDollar = yyS[yypt-3 : yypt+1]
@@ -571,61 +575,65 @@ yydefault:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line expressions.y:52
 		{
-			yylex.(*lexer).When = When{yyDollar[2].exprs}
+			exprs := make([]Expression, len(yyDollar[2].nodes))
+			for i, n := range yyDollar[2].nodes {
+				exprs[i] = Compile(n)
+			}
+			yylex.(*lexer).When = When{exprs, yyDollar[2].nodes}
 		}
 	case 6:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line expressions.y:55
+//line expressions.y:61
 		{
 			yyVAL.cycle = yyDollar[2].cyclefn(yyDollar[1].s)
 		}
 	case 7:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:58
+//line expressions.y:64
 		{
 			h, t := yyDollar[2].s, yyDollar[3].ss
 			yyVAL.cyclefn = func(g string) Cycle { return Cycle{g, append([]string{h}, t...)} }
 		}
 	case 8:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line expressions.y:62
+//line expressions.y:68
 		{
 			vals := yyDollar[1].ss
 			yyVAL.cyclefn = func(h string) Cycle { return Cycle{Values: append([]string{h}, vals...)} }
 		}
 	case 9:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line expressions.y:69
+//line expressions.y:75
 		{
 			yyVAL.ss = []string{}
 		}
 	case 10:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:70
+//line expressions.y:76
 		{
 			yyVAL.ss = append([]string{yyDollar[2].s}, yyDollar[3].ss...)
 		}
 	case 11:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line expressions.y:73
+//line expressions.y:79
 		{
-			yyVAL.exprs = append([]Expression{&expression{yyDollar[1].f}}, yyDollar[2].exprs...)
+			yyVAL.nodes = append([]Node{yyDollar[1].node}, yyDollar[2].nodes...)
 		}
 	case 12:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line expressions.y:75
+//line expressions.y:81
 		{
-			yyVAL.exprs = []Expression{}
+			yyVAL.nodes = []Node{}
 		}
 	case 13:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:76
+//line expressions.y:82
 		{
-			yyVAL.exprs = append([]Expression{&expression{yyDollar[2].f}}, yyDollar[3].exprs...)
+			yyVAL.nodes = append([]Node{yyDollar[2].node}, yyDollar[3].nodes...)
 		}
 	case 14:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line expressions.y:79
+//line expressions.y:85
 		{
 			s, ok := yyDollar[1].val.(string)
 			if !ok {
@@ -635,40 +643,39 @@ yydefault:
 		}
 	case 15:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line expressions.y:87
+//line expressions.y:93
 		{
-			name, expr, mods := yyDollar[1].name, yyDollar[3].f, yyDollar[4].loopmods
-			yyVAL.loop = Loop{name, &expression{expr}, mods}
+			name, expr, mods := yyDollar[1].name, yyDollar[3].node, yyDollar[4].loopmods
+			yyVAL.loop = Loop{name, Compile(expr), expr, mods}
 		}
 	case 16:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line expressions.y:93
+//line expressions.y:99
 		{
-			yyVAL.f = makeRangeExpr(yyDollar[2].f, yyDollar[4].f)
+			yyVAL.node = &Range{Span{yyDollar[1].pos, yyDollar[5].end}, yyDollar[2].node, yyDollar[4].node}
+			yyVAL.end = yyDollar[5].end
 		}
 	case 18:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line expressions.y:101
+//line expressions.y:108
 		{
-			val := yyDollar[1].val
-			yyVAL.f = func(Context) values.Value { return values.ValueOf(val) }
+			yyVAL.node = &Literal{Span{yyDollar[1].pos, yyDollar[1].end}, yyDollar[1].val}
 		}
 	case 19:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line expressions.y:102
+//line expressions.y:109
 		{
-			name := yyDollar[1].name
-			yyVAL.f = func(ctx Context) values.Value { return values.ValueOf(ctx.Get(name)) }
+			yyVAL.node = &Variable{Span{yyDollar[1].pos, yyDollar[1].end}, yyDollar[1].name}
 		}
 	case 20:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line expressions.y:105
+//line expressions.y:112
 		{
 			yyVAL.loopmods = loopModifiers{Cols: math.MaxUint32}
 		}
 	case 21:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line expressions.y:106
+//line expressions.y:113
 		{
 			switch yyDollar[2].name {
 			case "reversed":
@@ -680,7 +687,7 @@ yydefault:
 		}
 	case 22:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:115
+//line expressions.y:122
 		{ // TODO can this be a variable?
 			switch yyDollar[2].name {
 			case "cols":
@@ -708,153 +715,144 @@ yydefault:
 		}
 	case 23:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line expressions.y:143
+//line expressions.y:152
 		{
-			val := yyDollar[1].val
-			yyVAL.f = func(Context) values.Value { return values.ValueOf(val) }
+			yyVAL.node = &Literal{Span{yyDollar[1].pos, yyDollar[1].end}, yyDollar[1].val}
 		}
 	case 24:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line expressions.y:144
+//line expressions.y:153
 		{
-			name := yyDollar[1].name
-			yyVAL.f = func(ctx Context) values.Value { return values.ValueOf(ctx.Get(name)) }
+			yyVAL.node = &Variable{Span{yyDollar[1].pos, yyDollar[1].end}, yyDollar[1].name}
 		}
 	case 25:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line expressions.y:145
+//line expressions.y:154
 		{
-			yyVAL.f = makeObjectPropertyExpr(yyDollar[1].f, yyDollar[2].name)
+			yyVAL.node = &Property{Span{yyDollar[1].pos, yyDollar[2].end}, yyDollar[1].node, yyDollar[2].name}
+			yyVAL.end = yyDollar[2].end
 		}
 	case 26:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line expressions.y:146
+//line expressions.y:155
 		{
-			yyVAL.f = makeIndexExpr(yyDollar[1].f, yyDollar[3].f)
+			yyVAL.node = &Index{Span{yyDollar[1].pos, yyDollar[4].end}, yyDollar[1].node, yyDollar[3].node}
+			yyVAL.end = yyDollar[4].end
 		}
 	case 27:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:147
+//line expressions.y:156
 		{
-			yyVAL.f = yyDollar[2].f
+			yyVAL.node = yyDollar[2].node
+			yyVAL.end = yyDollar[3].end
 		}
 	case 29:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:152
+//line expressions.y:161
 		{
-			yyVAL.f = makeFilter(yyDollar[1].f, yyDollar[3].name, nil)
+			yyVAL.node = &Filter{Span: Span{yyDollar[1].pos, yyDollar[3].end}, Input: yyDollar[1].node, Name: yyDollar[3].name, NameSpan: Span{yyDollar[3].pos, yyDollar[3].end}}
+			yyVAL.end = yyDollar[3].end
 		}
 	case 30:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line expressions.y:153
+//line expressions.y:165
 		{
-			yyVAL.f = makeFilter(yyDollar[1].f, yyDollar[3].name, yyDollar[4].filter_params)
+			f := yyDollar[4].filter
+			f.Span, f.Input, f.Name, f.NameSpan = Span{yyDollar[1].pos, yyDollar[4].end}, yyDollar[1].node, yyDollar[3].name, Span{yyDollar[3].pos, yyDollar[3].end - 1}
+			yyVAL.node = f
+			yyVAL.end = yyDollar[4].end
 		}
 	case 31:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line expressions.y:157
+//line expressions.y:174
 		{
-			yyVAL.filter_params = []valueFn{yyDollar[1].f}
+			yyVAL.filter = &Filter{Args: []Node{yyDollar[1].node}}
 		}
 	case 32:
-		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:159
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line expressions.y:175
 		{
-			yyVAL.filter_params = append(yyDollar[1].filter_params, yyDollar[3].f)
+			yyVAL.filter = &Filter{Named: []NamedArg{{Span{yyDollar[1].pos, yyDollar[2].end}, yyDollar[1].name, yyDollar[2].node}}}
+			yyVAL.end = yyDollar[2].end
 		}
-	case 34:
+	case 33:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:163
+//line expressions.y:179
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(a.Equal(b))
-			}
+			yyDollar[1].filter.Args = append(yyDollar[1].filter.Args, yyDollar[3].node)
+			yyVAL.filter = yyDollar[1].filter
+			yyVAL.end = yyDollar[3].end
 		}
-	case 35:
-		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:170
+	case 34:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line expressions.y:184
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(!a.Equal(b))
-			}
+			yyDollar[1].filter.Named = append(yyDollar[1].filter.Named, NamedArg{Span{yyDollar[3].pos, yyDollar[4].end}, yyDollar[3].name, yyDollar[4].node})
+			yyVAL.filter = yyDollar[1].filter
+			yyVAL.end = yyDollar[4].end
 		}
 	case 36:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:177
+//line expressions.y:192
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(b.Less(a))
-			}
+			yyVAL.node = &Comparison{Span{yyDollar[1].pos, yyDollar[3].end}, "==", yyDollar[1].node, yyDollar[3].node}
+			yyVAL.end = yyDollar[3].end
 		}
 	case 37:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:184
+//line expressions.y:193
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(a.Less(b))
-			}
+			yyVAL.node = &Comparison{Span{yyDollar[1].pos, yyDollar[3].end}, "!=", yyDollar[1].node, yyDollar[3].node}
+			yyVAL.end = yyDollar[3].end
 		}
 	case 38:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:191
+//line expressions.y:194
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(b.Less(a) || a.Equal(b))
-			}
+			yyVAL.node = &Comparison{Span{yyDollar[1].pos, yyDollar[3].end}, ">", yyDollar[1].node, yyDollar[3].node}
+			yyVAL.end = yyDollar[3].end
 		}
 	case 39:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:198
+//line expressions.y:195
 		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				a, b := fa(ctx), fb(ctx)
-				return values.ValueOf(a.Less(b) || a.Equal(b))
-			}
+			yyVAL.node = &Comparison{Span{yyDollar[1].pos, yyDollar[3].end}, "<", yyDollar[1].node, yyDollar[3].node}
+			yyVAL.end = yyDollar[3].end
 		}
 	case 40:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:205
+//line expressions.y:196
+		{
+			yyVAL.node = &Comparison{Span{yyDollar[1].pos, yyDollar[3].end}, ">=", yyDollar[1].node, yyDollar[3].node}
+			yyVAL.end = yyDollar[3].end
+		}
+	case 41:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line expressions.y:197
 		{
-			yyVAL.f = makeContainsExpr(yyDollar[1].f, yyDollar[3].f)
+			yyVAL.node = &Comparison{Span{yyDollar[1].pos, yyDollar[3].end}, "<=", yyDollar[1].node, yyDollar[3].node}
+			yyVAL.end = yyDollar[3].end
 		}
 	case 42:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:210
-		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				if _, ok := ctx.(*varsContext); ok {
-					a := fa(ctx).Test()
-					b := fb(ctx).Test()
-					return values.ValueOf(a && b)
-				}
-				return values.ValueOf(fa(ctx).Test() && fb(ctx).Test())
-			}
+//line expressions.y:198
+		{
+			yyVAL.node = &Comparison{Span{yyDollar[1].pos, yyDollar[3].end}, "contains", yyDollar[1].node, yyDollar[3].node}
+			yyVAL.end = yyDollar[3].end
 		}
-	case 43:
+	case 44:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line expressions.y:221
-		{
-			fa, fb := yyDollar[1].f, yyDollar[3].f
-			yyVAL.f = func(ctx Context) values.Value {
-				if _, ok := ctx.(*varsContext); ok {
-					a := fa(ctx).Test()
-					b := fb(ctx).Test()
-					return values.ValueOf(a || b)
-				}
-				return values.ValueOf(fa(ctx).Test() || fb(ctx).Test())
-			}
+//line expressions.y:203
+		{
+			yyVAL.node = &Logical{Span{yyDollar[1].pos, yyDollar[3].end}, "and", yyDollar[1].node, yyDollar[3].node}
+			yyVAL.end = yyDollar[3].end
+		}
+	case 45:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line expressions.y:204
+		{
+			yyVAL.node = &Logical{Span{yyDollar[1].pos, yyDollar[3].end}, "or", yyDollar[1].node, yyDollar[3].node}
+			yyVAL.end = yyDollar[3].end
 		}
 	}
 	goto yystack /* stack new state and value */
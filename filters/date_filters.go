@@ -0,0 +1,455 @@
+package filters
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+
+	"github.com/autopilot3/ap3-types-go/types/date"
+	"github.com/autopilot3/liquid/values"
+)
+
+// relativeTimeWords are the words of the time_ago and time_until phrases in a language.
+type relativeTimeWords struct {
+	past, future string // phrases with a %s for the duration
+	now, today   string
+	units        map[string][2]string // the singular and plural of each unit
+}
+
+// relativeTimeLanguages are the languages of the time_ago and time_until phrases.
+// Other languages use English.
+var relativeTimeLanguages = map[string]relativeTimeWords{
+	"en": {"%s ago", "in %s", "just now", "today", map[string][2]string{
+		"minute": {"minute", "minutes"}, "hour": {"hour", "hours"}, "day": {"day", "days"},
+		"month": {"month", "months"}, "year": {"year", "years"},
+	}},
+	"fr": {"il y a %s", "dans %s", "à l’instant", "aujourd’hui", map[string][2]string{
+		"minute": {"minute", "minutes"}, "hour": {"heure", "heures"}, "day": {"jour", "jours"},
+		"month": {"mois", "mois"}, "year": {"an", "ans"},
+	}},
+	"de": {"vor %s", "in %s", "gerade eben", "heute", map[string][2]string{
+		"minute": {"Minute", "Minuten"}, "hour": {"Stunde", "Stunden"}, "day": {"Tag", "Tagen"},
+		"month": {"Monat", "Monaten"}, "year": {"Jahr", "Jahren"},
+	}},
+	"es": {"hace %s", "dentro de %s", "ahora mismo", "hoy", map[string][2]string{
+		"minute": {"minuto", "minutos"}, "hour": {"hora", "horas"}, "day": {"día", "días"},
+		"month": {"mes", "meses"}, "year": {"año", "años"},
+	}},
+	"it": {"%s fa", "tra %s", "proprio ora", "oggi", map[string][2]string{
+		"minute": {"minuto", "minuti"}, "hour": {"ora", "ore"}, "day": {"giorno", "giorni"},
+		"month": {"mese", "mesi"}, "year": {"anno", "anni"},
+	}},
+	"nl": {"%s geleden", "over %s", "zojuist", "vandaag", map[string][2]string{
+		"minute": {"minuut", "minuten"}, "hour": {"uur", "uur"}, "day": {"dag", "dagen"},
+		"month": {"maand", "maanden"}, "year": {"jaar", "jaar"},
+	}},
+	"pt": {"há %s", "em %s", "agora mesmo", "hoje", map[string][2]string{
+		"minute": {"minuto", "minutos"}, "hour": {"hora", "horas"}, "day": {"dia", "dias"},
+		"month": {"mês", "meses"}, "year": {"ano", "anos"},
+	}},
+}
+
+// AddDateFilters defines the filters that compute with dates and times.
+//
+// Like the date filter, they take a time.Time, a date.Date, a string that values.ParseDate
+// parses, or a Unix time. Strings, Unix times and dates are in the default time zone.
+// The filters that return a date or time return a date.Date for a date.Date input and
+// a day or longer unit, and a time.Time otherwise.
+func AddDateFilters(fd FilterDictionary) {
+	fd.AddFilter("date_add", dateAdd)
+	fd.AddFilter("date_diff", dateDiff)
+	fd.AddFilter("start_of", startOf)
+	fd.AddFilter("end_of", endOf)
+	fd.AddFilter("business_days_add", businessDaysAdd)
+	fd.AddFilter("time_ago", timeAgo)
+	fd.AddFilter("time_until", timeUntil)
+}
+
+// toTime returns the time of a date filter input, and whether it is a date.Date.
+// A date is midnight at the start of the day in the default time zone.
+func toTime(ctx context.Context, v interface{}) (time.Time, bool, error) {
+	loc := values.LocationOrLocal(ctx)
+	switch v := v.(type) {
+	case date.Date:
+		t, err := v.Time()
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc), true, nil
+	case time.Time:
+		if values.Location(ctx) != nil {
+			v = v.In(loc)
+		}
+		return v, false, nil
+	case string:
+		t, err := values.ParseDateContext(ctx, v)
+		return t, false, err
+	case int:
+		return time.Unix(int64(v), 0).In(loc), false, nil
+	case int64:
+		return time.Unix(v, 0).In(loc), false, nil
+	case float64:
+		return time.Unix(int64(v), 0).In(loc), false, nil
+	default:
+		return time.Time{}, false, fmt.Errorf("not a date: %v", v)
+	}
+}
+
+// fromTime returns the filter result of a time, a date.Date if isDate is true.
+func fromTime(t time.Time, isDate bool) (interface{}, error) {
+	if isDate {
+		return date.NewFromTime(t)
+	}
+	return t, nil
+}
+
+// timeUnit returns the singular form of a unit name, such as "day" for "days".
+func timeUnit(unit string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(unit)), "s")
+}
+
+// unitDurations are the durations of the units that are shorter than a day.
+var unitDurations = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+}
+
+// addMonths adds months to t. A day that is past the end of the resulting month is
+// the last day of the month, so that adding a month to January 31 is February 28 or 29.
+func addMonths(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+// dateAdd is the date_add filter: {{ trial.start | date_add: 14, 'days' }}.
+// The units are seconds, minutes, hours, days, weeks, months and years.
+func dateAdd(ctx context.Context, v interface{}, n int, unit string) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	t, isDate, err := toTime(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	u := timeUnit(unit)
+	if d, ok := unitDurations[u]; ok {
+		return t.Add(time.Duration(n) * d), nil
+	}
+	switch u {
+	case "day":
+		t = t.AddDate(0, 0, n)
+	case "week":
+		t = t.AddDate(0, 0, 7*n)
+	case "month":
+		t = addMonths(t, n)
+	case "year":
+		t = addMonths(t, 12*n)
+	default:
+		return nil, fmt.Errorf("unknown unit %q", unit)
+	}
+	return fromTime(t, isDate)
+}
+
+// civilDays returns the number of calendar days from b to a.
+func civilDays(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	da := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	db := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	return int(da.Sub(db).Hours() / 24)
+}
+
+// wholeMonths returns the number of whole months from b to a.
+func wholeMonths(a, b time.Time) int {
+	months := (a.Year()-b.Year())*12 + int(a.Month()-b.Month())
+	switch {
+	case months > 0 && addMonths(b, months).After(a):
+		months--
+	case months < 0 && addMonths(b, months).Before(a):
+		months++
+	}
+	return months
+}
+
+// dateDiff is the date_diff filter. It returns the number of whole units from other
+// to the input, so that {{ trial.end | date_diff: 'now', 'days' }} is the number
+// of days until the end of the trial. Days and weeks count calendar days in the
+// default time zone.
+func dateDiff(ctx context.Context, v interface{}, other interface{}, unit string) (int, error) {
+	a, _, err := toTime(ctx, v)
+	if err != nil {
+		return 0, err
+	}
+	b, _, err := toTime(ctx, other)
+	if err != nil {
+		return 0, err
+	}
+	loc := values.LocationOrLocal(ctx)
+	a, b = a.In(loc), b.In(loc)
+	u := timeUnit(unit)
+	if d, ok := unitDurations[u]; ok {
+		return int(a.Sub(b) / d), nil
+	}
+	switch u {
+	case "day":
+		return civilDays(a, b), nil
+	case "week":
+		return civilDays(a, b) / 7, nil
+	case "month":
+		return wholeMonths(a, b), nil
+	case "year":
+		return wholeMonths(a, b) / 12, nil
+	default:
+		return 0, fmt.Errorf("unknown unit %q", unit)
+	}
+}
+
+// startOfUnit returns the start of the unit that contains t. Weeks start on weekStart.
+func startOfUnit(t time.Time, unit string, weekStart time.Weekday) (time.Time, error) {
+	y, m, d := t.Date()
+	loc := t.Location()
+	switch unit {
+	case "minute":
+		return t.Truncate(time.Minute), nil
+	case "hour":
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, loc), nil
+	case "day":
+		return time.Date(y, m, d, 0, 0, 0, 0, loc), nil
+	case "week":
+		offset := (int(t.Weekday()) - int(weekStart) + 7) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, loc), nil
+	case "month":
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc), nil
+	case "quarter":
+		return time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, loc), nil
+	case "year":
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, loc), nil
+	default:
+		return time.Time{}, fmt.Errorf("unknown unit %q", unit)
+	}
+}
+
+// nextUnit returns the start of the unit after the one that starts at t.
+func nextUnit(t time.Time, unit string) time.Time {
+	switch unit {
+	case "minute":
+		return t.Add(time.Minute)
+	case "hour":
+		return t.Add(time.Hour)
+	case "day":
+		return t.AddDate(0, 0, 1)
+	case "week":
+		return t.AddDate(0, 0, 7)
+	case "month":
+		return t.AddDate(0, 1, 0)
+	case "quarter":
+		return t.AddDate(0, 3, 0)
+	default:
+		return t.AddDate(1, 0, 0)
+	}
+}
+
+// weekday returns the day that weeks start on: Monday, unless name is another day.
+func weekday(name string) (time.Weekday, error) {
+	if name == "" {
+		return time.Monday, nil
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(name, d.String()) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", name)
+}
+
+// startOf is the start_of filter: {{ 'now' | start_of: 'month' }}.
+// The units are minute, hour, day, week, month, quarter and year.
+// Weeks start on Monday, or on the day of the optional second argument.
+func startOf(ctx context.Context, v interface{}, unit string, weekStart func(string) string) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	t, isDate, err := toTime(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := weekday(weekStart(""))
+	if err != nil {
+		return nil, err
+	}
+	u := timeUnit(unit)
+	start, err := startOfUnit(t, u, ws)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := unitDurations[u]; ok && isDate {
+		return v, nil
+	}
+	return fromTime(start, isDate)
+}
+
+// endOf is the end_of filter: {{ 'now' | end_of: 'month' }}. It returns the last
+// nanosecond of the unit, or for a date.Date, the last day. It takes the arguments
+// of start_of.
+func endOf(ctx context.Context, v interface{}, unit string, weekStart func(string) string) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	t, isDate, err := toTime(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := weekday(weekStart(""))
+	if err != nil {
+		return nil, err
+	}
+	u := timeUnit(unit)
+	start, err := startOfUnit(t, u, ws)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := unitDurations[u]; ok && isDate {
+		return v, nil
+	}
+	return fromTime(nextUnit(start, u).Add(-time.Nanosecond), isDate)
+}
+
+// businessDaysAdd is the business_days_add filter: {{ order.date | business_days_add: 3 }}.
+// It adds a number of weekdays, skipping Saturdays, Sundays and the dates in the optional
+// holidays array. A negative number counts back.
+func businessDaysAdd(ctx context.Context, v interface{}, n int, holidays interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	t, isDate, err := toTime(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	skip := map[string]bool{}
+	var days []interface{}
+	switch h := holidays.(type) {
+	case nil:
+	case []interface{}:
+		days = h
+	case []string:
+		for _, s := range h {
+			days = append(days, s)
+		}
+	default:
+		return nil, fmt.Errorf("holidays must be an array of dates")
+	}
+	for _, day := range days {
+		ht, _, err := toTime(ctx, day)
+		if err != nil {
+			return nil, err
+		}
+		skip[ht.In(t.Location()).Format("2006-01-02")] = true
+	}
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday || skip[t.Format("2006-01-02")] {
+			continue
+		}
+		n--
+	}
+	return fromTime(t, isDate)
+}
+
+// timeAgo is the time_ago filter. It describes a past time relative to now, as in
+// "3 days ago". A future time is now.
+func timeAgo(ctx context.Context, v interface{}, locale func(string) string) (string, error) {
+	return relativeTime(ctx, v, locale(values.Locale(ctx)), false)
+}
+
+// timeUntil is the time_until filter. It describes a future time relative to now, as in
+// "in 2 hours". A past time is now.
+func timeUntil(ctx context.Context, v interface{}, locale func(string) string) (string, error) {
+	return relativeTime(ctx, v, locale(values.Locale(ctx)), true)
+}
+
+// relativeTime describes a past or future time relative to now in a locale. A time in
+// the other direction is now. Days and longer units count calendar days in the default
+// time zone.
+func relativeTime(ctx context.Context, v interface{}, locale string, future bool) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	t, isDate, err := toTime(ctx, v)
+	if err != nil {
+		return "", err
+	}
+	tag, err := language.Parse(locale)
+	if err != nil {
+		tag = language.English
+	}
+	base, _ := tag.Base()
+	words, ok := relativeTimeLanguages[base.String()]
+	if !ok {
+		words = relativeTimeLanguages["en"]
+	}
+	current := values.Now(ctx)
+	t = t.In(current.Location())
+	d := t.Sub(current)
+	if future && d < 0 || !future && d > 0 {
+		t, d = current, 0
+	}
+	if d < 0 {
+		d = -d
+	}
+	var (
+		n    int
+		unit string
+	)
+	days := civilDays(t, current)
+	if days < 0 {
+		days = -days
+	}
+	switch {
+	case isDate && days == 0:
+		return words.today, nil
+	case !isDate && d < time.Minute:
+		return words.now, nil
+	case !isDate && d < time.Hour:
+		n, unit = int(d/time.Minute), "minute"
+	case !isDate && d < 24*time.Hour:
+		n, unit = int(d/time.Hour), "hour"
+	case days < 30:
+		n, unit = days, "day"
+	default:
+		months := wholeMonths(t, current)
+		if months < 0 {
+			months = -months
+		}
+		switch {
+		case months == 0:
+			n, unit = days, "day"
+		case months < 12:
+			n, unit = months, "month"
+		default:
+			n, unit = months/12, "year"
+		}
+	}
+	forms := words.units[unit]
+	word := forms[1]
+	if plural.Cardinal.MatchPlural(tag, n, 0, 0, 0, 0) == plural.One {
+		word = forms[0]
+	}
+	phrase := words.past
+	if future {
+		phrase = words.future
+	}
+	return fmt.Sprintf(phrase, fmt.Sprintf("%d %s", n, word)), nil
+}
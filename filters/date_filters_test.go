@@ -0,0 +1,109 @@
+package filters
+
+import (
+	gocontext "context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/autopilot3/ap3-types-go/types/date"
+	"github.com/stretchr/testify/require"
+
+	"github.com/autopilot3/liquid/expressions"
+	"github.com/autopilot3/liquid/values"
+)
+
+var dateFilterTests = []struct {
+	in       string
+	expected interface{}
+}{
+	{`"2024-01-31 10:00" | date_add: 1, 'month' | date: '%F %R'`, "2024-02-29 10:00"},
+	{`"2024-01-31 10:00" | date_add: -2, 'days' | date: '%F %R'`, "2024-01-29 10:00"},
+	{`"2024-01-31 10:00" | date_add: 90, 'minutes' | date: '%F %R'`, "2024-01-31 11:30"},
+	{`"2024-02-29 10:00" | date_add: 1, 'year' | date: '%F'`, "2025-02-28"},
+	{`"2024-01-31 10:00" | date_add: 2, 'weeks' | date: '%F'`, "2024-02-14"},
+	{`day | date_add: 3, 'days'`, date.MustNewFromTime(time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC))},
+	{`day | date_add: 3, 'hours' | date: '%F %R'`, "2024-03-08 03:00"},
+	{`1704067200 | date_add: 1, 'day' | date: '%F'`, "2024-01-02"},
+	{`missing | date_add: 1, 'day'`, nil},
+
+	{`"2024-03-10 09:00" | date_diff: "2024-03-05 15:00", 'days'`, 5},
+	{`"2024-03-05 15:00" | date_diff: "2024-03-10 09:00", 'days'`, -5},
+	{`"2024-03-10 09:00" | date_diff: "2024-03-05 15:00", 'hours'`, 114},
+	{`"2024-03-10" | date_diff: "2024-01-31", 'months'`, 1},
+	{`"2024-03-30" | date_diff: "2024-01-31", 'months'`, 1},
+	{`"2024-03-31" | date_diff: "2024-01-31", 'months'`, 2},
+	{`"2024-03-31" | date_diff: "2022-04-01", 'years'`, 1},
+	{`day | date_diff: "2024-03-01", 'weeks'`, 1},
+
+	{`"2024-05-15 10:20:30" | start_of: 'month' | date: '%F %T'`, "2024-05-01 00:00:00"},
+	{`"2024-05-15 10:20:30" | start_of: 'week' | date: '%F %a'`, "2024-05-13 Mon"},
+	{`"2024-05-15 10:20:30" | start_of: 'week', 'sunday' | date: '%F %a'`, "2024-05-12 Sun"},
+	{`"2024-05-15 10:20:30" | start_of: 'quarter' | date: '%F'`, "2024-04-01"},
+	{`"2024-05-15 10:20:30" | start_of: 'hour' | date: '%T'`, "10:00:00"},
+	{`"2024-05-15 10:20:30" | end_of: 'month' | date: '%F %T'`, "2024-05-31 23:59:59"},
+	{`"2024-05-15 10:20:30" | end_of: 'year' | date: '%F %T'`, "2024-12-31 23:59:59"},
+	{`"2024-05-15 10:20:30" | end_of: 'day' | date: '%F %T'`, "2024-05-15 23:59:59"},
+	{`day | end_of: 'month'`, date.MustNewFromTime(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC))},
+	{`day | start_of: 'year'`, date.MustNewFromTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))},
+
+	{`"2024-03-08" | business_days_add: 1 | date: '%F %a'`, "2024-03-11 Mon"},
+	{`"2024-03-08" | business_days_add: 5 | date: '%F'`, "2024-03-15"},
+	{`"2024-03-11" | business_days_add: -1 | date: '%F'`, "2024-03-08"},
+	{`"2024-03-08" | business_days_add: 2, holidays | date: '%F'`, "2024-03-13"},
+	{`day | business_days_add: 0`, date.MustNewFromTime(time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC))},
+
+	{`three_days_ago | time_ago`, "3 days ago"},
+	{`in_two_hours | time_until`, "in 2 hours"},
+	{`in_two_hours | time_until: 'de'`, "in 2 Stunden"},
+	{`in_two_hours | time_ago`, "just now"},
+	{`three_days_ago | time_until`, "just now"},
+	{`day | time_until`, "today"},
+	{`in_five_minutes | time_until: 'fr'`, "dans 5 minutes"},
+	{`a_minute_ago | time_ago`, "1 minute ago"},
+	{`three_days_ago | time_ago: 'de'`, "vor 3 Tagen"},
+	{`last_year | time_ago: 'es'`, "hace 1 año"},
+	{`"now" | time_ago`, "just now"},
+	{`today | time_until`, "today"},
+	{`"today" | date_add: 5, 'days' | time_until`, "in 5 days"},
+	{`"2024-06-01" | time_ago`, "11 days ago"},
+	{`"2024-02-20" | time_ago`, "3 months ago"},
+}
+
+func TestDateFilters(t *testing.T) {
+	current := time.Date(2024, 6, 12, 15, 30, 0, 0, time.UTC)
+	today, err := date.NewFromTime(current)
+	require.NoError(t, err)
+	bindings := map[string]interface{}{
+		"day":             date.MustNewFromTime(time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)),
+		"holidays":        []interface{}{"2024-03-11", "2024-03-20"},
+		"three_days_ago":  current.Add(-72 * time.Hour),
+		"in_two_hours":    current.Add(2*time.Hour + time.Minute),
+		"in_five_minutes": current.Add(5*time.Minute + time.Second),
+		"a_minute_ago":    current.Add(-time.Minute - time.Second),
+		"last_year":       current.AddDate(-1, 0, -1),
+		"today":           today,
+	}
+	ctx := values.WithLocation(gocontext.Background(), time.UTC)
+	cfg := expressions.NewConfig(values.WithClock(ctx, func() time.Time { return current }))
+	AddStandardFilters(&cfg)
+	context := expressions.NewContext(bindings, cfg)
+
+	for i, test := range dateFilterTests {
+		t.Run(fmt.Sprintf("%02d", i+1), func(t *testing.T) {
+			actual, err := expressions.EvaluateString(test.in, context)
+			require.NoErrorf(t, err, test.in)
+			require.Equalf(t, test.expected, actual, test.in)
+		})
+	}
+
+	for _, in := range []string{
+		`"2024-03-08" | date_add: 1, 'fortnight'`,
+		`"2024-03-08" | start_of: 'week', 'someday'`,
+		`"2024-03-08" | date_diff: 'soon', 'days'`,
+		`"2024-03-08" | business_days_add: 1, 'monday'`,
+	} {
+		_, err := expressions.EvaluateString(in, context)
+		require.Error(t, err, in)
+	}
+}
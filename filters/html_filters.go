@@ -0,0 +1,99 @@
+package filters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+
+	"github.com/autopilot3/liquid/values"
+)
+
+// DefaultSanitizePolicy is the policy that sanitize_html uses when none is named.
+const DefaultSanitizePolicy = "basic"
+
+// sanitizePolicies are the policies that sanitize_html accepts, by name.
+// A bluemonday policy is safe for concurrent use once it has been built.
+var sanitizePolicies = map[string]*bluemonday.Policy{
+	// strict removes all markup.
+	"strict": bluemonday.StrictPolicy(),
+	// basic allows inline formatting, paragraphs and lists, without attributes.
+	"basic": basicPolicy(),
+	// links allows the basic elements, and links to http, https and mailto URLs.
+	"links": linksPolicy(),
+	// ugc allows the markup that is typical of user-generated rich text,
+	// including links, images and tables.
+	"ugc": bluemonday.UGCPolicy(),
+}
+
+func basicPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements(
+		"b", "strong", "i", "em", "u", "s", "strike", "del", "ins", "mark", "small", "sub", "sup", "code",
+		"p", "br", "hr", "blockquote", "pre", "ul", "ol", "li",
+		"h1", "h2", "h3", "h4", "h5", "h6",
+	)
+	return p
+}
+
+func linksPolicy() *bluemonday.Policy {
+	p := basicPolicy()
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.RequireParseableURLs(true)
+	p.AllowRelativeURLs(true)
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// AddHTMLFilters defines the filters that strip, sanitize and convert HTML.
+func AddHTMLFilters(fd FilterDictionary) {
+	fd.AddFilter("strip_html", stripHTML)
+	fd.AddFilter("sanitize_html", sanitizeHTML)
+	fd.AddFilter("html_to_text", HTMLToText)
+}
+
+// stripHTML returns the text content of s. The content of script and style
+// elements, and comments, are removed; character references are decoded. The result is
+// plain text, which auto-escaping escapes like any other string.
+func stripHTML(s string) string {
+	var (
+		buf  strings.Builder
+		skip string // the element whose content is skipped
+		z    = html.NewTokenizer(strings.NewReader(s))
+	)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			// A strings.Reader only returns io.EOF.
+			return buf.String()
+		case html.TextToken:
+			if skip == "" {
+				buf.Write(z.Text())
+			}
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			if tag := string(name); skip == "" && (tag == "script" || tag == "style") {
+				skip = tag
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if string(name) == skip {
+				skip = ""
+			}
+		}
+	}
+}
+
+// sanitizeHTML removes the markup that the named policy doesn't allow.
+// The result is trusted markup, so auto-escaping doesn't escape it.
+func sanitizeHTML(s string, policy func(string) string) (values.SafeHTML, error) {
+	name := policy(DefaultSanitizePolicy)
+	p, ok := sanitizePolicies[name]
+	if !ok {
+		return "", fmt.Errorf("unknown policy %q", name)
+	}
+	return values.SafeHTML(p.Sanitize(s)), nil
+}
@@ -0,0 +1,55 @@
+package filters
+
+import (
+	gocontext "context"
+	"fmt"
+	"testing"
+
+	"github.com/autopilot3/liquid/expressions"
+	"github.com/autopilot3/liquid/values"
+	"github.com/stretchr/testify/require"
+)
+
+var htmlFilterTests = []struct {
+	in       string
+	expected interface{}
+}{
+	{`"Have <em>you</em> read <strong>Ulysses</strong>?" | strip_html`, "Have you read Ulysses?"},
+	{`"Tom &amp; Jerry &lt;3 &#39;cheese&#39;" | strip_html`, "Tom & Jerry <3 'cheese'"},
+	{`"a<script>alert('<b>x</b>')</script>b<style>p { color: red }</style>c" | strip_html`, "abc"},
+	{`"a<!-- <b>comment</b> -->b" | strip_html`, "ab"},
+	{`multiline_tag | strip_html`, "a link"},
+	{`"1 < 2 and 3 > 2" | strip_html`, "1 < 2 and 3 > 2"},
+	{`"<p>unclosed" | strip_html`, "unclosed"},
+	{`"&lt;script&gt;alert(1)&lt;/script&gt;" | strip_html`, "<script>alert(1)</script>"},
+
+	{`rich_text | sanitize_html`, values.SafeHTML(`<p><b>Hi</b> there</p>`)},
+	{`rich_text | sanitize_html: 'basic'`, values.SafeHTML(`<p><b>Hi</b> there</p>`)},
+	{`rich_text | sanitize_html: 'strict'`, values.SafeHTML(`Hi there`)},
+	{`rich_text | sanitize_html: 'links'`, values.SafeHTML(`<p><b>Hi</b> <a href="https://example.com" rel="nofollow noopener" target="_blank">there</a></p>`)},
+	{`"<a href='javascript:alert(1)'>x</a>" | sanitize_html: 'links'`, values.SafeHTML(`x`)},
+	{`"<img src='https://example.com/a.png' onerror='x()'>" | sanitize_html: 'ugc'`, values.SafeHTML(`<img src="https://example.com/a.png">`)},
+}
+
+var htmlFilterTestBindings = map[string]interface{}{
+	"multiline_tag": "<a\n  href=\"https://example.com\"\n  title=\"x\">a link</a\n>",
+	"rich_text":     `<p onclick="x()"><b>Hi</b> <a href="https://example.com">there</a><script>alert(1)</script></p>`,
+}
+
+func TestHTMLFilters(t *testing.T) {
+	cfg := expressions.NewConfig(gocontext.Background())
+	AddStandardFilters(&cfg)
+	context := expressions.NewContext(htmlFilterTestBindings, cfg)
+
+	for i, test := range htmlFilterTests {
+		t.Run(fmt.Sprintf("%02d", i+1), func(t *testing.T) {
+			actual, err := expressions.EvaluateString(test.in, context)
+			require.NoErrorf(t, err, test.in)
+			require.Equalf(t, test.expected, actual, test.in)
+		})
+	}
+
+	_, err := expressions.EvaluateString(`"x" | sanitize_html: 'unknown'`, context)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown policy")
+}
@@ -0,0 +1,377 @@
+package filters
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLToText returns a readable plain-text version of an HTML document or fragment,
+// such as the text/plain alternative of an HTML email.
+//
+// Links are written as "text (url)", list items as bullets or numbers, and data tables
+// as aligned columns. Layout tables, which have a single column or cells that contain
+// blocks, are written as the blocks they contain. Paragraphs, headings and other blocks are separated by blank lines,
+// and <br> starts a new line. The content of script and style elements is dropped.
+func HTMLToText(s string) string {
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		// Parse only fails on read errors, which a strings.Reader doesn't return.
+		return stripHTML(s)
+	}
+	w := textWriter{}
+	w.node(doc)
+	return w.String()
+}
+
+// textWriter accumulates plain text, collapsing whitespace and deferring line breaks
+// until there's more text to write.
+type textWriter struct {
+	buf      strings.Builder
+	pending  int    // line breaks to write before the next text
+	space    bool   // write a space before the next text
+	indent   string // the prefix of each line
+	marker   string // the list item marker to write before the next text
+	pre      int    // the depth of <pre> elements
+	anyLines bool   // whether any text has been written
+}
+
+func (w *textWriter) String() string {
+	return w.buf.String()
+}
+
+// lineBreak ensures that the next text is preceded by at least n line breaks.
+func (w *textWriter) lineBreak(n int) {
+	if w.anyLines && w.pending < n {
+		w.pending = n
+	}
+	w.space = false
+}
+
+// br adds a line break.
+func (w *textWriter) br() {
+	if w.anyLines {
+		w.pending++
+	}
+	w.space = false
+}
+
+// flush writes pending line breaks, and the indent and list marker of a new line.
+func (w *textWriter) flush() {
+	if w.pending > 0 || !w.anyLines {
+		for ; w.pending > 0; w.pending-- {
+			w.buf.WriteByte('\n')
+			if w.pending > 1 {
+				// a blank line inside a block quote
+				w.buf.WriteString(strings.TrimRight(w.indent, " "))
+			}
+		}
+		if w.marker != "" {
+			w.buf.WriteString(strings.TrimSuffix(w.indent, "  "))
+			w.buf.WriteString(w.marker)
+			w.marker = ""
+		} else {
+			w.buf.WriteString(w.indent)
+		}
+		w.space = false
+		w.anyLines = true
+	} else if w.space {
+		w.buf.WriteByte(' ')
+	}
+	w.space = false
+}
+
+// text writes s, collapsing runs of whitespace into a single space unless it's inside <pre>.
+func (w *textWriter) text(s string) {
+	if w.pre > 0 {
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				w.br()
+			}
+			if line != "" {
+				w.flush()
+				w.buf.WriteString(line)
+			}
+		}
+		return
+	}
+	for i, word := range strings.Fields(s) {
+		if i > 0 || isHTMLSpaceByte(s[0]) {
+			w.space = true
+		}
+		w.flush()
+		w.buf.WriteString(word)
+	}
+	if s != "" && isHTMLSpaceByte(s[len(s)-1]) {
+		w.space = true
+	}
+}
+
+// line writes s on a line of its own, without collapsing whitespace.
+func (w *textWriter) line(s string) {
+	w.lineBreak(1)
+	w.flush()
+	w.buf.WriteString(s)
+	w.lineBreak(1)
+}
+
+func (w *textWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+func (w *textWriter) node(n *html.Node) { // nolint: gocyclo
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.DocumentNode:
+		w.children(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	switch n.DataAtom {
+	case atom.Head, atom.Script, atom.Style, atom.Template, atom.Title:
+	case atom.Br:
+		w.br()
+	case atom.Hr:
+		w.lineBreak(2)
+		w.line("--------")
+		w.lineBreak(2)
+	case atom.P, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.lineBreak(2)
+		w.children(n)
+		w.lineBreak(2)
+	case atom.Pre:
+		w.lineBreak(2)
+		w.pre++
+		w.children(n)
+		w.pre--
+		w.lineBreak(2)
+	case atom.Blockquote:
+		w.lineBreak(2)
+		indent := w.indent
+		w.indent += "> "
+		w.children(n)
+		w.indent = indent
+		w.lineBreak(2)
+	case atom.Ul, atom.Ol:
+		w.list(n)
+	case atom.Li:
+		// a list item outside a list
+		w.lineBreak(1)
+		w.children(n)
+		w.lineBreak(1)
+	case atom.Table:
+		w.table(n)
+	case atom.A:
+		w.link(n)
+	case atom.Img:
+		if alt := attr(n, "alt"); alt != "" {
+			w.text(alt)
+		}
+	case atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Nav, atom.Aside,
+		atom.Main, atom.Figure, atom.Figcaption, atom.Address, atom.Dl, atom.Dt, atom.Dd,
+		atom.Form, atom.Fieldset, atom.Center, atom.Tr:
+		w.lineBreak(1)
+		w.children(n)
+		w.lineBreak(1)
+	case atom.Td, atom.Th:
+		w.space = true
+		w.children(n)
+		w.space = true
+	default:
+		w.children(n)
+	}
+}
+
+func (w *textWriter) link(n *html.Node) {
+	w.children(n)
+	href := strings.TrimSpace(attr(n, "href"))
+	lower := strings.ToLower(href)
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(lower, "javascript:") {
+		return
+	}
+	// The text of the link, without the indent and list marker that w writes before it.
+	tw := textWriter{}
+	tw.children(n)
+	text := strings.TrimSpace(tw.String())
+	switch {
+	case text == "":
+		w.text(href)
+	case text == href, "mailto:"+text == href, "tel:"+text == href:
+	default:
+		w.space = true
+		w.flush()
+		w.buf.WriteString("(" + href + ")")
+	}
+}
+
+func (w *textWriter) list(n *html.Node) {
+	ordered := n.DataAtom == atom.Ol
+	w.lineBreak(1)
+	if w.indent == "" {
+		w.lineBreak(2)
+	}
+	indent, marker := w.indent, w.marker
+	w.indent += "  "
+	i := 1
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			w.node(c)
+			continue
+		}
+		w.lineBreak(1)
+		if ordered {
+			w.marker = strconv.Itoa(i) + ". "
+		} else {
+			w.marker = "* "
+		}
+		w.children(c)
+		w.marker = ""
+		i++
+	}
+	w.indent, w.marker = indent, marker
+	w.lineBreak(1)
+	if w.indent == "" {
+		w.lineBreak(2)
+	}
+}
+
+// blockAtoms are the elements that make a table cell that contains them a layout cell.
+var blockAtoms = map[atom.Atom]bool{
+	atom.P: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Table: true, atom.Pre: true, atom.Blockquote: true,
+	atom.Hr: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Header: true,
+	atom.Footer: true, atom.Nav: true, atom.Aside: true, atom.Main: true, atom.Center: true,
+}
+
+// hasBlock returns true if an element contains block elements.
+func hasBlock(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (blockAtoms[c.DataAtom] || hasBlock(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+// table writes the rows of a data table as lines of left-aligned columns. The cells of a
+// layout table, which has a single column or cells that contain blocks, are written as
+// blocks.
+func (w *textWriter) table(n *html.Node) {
+	var (
+		rows    [][]*html.Node
+		columns int
+		blocks  bool // whether a cell contains blocks
+	)
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collect(c)
+			case atom.Tr:
+				var row []*html.Node
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+						row = append(row, cell)
+						blocks = blocks || hasBlock(cell)
+					}
+				}
+				if len(row) > columns {
+					columns = len(row)
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	collect(n)
+	if columns <= 1 || blocks {
+		w.lineBreak(1)
+		for _, row := range rows {
+			for _, cell := range row {
+				w.lineBreak(1)
+				w.children(cell)
+				w.lineBreak(1)
+			}
+		}
+		w.lineBreak(1)
+		return
+	}
+	w.columns(rows)
+}
+
+// columns writes the cells of a data table as lines of left-aligned columns.
+func (w *textWriter) columns(cells [][]*html.Node) {
+	var (
+		rows   [][]string
+		header bool // whether the first row only contains <th> cells
+		widths []int
+	)
+	for _, cells := range cells {
+		var row []string
+		allHeaders := true
+		for _, cell := range cells {
+			allHeaders = allHeaders && cell.DataAtom == atom.Th
+			cw := textWriter{}
+			cw.children(cell)
+			row = append(row, strings.Join(strings.Fields(cw.String()), " "))
+		}
+		if len(rows) == 0 {
+			header = allHeaders && len(row) > 0
+		}
+		rows = append(rows, row)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	w.lineBreak(2)
+	for r, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			line.WriteString(cell)
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+			}
+		}
+		w.line(strings.TrimRight(line.String(), " "))
+		if r == 0 && header {
+			var rule []string
+			for _, n := range widths {
+				rule = append(rule, strings.Repeat("-", n))
+			}
+			w.line(strings.Join(rule, "  "))
+		}
+	}
+	w.lineBreak(2)
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func isHTMLSpaceByte(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\f' || ch == '\r'
+}
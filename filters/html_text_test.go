@@ -0,0 +1,52 @@
+package filters
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var htmlToTextTests = []struct{ in, expected string }{
+	{`plain  text`, "plain text"},
+	{`<p>one</p><p>two</p>`, "one\n\ntwo"},
+	{`a<br>b<br/><br />c`, "a\nb\n\nc"},
+	{`<div>a</div><div>b</div>`, "a\nb"},
+	{"<h1>Title</h1>\n<p>Some\n  <b>bold</b>\ttext.</p>", "Title\n\nSome bold text."},
+	{`Tom &amp; Jerry&nbsp;&lt;3`, "Tom & Jerry <3"},
+	{`<style>p { color: red }</style><script>x()</script><p>text</p>`, "text"},
+
+	// links
+	{`<a href="https://example.com">Example</a>`, "Example (https://example.com)"},
+	{`<a href="https://example.com">https://example.com</a>`, "https://example.com"},
+	{`<a href="mailto:a@example.com">a@example.com</a>`, "a@example.com"},
+	{`<a href="https://example.com"><img src="x.png"></a>`, "https://example.com"},
+	{`<a href="#top">Top</a> <a href="javascript:x()">x</a>`, "Top x"},
+
+	// lists
+	{`<ul><li>one</li><li>two</li></ul>`, "* one\n* two"},
+	{`<p>Items:</p><ol><li>one</li><li>two</li></ol><p>end</p>`, "Items:\n\n1. one\n2. two\n\nend"},
+	{`<ul><li>a<ul><li>b</li><li>c</li></ul></li><li>d</li></ul>`, "* a\n  * b\n  * c\n* d"},
+	{`<ul><li><a href="https://x.com">https://x.com</a></li><li><a href="https://x.com">X</a></li></ul>`, "* https://x.com\n* X (https://x.com)"},
+
+	// tables
+	{`<table><tr><th>Item</th><th>Qty</th></tr><tr><td>Apple</td><td>10</td></tr><tr><td>Kiwi fruit</td><td>2</td></tr></table>`,
+		"Item        Qty\n----------  ---\nApple       10\nKiwi fruit  2"},
+	{`<p>before</p><table><tbody><tr><td>a</td><td><b>b</b> c</td></tr></tbody></table><p>after</p>`, "before\n\na  b c\n\nafter"},
+	{`<table><tr><td><h1>Hello</h1><p>Para one.</p><ul><li>a</li><li>b</li></ul></td></tr></table>`, "Hello\n\nPara one.\n\n* a\n* b"},
+	{`<table><tr><td>Logo</td></tr><tr><td><p>Hi <a href="https://x.com">there</a>.</p></td></tr></table>`, "Logo\n\nHi there (https://x.com)."},
+	{`<table><tr><td><table><tr><td>a</td><td>1</td></tr></table></td><td><p>side</p></td></tr></table>`, "a  1\n\nside"},
+
+	// other blocks
+	{`<blockquote><p>quoted</p><p>text</p></blockquote>`, "> quoted\n>\n> text"},
+	{"<pre>a\n  b</pre>", "a\n  b"},
+	{`a<hr>b`, "a\n\n--------\n\nb"},
+}
+
+func TestHTMLToText(t *testing.T) {
+	for i, test := range htmlToTextTests {
+		t.Run(fmt.Sprintf("%02d", i+1), func(t *testing.T) {
+			require.Equalf(t, test.expected, HTMLToText(test.in), test.in)
+		})
+	}
+}
@@ -2,6 +2,7 @@
 package filters
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/md5"  // #nosec G501
 	"crypto/sha1" // #nosec G505
@@ -75,8 +76,10 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	fd.AddFilter("uniq", uniqFilter)
 
 	// date filters
-	fd.AddFilter("date", func(t interface{}, format func(string) string) (string, error) {
+	fd.AddFilter("date", func(ctx context.Context, t interface{}, format func(string) string) (string, error) {
 		f := format("%a, %b %d, %y")
+		// loc is the engine or render time zone, if any
+		loc := values.Location(ctx)
 		switch tp := t.(type) {
 		case date.Date:
 			d := t.(date.Date)
@@ -86,21 +89,24 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 			}
 			return tuesday.Strftime(f, tme)
 		case string:
-			tme, err := values.ParseDate(t.(string))
+			tme, err := values.ParseDateContext(ctx, t.(string))
 			if err != nil {
 				return "", err
 			}
 			return tuesday.Strftime(f, tme)
 		case time.Time:
 			tme := t.(time.Time)
+			if loc != nil {
+				tme = tme.In(loc)
+			}
 			return tuesday.Strftime(f, tme)
 		case int64:
 			unixTime := t.(int64)
-			tme := time.Unix(unixTime, 0)
+			tme := time.Unix(unixTime, 0).In(values.LocationOrLocal(ctx))
 			return tuesday.Strftime(f, tme)
 		case float64:
 			unixTime := t.(float64)
-			tme := time.Unix(int64(unixTime), 0)
+			tme := time.Unix(int64(unixTime), 0).In(values.LocationOrLocal(ctx))
 			return tuesday.Strftime(f, tme)
 		case nil:
 			return "", nil
@@ -108,6 +114,7 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 			return "", fmt.Errorf("date filter: unsupported type %T", tp)
 		}
 	})
+	AddDateFilters(fd)
 
 	// number filters
 	fd.AddFilter("to_number", func(value interface{}) float64 {
@@ -220,10 +227,8 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	fd.AddFilter("downcase", func(s, suffix string) string {
 		return strings.ToLower(s)
 	})
-	fd.AddFilter("escape", html.EscapeString)
-	fd.AddFilter("escape_once", func(s, suffix string) string {
-		return html.EscapeString(html.UnescapeString(s))
-	})
+	AddEscapeFilters(fd, false)
+	AddHTMLFilters(fd)
 	fd.AddFilter("newline_to_br", func(s string) string {
 		return strings.ReplaceAll(s, "\n", "<br />")
 	})
@@ -273,20 +278,16 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	})
 
 	fd.AddFilter("split", splitFilter)
-	fd.AddFilter("strip_html", func(s string) string {
-		// TODO this probably isn't sufficient
-		return regexp.MustCompile(`<.*?>`).ReplaceAllString(s, "")
-	})
-	fd.AddFilter("strip_newlines", func(s string) string {
+	fd.AddFilter("strip_newlines", markupFilter(func(s string) string {
 		return strings.ReplaceAll(s, "\n", "")
-	})
-	fd.AddFilter("strip", strings.TrimSpace)
-	fd.AddFilter("lstrip", func(s string) string {
+	}))
+	fd.AddFilter("strip", markupFilter(strings.TrimSpace))
+	fd.AddFilter("lstrip", markupFilter(func(s string) string {
 		return strings.TrimLeftFunc(s, unicode.IsSpace)
-	})
-	fd.AddFilter("rstrip", func(s string) string {
+	}))
+	fd.AddFilter("rstrip", markupFilter(func(s string) string {
 		return strings.TrimRightFunc(s, unicode.IsSpace)
-	})
+	}))
 	fd.AddFilter("truncate", func(s string, length func(int) int, ellipsis func(string) string) string {
 		n := length(50)
 		el := ellipsis("...")
@@ -309,6 +310,11 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	})
 	fd.AddFilter("url_encode", url.QueryEscape)
 	fd.AddFilter("url_decode", url.QueryUnescape)
+	AddURLFilters(fd)
+
+	// raw and safe opt out of auto-escaping
+	fd.AddFilter("raw", safeFilter)
+	fd.AddFilter("safe", safeFilter)
 
 	// debugging filters
 	// inspect is from Jekyll
@@ -322,6 +328,17 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 		}
 		return string(s)
 	})
+	// parse_json parses JSON into arrays, maps, strings, numbers, booleans and nil. Partial
+	// renders write the known arrays and maps that are left in residual templates with it.
+	fd.AddFilter("parse_json", func(s string) (interface{}, error) {
+		d := json.NewDecoder(strings.NewReader(s))
+		d.UseNumber()
+		var value interface{}
+		if err := d.Decode(&value); err != nil {
+			return nil, err
+		}
+		return jsonNumbers(value), nil
+	})
 	fd.AddFilter("type", func(value interface{}) string {
 		return fmt.Sprintf("%T", value)
 	})
@@ -338,6 +355,54 @@ func AddStandardFilters(fd FilterDictionary) { // nolint: gocyclo
 	fd.AddFilter("hmac_sha256", hmacFilter(sha256.New))
 }
 
+// AddEscapeFilters defines the escape and escape_once filters.
+// If safe is true, their results are SafeHTML, so that auto-escaping doesn't escape them a second time.
+func AddEscapeFilters(fd FilterDictionary, safe bool) {
+	if safe {
+		fd.AddFilter("escape", func(s string) values.SafeHTML {
+			return values.SafeHTML(html.EscapeString(s))
+		})
+		fd.AddFilter("escape_once", func(s, suffix string) values.SafeHTML {
+			return values.SafeHTML(html.EscapeString(html.UnescapeString(s)))
+		})
+		return
+	}
+	fd.AddFilter("escape", html.EscapeString)
+	fd.AddFilter("escape_once", func(s, suffix string) string {
+		return html.EscapeString(html.UnescapeString(s))
+	})
+}
+
+var stringType = reflect.TypeOf("")
+
+// markupFilter returns a string filter that keeps the markup of its input, so that its
+// result for SafeHTML is SafeHTML. Other string filters, such as upcase, can change
+// attribute values and entities, so their results aren't.
+func markupFilter(fn func(string) string) func(interface{}) interface{} {
+	return func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case nil:
+			return ""
+		case values.SafeHTML:
+			return values.SafeHTML(fn(string(v)))
+		}
+		return fn(values.MustConvert(value, stringType).(string))
+	}
+}
+
+func safeFilter(value interface{}) values.SafeHTML {
+	switch v := values.ToLiquid(value).(type) {
+	case nil:
+		return ""
+	case values.SafeHTML:
+		return v
+	case string:
+		return values.SafeHTML(v)
+	default:
+		return values.SafeHTML(fmt.Sprint(v))
+	}
+}
+
 func hashFilter(hashFn func() hash.Hash) func(value interface{}) string {
 	return func(value interface{}) string {
 		valueBytes := toBytes(value)
@@ -489,3 +554,25 @@ func eqItems(a, b interface{}) bool {
 	}
 	return reflect.DeepEqual(a, b)
 }
+
+// jsonNumbers replaces the numbers of a parsed JSON value with ints, or float64s if
+// they aren't integers.
+func jsonNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil && int64(int(n)) == n {
+			return int(n)
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonNumbers(e)
+		}
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = jsonNumbers(e)
+		}
+	}
+	return value
+}
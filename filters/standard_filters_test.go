@@ -193,6 +193,8 @@ var filterTests = []struct {
 	// Jekyll extensions; added here for convenient testing
 	// TODO add this just to the test environment
 	{`map | inspect`, `{"a":1}`},
+	{`'{"a":[1,2.5,"b",null]}' | parse_json`, map[string]interface{}{"a": []interface{}{1, 2.5, "b", nil}}},
+	{`'"it\u0027s \"x\""' | parse_json`, `it's "x"`},
 	{`1 | type`, `int`},
 	{`"1" | type`, `string`},
 
@@ -0,0 +1,156 @@
+package filters
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// The trackURL filter, without a link rewriter, writes its URL between these markers.
+// The URL filters operate on the URL inside the markers.
+const (
+	TrackURLPrefix = "$$TRACK_ME:"
+	TrackURLSuffix = "$$"
+)
+
+// utmParams are the standard campaign parameters, in the order that set_utm adds them.
+var utmParams = []string{"source", "medium", "campaign", "term", "content"}
+
+// AddURLFilters defines the filters that inspect and modify URLs.
+func AddURLFilters(fd FilterDictionary) {
+	fd.AddFilter("add_query", func(s, key string, value interface{}) (string, error) {
+		return editURL(s, func(u *url.URL) {
+			u.RawQuery = setQuery(u.RawQuery, key, queryValue(value))
+		})
+	})
+	fd.AddFilter("remove_query", func(s string, keys ...string) (string, error) {
+		return editURL(s, func(u *url.URL) {
+			for _, key := range keys {
+				u.RawQuery = setQuery(u.RawQuery, key, nil)
+			}
+		})
+	})
+	fd.AddFilter("set_utm", func(s string, params map[string]interface{}) (string, error) {
+		return editURL(s, func(u *url.URL) {
+			for _, key := range utmKeys(params) {
+				name := key
+				if !strings.HasPrefix(name, "utm_") {
+					name = "utm_" + name
+				}
+				u.RawQuery = setQuery(u.RawQuery, name, queryValue(params[key]))
+			}
+		})
+	})
+	fd.AddFilter("url_join", func(s string, elems ...string) (string, error) {
+		return editURL(s, func(u *url.URL) {
+			*u = *u.JoinPath(elems...)
+		})
+	})
+	fd.AddFilter("url_host", func(s string) (string, error) {
+		u, err := url.Parse(trackedURL(s))
+		if err != nil {
+			return "", err
+		}
+		return u.Host, nil
+	})
+	fd.AddFilter("url_path", func(s string) (string, error) {
+		u, err := url.Parse(trackedURL(s))
+		if err != nil {
+			return "", err
+		}
+		return u.Path, nil
+	})
+}
+
+// trackedURL returns the URL inside a trackURL marker, or s if it isn't a marker.
+func trackedURL(s string) string {
+	if strings.HasPrefix(s, TrackURLPrefix) && strings.HasSuffix(s, TrackURLSuffix) && len(s) >= len(TrackURLPrefix)+len(TrackURLSuffix) {
+		return s[len(TrackURLPrefix) : len(s)-len(TrackURLSuffix)]
+	}
+	return s
+}
+
+// editURL parses s, applies fn, and formats the result.
+// If s is a trackURL marker, fn applies to the URL inside it, and the result is a marker.
+func editURL(s string, fn func(*url.URL)) (string, error) {
+	inner := trackedURL(s)
+	u, err := url.Parse(inner)
+	if err != nil {
+		return "", err
+	}
+	fn(u)
+	if inner != s {
+		return TrackURLPrefix + u.String() + TrackURLSuffix, nil
+	}
+	return u.String(), nil
+}
+
+// setQuery returns the raw query with the value of key replaced by value,
+// or removed if value is nil. Other parameters keep their order and encoding.
+func setQuery(rawQuery, key string, value *string) string {
+	var (
+		parts []string
+		found bool
+	)
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		k := part
+		if i := strings.IndexByte(k, '='); i >= 0 {
+			k = k[:i]
+		}
+		if uk, err := url.QueryUnescape(k); err == nil && uk == key {
+			if value != nil && !found {
+				parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(*value))
+			}
+			found = true
+			continue
+		}
+		parts = append(parts, part)
+	}
+	if value != nil && !found {
+		parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(*value))
+	}
+	return strings.Join(parts, "&")
+}
+
+// queryValue returns the query parameter value of a filter argument.
+// A nil argument removes the parameter.
+func queryValue(value interface{}) *string {
+	if value == nil {
+		return nil
+	}
+	s := fmt.Sprint(value)
+	return &s
+}
+
+// utmKeys returns the keys of params: the standard campaign parameters in their usual
+// order, followed by any others in alphabetical order.
+func utmKeys(params map[string]interface{}) []string {
+	var keys, others []string
+	for _, name := range utmParams {
+		for _, key := range []string{name, "utm_" + name} {
+			if _, ok := params[key]; ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	for key := range params {
+		if !contains(keys, key) {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	return append(keys, others...)
+}
+
+func contains(a []string, s string) bool {
+	for _, t := range a {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package filters
+
+import (
+	gocontext "context"
+	"fmt"
+	"testing"
+
+	"github.com/autopilot3/liquid/expressions"
+	"github.com/stretchr/testify/require"
+)
+
+var urlFilterTests = []struct {
+	in       string
+	expected interface{}
+}{
+	{`"https://example.com/a" | add_query: 'utm_source', 'email'`, "https://example.com/a?utm_source=email"},
+	{`"https://example.com/a?b=2&a=1#top" | add_query: 'c', 'x y&z'`, "https://example.com/a?b=2&a=1&c=x+y%26z#top"},
+	{`"https://example.com/a?b=2&a=1&b=3" | add_query: 'b', 4`, "https://example.com/a?b=4&a=1"},
+	{`"/a?x=%2F" | add_query: 'y', 1`, "/a?x=%2F&y=1"},
+	{`"https://example.com/a?b=2&a=1&utm_x=3#f" | remove_query: 'b'`, "https://example.com/a?a=1&utm_x=3#f"},
+	{`"https://example.com/a?b=2&a=1" | remove_query: 'a', 'b'`, "https://example.com/a"},
+	{`"https://example.com/a" | remove_query: 'a'`, "https://example.com/a"},
+	{`"https://example.com/a/?q=1#f" | url_join: 'b', 'c d'`, "https://example.com/a/b/c%20d?q=1#f"},
+	{`"https://example.com" | url_join: 'b'`, "https://example.com/b"},
+	{`"https://example.com:8080/a/b?q=1" | url_host`, "example.com:8080"},
+	{`"https://example.com/a%20b/c?q=1" | url_path`, "/a b/c"},
+	{`"https://example.com/?utm_source=web&x=1" | set_utm: utm`, "https://example.com/?utm_source=email&x=1&utm_medium=newsletter&utm_campaign=spring+sale"},
+	{`"https://example.com/?x=1" | set_utm: utm_prefixed`, "https://example.com/?x=1&utm_source=email&utm_id=7"},
+
+	// trackURL markers
+	{`"$$TRACK_ME:https://example.com/a?x=1$$" | add_query: 'y', 2`, "$$TRACK_ME:https://example.com/a?x=1&y=2$$"},
+	{`"$$TRACK_ME:https://example.com/a?x=1$$" | remove_query: 'x'`, "$$TRACK_ME:https://example.com/a$$"},
+	{`"$$TRACK_ME:https://example.com/a$$" | url_host`, "example.com"},
+}
+
+var urlFilterTestBindings = map[string]interface{}{
+	"utm": map[string]interface{}{
+		"campaign": "spring sale",
+		"medium":   "newsletter",
+		"source":   "email",
+	},
+	"utm_prefixed": map[string]interface{}{
+		"utm_source": "email",
+		"utm_id":     7,
+	},
+}
+
+func TestURLFilters(t *testing.T) {
+	cfg := expressions.NewConfig(gocontext.Background())
+	AddStandardFilters(&cfg)
+	context := expressions.NewContext(urlFilterTestBindings, cfg)
+
+	for i, test := range urlFilterTests {
+		t.Run(fmt.Sprintf("%02d", i+1), func(t *testing.T) {
+			actual, err := expressions.EvaluateString(test.in, context)
+			require.NoErrorf(t, err, test.in)
+			require.Equalf(t, test.expected, actual, test.in)
+		})
+	}
+
+	_, err := expressions.EvaluateString(`"http://[::1" | url_host`, context)
+	require.Error(t, err)
+}
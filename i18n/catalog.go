@@ -0,0 +1,146 @@
+// Package i18n provides the message catalogs of the t filter.
+package i18n
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// A Catalog supplies translated messages, keyed by locale and message key.
+type Catalog interface {
+	// Message returns the message for key in locale, and whether there is one.
+	// It doesn't fall back to other locales.
+	Message(locale, key string) (string, bool)
+}
+
+// MapCatalog is an in-memory Catalog. It maps locales to message keys to messages.
+type MapCatalog map[string]map[string]string
+
+// Message implements Catalog.
+func (c MapCatalog) Message(locale, key string) (string, bool) {
+	msg, ok := c[locale][key]
+	return msg, ok
+}
+
+// Add adds messages to the catalog, replacing existing messages with the same keys.
+func (c MapCatalog) Add(locale string, messages map[string]string) {
+	m, ok := c[locale]
+	if !ok {
+		m = map[string]string{}
+		c[locale] = m
+	}
+	for k, v := range messages {
+		m[k] = v
+	}
+}
+
+// ParseYAML parses messages in the format of Rails and Shopify locale files:
+// a mapping from each locale to nested mappings of messages.
+//
+//	en:
+//	  footer:
+//	    unsubscribe: "Unsubscribe {{ name }}"
+//
+// The message keys are the dotted paths, such as "footer.unsubscribe".
+func ParseYAML(data []byte) (MapCatalog, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	c := MapCatalog{}
+	for locale, tree := range doc {
+		messages := map[string]string{}
+		if err := flatten(messages, "", tree); err != nil {
+			return nil, fmt.Errorf("locale %s: %s", locale, err)
+		}
+		c.Add(locale, messages)
+	}
+	return c, nil
+}
+
+func flatten(messages map[string]string, prefix string, tree interface{}) error {
+	switch tree := tree.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range tree {
+			key := fmt.Sprint(k)
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			if err := flatten(messages, key, v); err != nil {
+				return err
+			}
+		}
+	case nil:
+	case []interface{}:
+		return fmt.Errorf("%s: a message must be a string, not a list", prefix)
+	default:
+		if prefix == "" {
+			return fmt.Errorf("expected a mapping of messages")
+		}
+		messages[prefix] = fmt.Sprint(tree)
+	}
+	return nil
+}
+
+// Fallbacks returns the locales to look up a message in, in order: locale, its parent
+// locales, defaultLocale, and its parent locales. For example, the fallbacks of "fr-CA"
+// with the default "en-US" are "fr-CA", "fr", "en-US", "en".
+// An underscore separator, as in "fr_CA", is the same as a hyphen.
+func Fallbacks(locale, defaultLocale string) []string {
+	var result []string
+	for _, l := range []string{locale, defaultLocale} {
+		l = strings.ReplaceAll(l, "_", "-")
+		for l != "" {
+			if !contains(result, l) {
+				result = append(result, l)
+			}
+			i := strings.LastIndexByte(l, '-')
+			if i < 0 {
+				break
+			}
+			l = l[:i]
+		}
+	}
+	return result
+}
+
+// Lookup returns the message for key in the first of the fallbacks of locale that has one,
+// and the locale that it was found in.
+func Lookup(c Catalog, locale, defaultLocale, key string) (msg, found string, ok bool) {
+	for _, l := range Fallbacks(locale, defaultLocale) {
+		if msg, ok := c.Message(l, key); ok {
+			return msg, l, true
+		}
+	}
+	return "", "", false
+}
+
+var placeholderRE = regexp.MustCompile(`{{\s*([\w-]+)\s*}}`)
+
+// Interpolate replaces each {{ name }} in msg by the value of the named variable.
+// A placeholder for a missing variable is replaced by the empty string.
+func Interpolate(msg string, vars map[string]interface{}) string {
+	if !strings.Contains(msg, "{{") {
+		return msg
+	}
+	return placeholderRE.ReplaceAllStringFunc(msg, func(m string) string {
+		name := placeholderRE.FindStringSubmatch(m)[1]
+		v, ok := vars[name]
+		if !ok || v == nil {
+			return ""
+		}
+		return fmt.Sprint(v)
+	})
+}
+
+func contains(a []string, s string) bool {
+	for _, t := range a {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
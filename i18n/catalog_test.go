@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallbacks(t *testing.T) {
+	require.Equal(t, []string{"fr-CA", "fr", "en"}, Fallbacks("fr-CA", "en"))
+	require.Equal(t, []string{"fr-CA", "fr", "en-US", "en"}, Fallbacks("fr_CA", "en-US"))
+	require.Equal(t, []string{"zh-Hant-TW", "zh-Hant", "zh", "en"}, Fallbacks("zh-Hant-TW", "en"))
+	require.Equal(t, []string{"en"}, Fallbacks("en", "en"))
+	require.Equal(t, []string{"en"}, Fallbacks("", "en"))
+	require.Empty(t, Fallbacks("", ""))
+}
+
+func TestParseYAML(t *testing.T) {
+	c, err := ParseYAML([]byte(`
+en:
+  footer:
+    unsubscribe: "Unsubscribe, {{ name }}"
+    address: 1 Main St
+  greeting: Hello
+fr:
+  footer:
+    unsubscribe: "Se désabonner, {{name}}"
+fr-CA:
+  greeting: Allô
+`))
+	require.NoError(t, err)
+	require.Equal(t, MapCatalog{
+		"en":    {"footer.unsubscribe": "Unsubscribe, {{ name }}", "footer.address": "1 Main St", "greeting": "Hello"},
+		"fr":    {"footer.unsubscribe": "Se désabonner, {{name}}"},
+		"fr-CA": {"greeting": "Allô"},
+	}, c)
+
+	_, err = ParseYAML([]byte("en: [a, b]"))
+	require.Error(t, err)
+	_, err = ParseYAML([]byte("en:\n  key: [a, b]"))
+	require.Error(t, err)
+	_, err = ParseYAML([]byte(":"))
+	require.Error(t, err)
+}
+
+func TestLookup(t *testing.T) {
+	c := MapCatalog{}
+	c.Add("en", map[string]string{"greeting": "Hello", "bye": "Bye"})
+	c.Add("fr", map[string]string{"greeting": "Bonjour"})
+	c.Add("fr-CA", map[string]string{"greeting": "Allô"})
+
+	msg, locale, ok := Lookup(c, "fr-CA", "en", "greeting")
+	require.True(t, ok)
+	require.Equal(t, "Allô", msg)
+	require.Equal(t, "fr-CA", locale)
+
+	msg, locale, ok = Lookup(c, "fr-FR", "en", "greeting")
+	require.True(t, ok)
+	require.Equal(t, "Bonjour", msg)
+	require.Equal(t, "fr", locale)
+
+	msg, locale, ok = Lookup(c, "fr-CA", "en", "bye")
+	require.True(t, ok)
+	require.Equal(t, "Bye", msg)
+	require.Equal(t, "en", locale)
+
+	_, _, ok = Lookup(c, "fr-CA", "en", "missing")
+	require.False(t, ok)
+}
+
+func TestInterpolate(t *testing.T) {
+	vars := map[string]interface{}{"name": "Ann", "count": 3, "nil": nil}
+	require.Equal(t, "Hi Ann, 3 items", Interpolate("Hi {{ name }}, {{count}} items", vars))
+	require.Equal(t, "Hi !", Interpolate("Hi {{ missing }}{{ nil }}!", vars))
+	require.Equal(t, "{{ not a var }}", Interpolate("{{ not a var }}", vars))
+	require.Equal(t, "plain", Interpolate("plain", nil))
+}
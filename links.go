@@ -0,0 +1,141 @@
+package liquid
+
+import (
+	"bytes"
+	"context"
+	"html"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+
+	"github.com/autopilot3/liquid/filters"
+	"github.com/autopilot3/liquid/render"
+)
+
+// A Link is a URL that a LinkRewriter rewrites.
+type Link = render.Link
+
+// A LinkRewriter replaces the URLs of links, for example with click-tracking URLs.
+//
+// See Engine.SetLinkRewriter.
+type LinkRewriter = render.LinkRewriter
+
+// LinkRewriterFunc adapts a function to a LinkRewriter.
+type LinkRewriterFunc = render.LinkRewriterFunc
+
+// trackURL is the trackURL filter.
+//
+// Without a LinkRewriter, it returns a $$TRACK_ME:url$$ marker, for services that
+// replace the markers in the rendered output.
+func trackURL(ctx context.Context, s string) (string, error) {
+	u := html.UnescapeString(s)
+	cfg := render.ContextConfig(ctx)
+	if cfg == nil || cfg.Links == nil {
+		return filters.TrackURLPrefix + u + filters.TrackURLSuffix, nil
+	}
+	return cfg.Links.Rewrite(ctx, u, false)
+}
+
+// rewriteLinks rewrites the http and https hrefs of the <a> elements in src.
+// Text outside these attribute values is copied unchanged.
+func rewriteLinks(ctx context.Context, links *render.Links, src []byte) ([]byte, error) {
+	var (
+		out = bytes.NewBuffer(make([]byte, 0, len(src)))
+		z   = nethtml.NewTokenizer(bytes.NewReader(src))
+	)
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			// A bytes.Reader only returns io.EOF.
+			return out.Bytes(), nil
+		}
+		raw := z.Raw()
+		if tt != nethtml.StartTagToken && tt != nethtml.SelfClosingTagToken {
+			out.Write(raw)
+			continue
+		}
+		if name, _ := z.TagName(); string(name) != "a" {
+			out.Write(raw)
+			continue
+		}
+		start, end, quoted, ok := attrValue(raw, "href")
+		if !ok {
+			out.Write(raw)
+			continue
+		}
+		u := html.UnescapeString(string(raw[start:end]))
+		lower := strings.ToLower(strings.TrimSpace(u))
+		if links.Rewritten(u) || !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+			out.Write(raw)
+			continue
+		}
+		rewritten, err := links.Rewrite(ctx, u, true)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(raw[:start])
+		if quoted {
+			out.WriteString(html.EscapeString(rewritten))
+		} else {
+			out.WriteString(`"` + html.EscapeString(rewritten) + `"`)
+		}
+		out.Write(raw[end:])
+	}
+}
+
+// attrValue returns the bounds of the value of the named attribute in the source
+// text of a start tag, and whether the value is quoted.
+func attrValue(tag []byte, name string) (start, end int, quoted, ok bool) {
+	i := 1 // skip "<"
+	for i < len(tag) && !isSpace(tag[i]) && tag[i] != '/' && tag[i] != '>' {
+		i++
+	}
+	for i < len(tag) {
+		for i < len(tag) && (isSpace(tag[i]) || tag[i] == '/') {
+			i++
+		}
+		if i >= len(tag) || tag[i] == '>' {
+			return 0, 0, false, false
+		}
+		j := i
+		i++ // an attribute name can start with "="
+		for i < len(tag) && !isSpace(tag[i]) && tag[i] != '/' && tag[i] != '>' && tag[i] != '=' {
+			i++
+		}
+		key := string(tag[j:i])
+		for i < len(tag) && isSpace(tag[i]) {
+			i++
+		}
+		if i >= len(tag) || tag[i] != '=' {
+			continue
+		}
+		i++
+		for i < len(tag) && isSpace(tag[i]) {
+			i++
+		}
+		if i < len(tag) && (tag[i] == '"' || tag[i] == '\'') {
+			q := tag[i]
+			start = i + 1
+			end = bytes.IndexByte(tag[start:], q)
+			if end < 0 {
+				return 0, 0, false, false
+			}
+			end += start
+			quoted, i = true, end+1
+		} else {
+			start = i
+			for i < len(tag) && !isSpace(tag[i]) && tag[i] != '>' {
+				i++
+			}
+			end, quoted = i, false
+		}
+		if strings.EqualFold(key, name) {
+			return start, end, quoted, true
+		}
+	}
+	return 0, 0, false, false
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\f' || ch == '\r'
+}
@@ -0,0 +1,59 @@
+package liquid
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testLinkRewriter() LinkRewriter {
+	return LinkRewriterFunc(func(_ context.Context, link Link) (string, error) {
+		if link.URL == "https://example.com/error" {
+			return "", fmt.Errorf("rewrite error")
+		}
+		return fmt.Sprintf("https://t.example.com/%v/%d?auto=%v&u=%s", link.Data["recipient"], link.Sequence, link.Auto, link.URL), nil
+	})
+}
+
+func TestTrackURL(t *testing.T) {
+	src := `<a href="{{ 'https://example.com/a?x=1&amp;y=2' | trackURL }}">a</a>`
+	out, err := NewEngine().ParseAndRenderString(src, nil)
+	require.NoError(t, err)
+	require.Equal(t, `<a href="$$TRACK_ME:https://example.com/a?x=1&y=2$$">a</a>`, out)
+
+	engine := NewEngine().SetLinkRewriter(testLinkRewriter())
+	out, err = engine.ParseAndRenderString(src+`{{ 'https://example.com/b' | trackURL }}`, nil, WithLinkData(map[string]interface{}{"recipient": 42}))
+	require.NoError(t, err)
+	require.Equal(t, `<a href="https://t.example.com/42/0?auto=false&u=https://example.com/a?x=1&y=2">a</a>https://t.example.com/42/1?auto=false&u=https://example.com/b`, out)
+
+	_, err = engine.ParseAndRenderString(`{{ 'https://example.com/error' | trackURL }}`, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "rewrite error")
+}
+
+func TestEngine_RewriteAllLinks(t *testing.T) {
+	engine := NewEngine().SetLinkRewriter(testLinkRewriter()).RewriteAllLinks(true)
+	tpl, err := engine.ParseString(`<p class="x">Hi</p>
+<a title="t" href="https://example.com/a?x=1&amp;y=2">a</a>
+<a href='{{ "https://example.com/b" | trackURL }}'>b</a>
+<A HREF=https://example.com/c>c</A>
+<a href="mailto:a@example.com">mail</a> <a href="#top">top</a> <a name="x">x</a>
+<script>var s = '<a href="https://example.com/d">';</script>`)
+	require.NoError(t, err)
+	for _, id := range []int{1, 2} {
+		out, err := tpl.RenderString(nil, WithLinkData(map[string]interface{}{"recipient": id}))
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf(`<p class="x">Hi</p>
+<a title="t" href="https://t.example.com/%[1]d/1?auto=true&amp;u=https://example.com/a?x=1&amp;y=2">a</a>
+<a href='https://t.example.com/%[1]d/0?auto=false&u=https://example.com/b'>b</a>
+<A HREF="https://t.example.com/%[1]d/2?auto=true&amp;u=https://example.com/c">c</A>
+<a href="mailto:a@example.com">mail</a> <a href="#top">top</a> <a name="x">x</a>
+<script>var s = '<a href="https://example.com/d">';</script>`, id), out)
+	}
+
+	_, err = engine.ParseAndRenderString(`<a href="https://example.com/error">`, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "rewrite error")
+}
@@ -0,0 +1,395 @@
+// Package lint checks compiled templates for mistakes that rendering only reports on the
+// path that it takes, if at all: unknown filters, filter arguments that the filter
+// doesn't take, variables that are assigned and never used or used before they're
+// assigned, branches that are never taken, and loop tags outside a loop.
+package lint
+
+import (
+	gocontext "context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/autopilot3/liquid/expressions"
+	"github.com/autopilot3/liquid/parser"
+	"github.com/autopilot3/liquid/render"
+)
+
+// Severity is the severity of a Finding.
+type Severity int
+
+const (
+	// Info is a finding that doesn't change the output, such as a deprecated filter.
+	Info Severity = iota
+	// Warning is a finding that is probably a mistake.
+	Warning
+	// Error is a finding that makes rendering fail, or ignore part of the template.
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// The rules of findings.
+const (
+	UnknownFilter     = "unknown-filter"
+	FilterArguments   = "filter-arguments"
+	DeprecatedFilter  = "deprecated-filter"
+	UnusedAssign      = "unused-assign"
+	UseBeforeAssign   = "use-before-assign"
+	BranchOrder       = "branch-order"
+	UnreachableBranch = "unreachable-branch"
+	OutsideLoop       = "outside-loop"
+)
+
+// A Finding is a problem that Lint finds in a template.
+type Finding struct {
+	Severity Severity
+	// Rule is the check that found the problem, such as UnknownFilter.
+	Rule string
+	// Line and Column are the position of the problem. Columns are counted in characters
+	// from 1.
+	Line, Column int
+	Message      string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", f.Line, f.Column, f.Severity, f.Message, f.Rule)
+}
+
+var (
+	namedArgsType = reflect.TypeOf(expressions.NamedArgs{})
+	contextType   = reflect.TypeOf((*gocontext.Context)(nil)).Elem()
+)
+
+// loopTags are the tags that only work in a loop.
+var loopTags = map[string]bool{
+	"break":    true,
+	"continue": true,
+	"cycle":    true,
+}
+
+// An assignment is an assign or capture of a variable. order is its position in the
+// template, and loops are the loops that contain it.
+type assignment struct {
+	name         string
+	line, column int
+	order        int
+	loops        []int
+}
+
+// A use is a reference to a variable.
+type use struct {
+	name         string
+	line, column int
+	order        int
+	loops        []int
+}
+
+type linter struct {
+	cfg      *render.Config
+	line     func(int) int // converts the line of a source location to the line to report
+	findings []Finding
+	loops    []int // the enclosing loops
+	nextLoop int
+	order    int
+	assigns  []assignment
+	uses     []use
+}
+
+// Lint returns the problems in a compiled template, sorted by position. The filters are
+// those of cfg. If line isn't nil, it converts the lines of the template's source
+// locations to the lines that the findings report, in their positions and messages.
+func Lint(node render.Node, cfg *render.Config, line func(int) int) []Finding {
+	l := linter{cfg: cfg, line: line}
+	if l.line == nil {
+		l.line = func(n int) int { return n }
+	}
+	l.node(node)
+	l.variables()
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		a, b := l.findings[i], l.findings[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.findings
+}
+
+func (l *linter) report(loc parser.SourceLoc, severity Severity, rule, format string, a ...interface{}) {
+	l.findings = append(l.findings, Finding{
+		Severity: severity,
+		Rule:     rule,
+		Line:     l.line(loc.LineNo),
+		Column:   loc.ColNo,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+func (l *linter) node(node render.Node) {
+	switch n := node.(type) {
+	case *render.SeqNode:
+		l.nodes(n.Children)
+	case *render.ObjectNode:
+		l.expression(n.Token, tree(n.Args))
+	case *render.TagNode:
+		l.tag(n.Token)
+	case *render.BlockNode:
+		l.block(n)
+	}
+}
+
+func (l *linter) nodes(nodes []render.Node) {
+	for _, n := range nodes {
+		l.node(n)
+	}
+}
+
+func (l *linter) tag(tok parser.Token) {
+	switch {
+	case tok.Name == "assign":
+		stmt, err := expressions.ParseStatement(expressions.AssignStatementSelector, tok.Args)
+		if err != nil {
+			return
+		}
+		l.expression(tok, stmt.Assignment.Value)
+		l.assign(tok, stmt.Assignment.Variable)
+	case loopTags[tok.Name]:
+		if len(l.loops) == 0 {
+			l.report(tok.SourceLoc, Error, OutsideLoop, "%s outside a loop", tok.Name)
+		}
+	}
+}
+
+func (l *linter) block(n *render.BlockNode) {
+	switch n.Name {
+	case "if", "unless":
+		l.branches(n)
+		l.expression(n.Token, tree(n.Args))
+		l.nodes(n.Body)
+		for _, c := range n.Clauses {
+			if c.Name == "elsif" {
+				l.expression(c.Token, tree(c.Args))
+			}
+			l.nodes(c.Body)
+		}
+	case "case":
+		l.branches(n)
+		l.expression(n.Token, tree(n.Args))
+		l.nodes(n.Body)
+		for _, c := range n.Clauses {
+			if c.Name == "when" {
+				if stmt, err := expressions.ParseStatement(expressions.WhenStatementSelector, c.Args); err == nil {
+					l.expression(c.Token, stmt.When.Values...)
+				}
+			}
+			l.nodes(c.Body)
+		}
+	case "for", "tablerow":
+		if stmt, err := expressions.ParseStatement(expressions.LoopStatementSelector, n.Args); err == nil {
+			l.expression(n.Token, stmt.Loop.Collection)
+		}
+		l.loops = append(l.loops, l.nextLoop)
+		l.nextLoop++
+		l.nodes(n.Body)
+		l.loops = l.loops[:len(l.loops)-1]
+		for _, c := range n.Clauses {
+			l.nodes(c.Body)
+		}
+	case "capture":
+		l.nodes(n.Body)
+		l.assign(n.Token, strings.TrimSpace(n.Args))
+	default:
+		l.nodes(n.Body)
+		for _, c := range n.Clauses {
+			l.nodes(c.Body)
+		}
+	}
+}
+
+// branches reports clauses after an else, and branches of an if or unless that are never
+// taken because a condition is constant.
+func (l *linter) branches(n *render.BlockNode) {
+	var elseClause *render.BlockNode
+	for _, c := range n.Clauses {
+		if elseClause != nil {
+			l.report(c.SourceLoc, Error, BranchOrder, "%s after the else on line %d is never reached", c.Name, l.line(elseClause.SourceLoc.LineNo))
+		} else if c.Name == "else" {
+			elseClause = c
+		}
+	}
+	if n.Name == "case" {
+		return
+	}
+	for i, b := range append([]*render.BlockNode{n}, n.Clauses...) {
+		if b.Name == "else" {
+			return
+		}
+		value, ok := l.constant(b.Args)
+		if !ok {
+			continue
+		}
+		truthy := value != nil && value != false
+		if i == 0 && n.Name == "unless" {
+			truthy = !truthy
+		}
+		if !truthy {
+			l.report(b.SourceLoc, Warning, UnreachableBranch, "the condition of this %s is always false", b.Name)
+			continue
+		}
+		for _, c := range n.Clauses[i:] {
+			l.report(c.SourceLoc, Warning, UnreachableBranch, "%s is never reached, because the condition on line %d is always true", c.Name, l.line(b.SourceLoc.LineNo))
+		}
+		return
+	}
+}
+
+// constant returns the value of an expression without variables.
+func (l *linter) constant(source string) (interface{}, bool) {
+	n := tree(source)
+	if n == nil {
+		return nil, false
+	}
+	constant := true
+	expressions.Walk(n, func(n expressions.Node) bool {
+		if _, ok := n.(*expressions.Variable); ok {
+			constant = false
+		}
+		return constant
+	})
+	if !constant {
+		return nil, false
+	}
+	value, err := expressions.EvaluateString(source, expressions.NewContext(map[string]interface{}{}, l.cfg.Config.Config))
+	return value, err == nil
+}
+
+// tree parses the arguments of a token as an expression. It returns nil if they aren't one.
+func tree(args string) expressions.Node {
+	n, err := expressions.ParseTree(args)
+	if err != nil {
+		return nil
+	}
+	return n
+}
+
+// expression records the variable uses of the expression trees of the arguments of a
+// token, and checks their filters.
+func (l *linter) expression(tok parser.Token, trees ...expressions.Node) {
+	start := strings.LastIndex(tok.Source, tok.Args)
+	for _, n := range trees {
+		expressions.Walk(n, func(n expressions.Node) bool {
+			switch n := n.(type) {
+			case *expressions.Variable:
+				loc := tok.LocAt(start + n.Start)
+				l.order++
+				l.uses = append(l.uses, use{n.Name, loc.LineNo, loc.ColNo, l.order, l.scope()})
+			case *expressions.Filter:
+				l.filter(tok.LocAt(start+n.NameSpan.Start), n)
+			}
+			return true
+		})
+	}
+}
+
+// filter checks that a filter is defined, and takes the arguments that it's given.
+func (l *linter) filter(loc parser.SourceLoc, call *expressions.Filter) {
+	fn, ok := l.cfg.Filter(call.Name)
+	if !ok {
+		l.report(loc, Error, UnknownFilter, "unknown filter %s", call.Name)
+		return
+	}
+	if replacement, ok := l.cfg.FilterDeprecation(call.Name); ok {
+		if replacement != "" {
+			l.report(loc, Info, DeprecatedFilter, "%s is deprecated; use %s instead", call.Name, replacement)
+		} else {
+			l.report(loc, Info, DeprecatedFilter, "%s is deprecated", call.Name)
+		}
+	}
+	t := reflect.TypeOf(fn)
+	params := t.NumIn() - 1 // the parameters after the input
+	if t.In(0) == contextType {
+		params--
+	}
+	if t.IsVariadic() {
+		return
+	}
+	named := params > 0 && t.In(t.NumIn()-1) == namedArgsType
+	switch {
+	case named:
+		params--
+	case len(call.Named) > 0:
+		l.report(loc, Error, FilterArguments, "%s doesn't take named arguments", call.Name)
+		return
+	}
+	if len(call.Args) > params {
+		l.report(loc, Error, FilterArguments, "%s takes %s, but is given %d", call.Name, plural(params, "argument"), len(call.Args))
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// assign records the assignment of a variable by a token.
+func (l *linter) assign(tok parser.Token, name string) {
+	if name == "" {
+		return
+	}
+	offset := strings.LastIndex(tok.Source, tok.Args)
+	if i := strings.Index(tok.Args, name); i >= 0 {
+		offset += i
+	}
+	loc := tok.LocAt(offset)
+	l.order++
+	l.assigns = append(l.assigns, assignment{name, loc.LineNo, loc.ColNo, l.order, l.scope()})
+}
+
+func (l *linter) scope() []int {
+	return append([]int(nil), l.loops...)
+}
+
+// variables reports assigned variables that aren't used after they're assigned, and
+// uses before the first assignment. A use in a loop before an assignment in the same
+// loop is a use of the previous iteration's value.
+func (l *linter) variables() {
+	first := map[string]assignment{}
+	for _, a := range l.assigns {
+		if _, ok := first[a.name]; !ok {
+			first[a.name] = a
+		}
+		used := false
+		for _, u := range l.uses {
+			if u.name == a.name && (u.order > a.order || shareLoop(u.loops, a.loops)) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			l.report(parser.SourceLoc{LineNo: a.line, ColNo: a.column}, Warning, UnusedAssign, "%s is assigned but never used", a.name)
+		}
+	}
+	for _, u := range l.uses {
+		if a, ok := first[u.name]; ok && u.order < a.order && !shareLoop(u.loops, a.loops) {
+			l.report(parser.SourceLoc{LineNo: u.line, ColNo: u.column}, Warning, UseBeforeAssign, "%s is used before it's assigned on line %d", u.name, l.line(a.line))
+		}
+	}
+}
+
+func shareLoop(a, b []int) bool {
+	return len(a) > 0 && len(b) > 0 && a[0] == b[0]
+}
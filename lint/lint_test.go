@@ -0,0 +1,44 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/autopilot3/liquid/filters"
+	"github.com/autopilot3/liquid/parser"
+	"github.com/autopilot3/liquid/render"
+	"github.com/autopilot3/liquid/tags"
+	"github.com/stretchr/testify/require"
+)
+
+const lintTemplate = `{% assign unused = 1 %}
+{{ name | upcas }}
+{{ name | plus: 1, 2 | upcase: n: 1 }}
+{{ greeting }}{% assign greeting = 'hi' %}{{ greeting }}
+{% if x %}a{% else %}b{% elsif y %}c{% endif %}
+{% if false %}a{% elsif true %}b{% else %}c{% endif %}
+{% break %}
+{% for i in items %}{% if prev %}{{ prev }}{% endif %}{% assign prev = i %}{% cycle 'a', 'b' %}{% endfor %}
+{% case x %}{% else %}a{% when 1 %}b{% endcase %}`
+
+func TestLint(t *testing.T) {
+	cfg := render.NewConfig()
+	filters.AddStandardFilters(&cfg)
+	tags.AddStandardTags(cfg)
+	cfg.DeprecateFilter("upcase", "")
+	root, err := cfg.Compile(lintTemplate, parser.SourceLoc{LineNo: 1})
+	require.NoError(t, err)
+	require.Equal(t, []Finding{
+		{Warning, UnusedAssign, 1, 11, "unused is assigned but never used"},
+		{Error, UnknownFilter, 2, 11, "unknown filter upcas"},
+		{Error, FilterArguments, 3, 11, "plus takes 1 argument, but is given 2"},
+		{Info, DeprecatedFilter, 3, 24, "upcase is deprecated"},
+		{Error, FilterArguments, 3, 24, "upcase doesn't take named arguments"},
+		{Warning, UseBeforeAssign, 4, 4, "greeting is used before it's assigned on line 4"},
+		{Error, BranchOrder, 5, 23, "elsif after the else on line 5 is never reached"},
+		{Warning, UnreachableBranch, 6, 1, "the condition of this if is always false"},
+		{Warning, UnreachableBranch, 6, 33, "else is never reached, because the condition on line 6 is always true"},
+		{Error, OutsideLoop, 7, 1, "break outside a loop"},
+		{Error, BranchOrder, 9, 24, "when after the else on line 9 is never reached"},
+	}, Lint(root, &cfg, nil))
+	require.Equal(t, "7:1: error: break outside a loop (outside-loop)", Lint(root, &cfg, nil)[9].String())
+}
@@ -8,8 +8,10 @@ The liquid package itself is versioned in gopkg.in. Subpackages have no compatib
 package liquid
 
 import (
+	"github.com/autopilot3/liquid/lint"
 	"github.com/autopilot3/liquid/render"
 	"github.com/autopilot3/liquid/tags"
+	"github.com/autopilot3/liquid/values"
 )
 
 // Bindings is a map of variable names to values.
@@ -18,6 +20,23 @@ import (
 // of map[string]interface{} itself as argument values to functions declared with this parameter type.
 type Bindings map[string]interface{}
 
+// SafeHTML is a string of trusted markup. When auto-escaping is enabled, it is written verbatim.
+//
+// See Engine.SetAutoEscape.
+type SafeHTML = values.SafeHTML
+
+// A Clock returns the current time. See Engine.SetClock.
+type Clock = values.Clock
+
+// A VariableReport lists the usages of a variable path in a template. See Template.AnalyzeVariables.
+type VariableReport = render.VariableReport
+
+// A VariableUsage is a use of a variable in a template, with its position.
+type VariableUsage = render.VariableUsage
+
+// A LintFinding is a problem that Engine.Lint finds in a template.
+type LintFinding = lint.Finding
+
 // A Renderer returns the rendered string for a block. This is the type of a tag definition.
 //
 // See the examples at Engine.RegisterTag and Engine.RegisterBlock.
@@ -0,0 +1,428 @@
+package liquid
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bojanz/currency"
+	"golang.org/x/text/number"
+
+	"github.com/autopilot3/liquid/expressions"
+	"github.com/autopilot3/liquid/render"
+)
+
+// Money is an exact decimal amount of a currency, such as 12.34 EUR.
+//
+// The price, decimal, decimalWithDelimiter and money filters format Money values, and
+// the money_plus, money_times and money_round filters compute with them. Unlike the
+// amounts of the {"amount", "currency"} maps that the price filter also accepts,
+// Money amounts are never converted to float64.
+type Money struct {
+	amount currency.Amount
+}
+
+// NewMoney returns the Money of a decimal amount, such as "12.34", and an ISO 4217 currency code.
+func NewMoney(amount, currencyCode string) (Money, error) {
+	a, err := currency.NewAmount(amount, currencyCode)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{a}, nil
+}
+
+// Amount returns the decimal amount, such as "12.34".
+func (m Money) Amount() string {
+	return m.amount.Number()
+}
+
+// Currency returns the ISO 4217 currency code.
+func (m Money) Currency() string {
+	return m.amount.CurrencyCode()
+}
+
+// String returns the amount and currency, such as "12.34 EUR".
+func (m Money) String() string {
+	return m.amount.String()
+}
+
+func (m Money) rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(m.amount.Number())
+	return r
+}
+
+// A SymbolPosition places the currency symbol of an amount of money.
+type SymbolPosition = render.SymbolPosition
+
+// The positions of the currency symbol.
+const (
+	// SymbolLocale places the symbol where the locale does.
+	SymbolLocale = render.SymbolLocale
+	// SymbolBefore places the symbol before the number, as in €12.34.
+	SymbolBefore = render.SymbolBefore
+	// SymbolAfter places the symbol after the number, as in 12.34 €.
+	SymbolAfter = render.SymbolAfter
+)
+
+// MoneyDisplay configures how amounts of money are displayed. See Engine.SetMoneyDisplay.
+type MoneyDisplay = render.MoneyDisplay
+
+// contextMoneyDisplay returns the money display options of the render of a filter's
+// context.
+func contextMoneyDisplay(ctx context.Context) MoneyDisplay {
+	if cfg := render.ContextConfig(ctx); cfg != nil {
+		return cfg.MoneyDisplay
+	}
+	return MoneyDisplay{}
+}
+
+// priceFromLiquid returns the amount and currency of a Money value, or of a map with
+// an "amount" in thousandths and a "currency".
+func priceFromLiquid(v interface{}) (amount *big.Rat, currencyCode string, ok bool) {
+	switch v := v.(type) {
+	case Money:
+		return v.rat(), v.Currency(), true
+	case *Money:
+		if v == nil {
+			return nil, "", false
+		}
+		return v.rat(), v.Currency(), true
+	}
+	m, mok := v.(map[string]any)
+	if !mok {
+		return nil, "", false
+	}
+	currencyCode, _ = m["currency"].(string)
+	switch a := m["amount"].(type) {
+	case int64:
+		amount = big.NewRat(a, 1000)
+	case int:
+		amount = big.NewRat(int64(a), 1000)
+	case float64:
+		amount = big.NewRat(int64(a), 1000)
+	default:
+		return nil, "", false
+	}
+	return amount, currencyCode, true
+}
+
+// moneyFromLiquid returns the Money of a filter input, as for priceFromLiquid.
+// The currency must be valid.
+func moneyFromLiquid(v interface{}) (Money, error) {
+	amount, currencyCode, ok := priceFromLiquid(v)
+	if !ok {
+		return Money{}, fmt.Errorf("not an amount of money: %v", v)
+	}
+	return ratMoney(amount, strings.TrimSpace(currencyCode))
+}
+
+// ratMoney returns the Money of an exact amount.
+func ratMoney(amount *big.Rat, currencyCode string) (Money, error) {
+	return NewMoney(exactDecimal(amount), currencyCode)
+}
+
+// exactDecimal returns the decimal string of a number with a finite decimal expansion.
+func exactDecimal(r *big.Rat) string {
+	for digits := 0; ; digits++ {
+		s := r.FloatString(digits)
+		if d, _ := new(big.Rat).SetString(s); d.Cmp(r) == 0 || digits == 30 {
+			return s
+		}
+	}
+}
+
+// thousandths returns the exact value of a decimal string of thousandths, such as 12.345 for "12345".
+func thousandths(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return nil, fmt.Errorf("not a number: %q", s)
+	}
+	return r.Quo(r, big.NewRat(1000, 1)), nil
+}
+
+// formatDecimal formats an exact number, rounded half away from zero to a number of
+// fraction digits, with the digits, separators and grouping of a locale.
+func formatDecimal(r *big.Rat, digits uint8, loc string) string {
+	sym := localeDecimalSymbols(loc)
+	s := r.FloatString(int(digits))
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	integer, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		integer, fraction = s[:i], s[i+1:]
+	}
+	var b strings.Builder
+	if negative && strings.Trim(s, "0.") != "" {
+		b.WriteString(sym.minus)
+	}
+	for i, d := range integer {
+		if i > 0 && sym.grouped(len(integer)) && sym.groupBoundary(len(integer)-i) {
+			b.WriteString(sym.group)
+		}
+		b.WriteRune(sym.digits[d-'0'])
+	}
+	if fraction != "" {
+		b.WriteString(sym.decimal)
+		for _, d := range fraction {
+			b.WriteRune(sym.digits[d-'0'])
+		}
+	}
+	return b.String()
+}
+
+// decimalSymbols are the digits, separators and grouping of the decimal format of a locale.
+type decimalSymbols struct {
+	digits         [10]rune
+	minus          string
+	decimal, group string
+	// primary is the size of the group of the lowest integer digits, and secondary
+	// that of the other groups. primary is zero if the digits aren't grouped.
+	primary, secondary int
+	// minGrouping is the least number of digits before the first separator.
+	minGrouping int
+}
+
+// decimalSample has every digit, so that its format in a locale shows the locale's
+// digits, separators and grouping.
+const decimalSample = "9876543210"
+
+// englishDecimalSymbols are the decimal symbols of English, for locales whose format of
+// the sample isn't understood.
+var englishDecimalSymbols = decimalSymbols{
+	digits:      [10]rune{'0', '1', '2', '3', '4', '5', '6', '7', '8', '9'},
+	minus:       "-",
+	decimal:     ".",
+	group:       ",",
+	primary:     3,
+	secondary:   3,
+	minGrouping: 1,
+}
+
+// localeDecimalSymbols returns the decimal symbols of a locale, read from the formats
+// of sample numbers. Only the samples are formatted as float64 values.
+func localeDecimalSymbols(loc string) decimalSymbols {
+	p := numberPrinter(loc)
+	formatted := p.Sprint(number.Decimal(-9876543210.5))
+	runs := strings.FieldsFunc(formatted, func(c rune) bool { return !unicode.IsDigit(c) })
+	texts := strings.FieldsFunc(formatted, unicode.IsDigit)
+	if strings.IndexFunc(formatted, unicode.IsDigit) <= 0 || len(runs) < 2 || len(texts) < len(runs) {
+		return englishDecimalSymbols
+	}
+	integer := []rune(strings.Join(runs[:len(runs)-1], ""))
+	if len(integer) != len(decimalSample) {
+		return englishDecimalSymbols
+	}
+	// texts[0] is the minus sign, and texts[i] precedes runs[i].
+	sym := decimalSymbols{minus: texts[0], decimal: texts[len(runs)-1], minGrouping: 1}
+	for i, c := range integer {
+		sym.digits[decimalSample[i]-'0'] = c
+	}
+	if groups := runs[:len(runs)-1]; len(groups) > 1 {
+		sym.group = texts[1]
+		sym.primary = utf8.RuneCountInString(groups[len(groups)-1])
+		sym.secondary = sym.primary
+		if len(groups) > 2 {
+			sym.secondary = utf8.RuneCountInString(groups[len(groups)-2])
+		}
+		if !strings.Contains(p.Sprint(number.Decimal(1000)), sym.group) {
+			sym.minGrouping = 2
+		}
+	}
+	return sym
+}
+
+// grouped reports whether an integer of n digits is grouped.
+func (sym decimalSymbols) grouped(n int) bool {
+	return sym.primary > 0 && n >= sym.primary+sym.minGrouping
+}
+
+// groupBoundary reports whether a group separator precedes the digit that has n digits
+// after it, including itself.
+func (sym decimalSymbols) groupBoundary(n int) bool {
+	if n == sym.primary {
+		return true
+	}
+	return n > sym.primary && (n-sym.primary)%sym.secondary == 0
+}
+
+// formatMoney formats an amount of money in a locale, rounded half up to maxDigits
+// fraction digits.
+func formatMoney(m Money, maxDigits uint8, loc string, display MoneyDisplay) string {
+	locale := currency.NewLocale(loc)
+	formatter := currency.NewFormatter(locale)
+	formatter.MaxDigits = maxDigits
+	formatter.AccountingStyle = display.Accounting
+	if display.Symbol == SymbolLocale {
+		return formatter.Format(m.amount)
+	}
+	formatter.CurrencyDisplay = currency.DisplayNone
+	s := formatter.Format(m.amount)
+	symbol, _ := currency.GetSymbol(m.Currency(), locale)
+	// Place the symbol around the digits, inside any sign or parentheses.
+	i := strings.IndexFunc(s, unicode.IsDigit)
+	j := strings.LastIndexFunc(s, unicode.IsDigit)
+	if i < 0 {
+		return s
+	}
+	_, size := utf8.DecodeRuneInString(s[j:])
+	j += size
+	if display.Symbol == SymbolAfter {
+		return s[:j] + "\u00a0" + symbol + s[j:]
+	}
+	if r, _ := utf8.DecodeLastRuneInString(symbol); unicode.IsLetter(r) {
+		symbol += "\u00a0"
+	}
+	return s[:i] + symbol + s[i:]
+}
+
+// moneyMaxDigits returns the maximum fraction digits of a money format:
+// the default digits of the currency if the format is empty.
+func moneyMaxDigits(format string) uint8 {
+	if format == "" {
+		return currency.DefaultDigits
+	}
+	return priceMaxDigits(format)
+}
+
+// moneyOperand returns the decimal string of an operand of the money filters:
+// the amount of a Money value or map in the currency of m, or a number.
+// A float64, such as the literal 1.5, is taken as its shortest decimal representation.
+func moneyOperand(m Money, v interface{}) (string, error) {
+	switch n := v.(type) {
+	case string:
+		if _, ok := new(big.Rat).SetString(strings.TrimSpace(n)); !ok {
+			return "", fmt.Errorf("not a number: %q", n)
+		}
+		return strings.TrimSpace(n), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(n), nil
+	case float32:
+		return strconv.FormatFloat(float64(n), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	}
+	other, err := moneyFromLiquid(v)
+	if err != nil {
+		return "", err
+	}
+	if other.Currency() != m.Currency() {
+		return "", fmt.Errorf("can't combine %s and %s", m.Currency(), other.Currency())
+	}
+	return other.Amount(), nil
+}
+
+// roundingModes are the rounding modes of the money_round filter.
+var roundingModes = map[string]currency.RoundingMode{
+	"":          currency.RoundHalfUp,
+	"half_up":   currency.RoundHalfUp,
+	"half_down": currency.RoundHalfDown,
+	"half_even": currency.RoundHalfEven,
+	"up":        currency.RoundUp,
+	"down":      currency.RoundDown,
+}
+
+// moneyPlus is the money_plus filter. It adds an amount of the same currency, or a number.
+func moneyPlus(v, operand interface{}) (Money, error) {
+	m, err := moneyFromLiquid(v)
+	if err != nil {
+		return Money{}, err
+	}
+	n, err := moneyOperand(m, operand)
+	if err != nil {
+		return Money{}, err
+	}
+	other, err := currency.NewAmount(n, m.Currency())
+	if err != nil {
+		return Money{}, err
+	}
+	sum, err := m.amount.Add(other)
+	return Money{sum}, err
+}
+
+// moneyTimes is the money_times filter. It multiplies an amount by a number.
+func moneyTimes(v, factor interface{}) (Money, error) {
+	m, err := moneyFromLiquid(v)
+	if err != nil {
+		return Money{}, err
+	}
+	switch factor.(type) {
+	case Money, *Money, map[string]any:
+		return Money{}, fmt.Errorf("can't multiply amounts of money")
+	}
+	n, err := moneyOperand(m, factor)
+	if err != nil {
+		return Money{}, err
+	}
+	product, err := m.amount.Mul(n)
+	return Money{product}, err
+}
+
+// moneyRound is the money_round filter. It rounds an amount to a number of fraction digits,
+// by default those of the currency, with a rounding mode: half_up (the default), half_down,
+// half_even, up or down.
+func moneyRound(v, digits interface{}, mode string) (Money, error) {
+	m, err := moneyFromLiquid(v)
+	if err != nil {
+		return Money{}, err
+	}
+	rm, ok := roundingModes[mode]
+	if !ok {
+		return Money{}, fmt.Errorf("unknown rounding mode %q", mode)
+	}
+	d := currency.DefaultDigits
+	if digits != nil {
+		n, err := strconv.Atoi(fmt.Sprint(digits))
+		if err != nil || n < 0 || n > 30 {
+			return Money{}, fmt.Errorf("invalid number of digits: %v", digits)
+		}
+		d = uint8(n)
+	}
+	return Money{m.amount.RoundTo(d, rm)}, nil
+}
+
+// money is the money filter. It formats an amount of money in a locale:
+//
+//	{{ total | money }}
+//	{{ total | money: 'two', 'de', accounting: true, symbol: 'after' }}
+//
+// The format is whole, one or two fraction digits; by default, those of the currency.
+// The named arguments accounting and symbol override the engine's MoneyDisplay.
+func money(ctx context.Context, v interface{}, args ...interface{}) (string, error) {
+	var (
+		positional []string
+		display    = contextMoneyDisplay(ctx)
+	)
+	for _, arg := range args {
+		named, ok := arg.(expressions.NamedArgs)
+		if !ok {
+			positional = append(positional, fmt.Sprint(arg))
+			continue
+		}
+		for name, value := range named {
+			switch name {
+			case "accounting":
+				display.Accounting = value != nil && value != false
+			case "symbol":
+				display.Symbol = SymbolPosition(fmt.Sprint(value))
+				if display.Symbol != SymbolBefore && display.Symbol != SymbolAfter {
+					return "", fmt.Errorf("unknown symbol position %q", value)
+				}
+			default:
+				return "", fmt.Errorf("unknown option %q", name)
+			}
+		}
+	}
+	if len(positional) > 2 {
+		return "", fmt.Errorf("expected a format and a locale")
+	}
+	positional = append(positional, "", "")
+	m, err := moneyFromLiquid(v)
+	if err != nil {
+		return "", err
+	}
+	return formatMoney(m, moneyMaxDigits(positional[0]), localeOrDefault(ctx, positional[1]), display), nil
+}
@@ -0,0 +1,106 @@
+package liquid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMoney(t *testing.T) {
+	m, err := NewMoney("12.30", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, "12.30", m.Amount())
+	require.Equal(t, "EUR", m.Currency())
+	require.Equal(t, "12.30 EUR", m.String())
+
+	_, err = NewMoney("12.30", "XX")
+	require.Error(t, err)
+	_, err = NewMoney("twelve", "EUR")
+	require.Error(t, err)
+}
+
+func TestMoneyFilters(t *testing.T) {
+	engine := NewEngine()
+	mustMoney := func(amount, currencyCode string) Money {
+		m, err := NewMoney(amount, currencyCode)
+		require.NoError(t, err)
+		return m
+	}
+	bindings := map[string]any{
+		"subtotal": mustMoney("19.99", "USD"),
+		"shipping": mustMoney("5.01", "USD"),
+		"refund":   mustMoney("-1234.5", "USD"),
+		"eur":      mustMoney("1234.5", "EUR"),
+		"yen":      mustMoney("1500", "JPY"),
+		"legacy":   map[string]any{"amount": int64(1005), "currency": "USD"},
+		"unit":     mustMoney("0.1", "USD"),
+		"large":    mustMoney("90071992547409931.23", "USD"),
+		"eurPtr":   func() *Money { m := mustMoney("1234.5", "EUR"); return &m }(),
+	}
+	tests := []struct {
+		name          string
+		liquid        string
+		expectedValue string
+	}{
+		{"money", `{{ subtotal | money }}`, "$19.99"},
+		{"money locale", `{{ eur | money: '', 'de' }}`, "1.234,50\u00a0€"},
+		{"money currency digits", `{{ yen | money: '', 'en' }}`, "¥1,500"},
+		{"money whole", `{{ eur | money: 'whole', 'en' }}`, "€1,235"},
+		{"money legacy map", `{{ legacy | money }}`, "$1.01"},
+		{"money accounting", `{{ refund | money: 'two', 'en', accounting: true }}`, "($1,234.50)"},
+		{"money symbol before", `{{ eur | money: 'two', 'de', symbol: 'before' }}`, "€1.234,50"},
+		{"money symbol after", `{{ refund | money: 'two', 'en', symbol: 'after' }}`, "-1,234.50\u00a0$"},
+		{"money accounting symbol after", `{{ refund | money: 'two', 'en', accounting: true, symbol: 'after' }}`, "(1,234.50\u00a0$)"},
+		{"money_plus", `{{ subtotal | money_plus: shipping }}`, "25.00 USD"},
+		{"money_plus number", `{{ subtotal | money_plus: '0.01' | money }}`, "$20.00"},
+		{"money_plus literal", `{{ unit | money_plus: 0.2 | price: 'value', 'two', 'en' }}`, "0.30"},
+		{"money_times", `{{ unit | money_times: 3 }}`, "0.3 USD"},
+		{"money_times decimal", `{{ subtotal | money_times: '1.0825' }}`, "21.639175 USD"},
+		{"money_round", `{{ subtotal | money_times: '1.0825' | money_round }}`, "21.64 USD"},
+		{"money_round digits", `{{ subtotal | money_round: 0 }}`, "20 USD"},
+		{"money_round mode", `{{ subtotal | money_times: '1.0825' | money_round: 2, 'down' }}`, "21.63 USD"},
+		{"money_round half_even", `{{ legacy | money_round: 2, 'half_even' }}`, "1.00 USD"},
+		{"price", `{{ subtotal | money_plus: shipping | price: 'currency_value', 'two', 'en' }}`, "$25.00"},
+		{"price value", `{{ legacy | price: 'value', 'two', 'en' }}`, "1.01"},
+		{"price value exact", `{{ large | price: 'value', 'two', 'en' }}`, "90,071,992,547,409,931.23"},
+		{"price value exact locale", `{{ large | price: 'value', 'one', 'de' }}`, "90.071.992.547.409.931,2"},
+		{"price value negative", `{{ refund | price: 'value', 'whole', 'en' }}`, "-1,235"},
+		{"price value indian grouping", `{{ large | price: 'value', 'whole', 'en-IN' }}`, "90,07,19,92,54,74,09,931"},
+		{"decimal", `{{ eur | decimal: 'one', '€' }}`, "€1,234.5"},
+		{"decimal thousandths", `{{ 1005 | decimal: 'two', '$' }}`, "$1.01"},
+		{"decimalWithDelimiter", `{{ eur | decimalWithDelimiter: 'two', '', 'de' }}`, "1.234,50\u00a0€"},
+		{"decimal pointer", `{{ eurPtr | decimal: 'one', '€' }}`, "€1,234.5"},
+		{"decimal legacy map", `{{ legacy | decimal: 'two', '$' }}`, "$1.01"},
+		{"decimalWithDelimiter pointer", `{{ eurPtr | decimalWithDelimiter: 'two', '', 'de' }}`, "1.234,50\u00a0€"},
+		{"decimalWithDelimiter legacy map", `{{ legacy | decimalWithDelimiter: 'two', '', 'en' }}`, "$1.01"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			str, err := engine.ParseAndRenderString(test.liquid, bindings)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedValue, str)
+		})
+	}
+
+	for _, liquid := range []string{
+		`{{ subtotal | money_plus: eur }}`,
+		`{{ subtotal | money_plus: 'abc' }}`,
+		`{{ subtotal | money_times: shipping }}`,
+		`{{ subtotal | money_round: 2, 'sideways' }}`,
+		`{{ 12 | money_plus: 1 }}`,
+		`{{ subtotal | money: symbol: 'middle' }}`,
+	} {
+		_, err := engine.ParseAndRenderString(liquid, bindings)
+		require.Error(t, err, liquid)
+	}
+}
+
+func TestEngine_SetMoneyDisplay(t *testing.T) {
+	engine := NewEngine().SetMoneyDisplay(MoneyDisplay{Accounting: true, Symbol: SymbolAfter})
+	bindings := map[string]any{
+		"neg": map[string]any{"amount": int64(-1500), "currency": "USD"},
+	}
+	str, err := engine.ParseAndRenderString(`{{ neg | price: 'currency_value', 'two', 'en' }} {{ neg | money: symbol: 'before' }}`, bindings)
+	require.NoError(t, err)
+	require.Equal(t, "(1.50\u00a0$) ($1.50)", str)
+}
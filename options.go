@@ -0,0 +1,96 @@
+package liquid
+
+import (
+	"context"
+	"time"
+
+	"github.com/autopilot3/liquid/render"
+	"github.com/autopilot3/liquid/values"
+)
+
+// A RenderOption configures a single render of a template.
+//
+// Render options override the engine configuration for that render.
+type RenderOption func(*renderOptions)
+
+type renderOptions struct {
+	linkData map[string]interface{}
+	locale   string
+	location *time.Location
+	clock    Clock
+	formats  *DateFormats
+}
+
+// WithLinkData sets the data, such as the recipient ID, that the engine's LinkRewriter
+// receives for each link in the render.
+func WithLinkData(data map[string]interface{}) RenderOption {
+	return func(o *renderOptions) {
+		o.linkData = data
+	}
+}
+
+// WithLocale overrides the engine's default locale for a render. See Engine.SetLocale.
+func WithLocale(locale string) RenderOption {
+	return func(o *renderOptions) {
+		o.locale = locale
+	}
+}
+
+// WithLocation overrides the engine's default time zone for a render. See Engine.SetLocation.
+func WithLocation(loc *time.Location) RenderOption {
+	return func(o *renderOptions) {
+		o.location = loc
+	}
+}
+
+// WithClock overrides the engine's clock for a render, for example to preview a scheduled
+// message as of its send time. See Engine.SetClock.
+func WithClock(c Clock) RenderOption {
+	return func(o *renderOptions) {
+		o.clock = c
+	}
+}
+
+// WithDateFormats overrides the engine's date formats for a render, for example with
+// the formats of a tenant. See Engine.DateFormats and DateFormats.Clone.
+func WithDateFormats(f *DateFormats) RenderOption {
+	return func(o *renderOptions) {
+		o.formats = f
+	}
+}
+
+// localeOrDefault returns locale, or the default locale of ctx if locale is empty.
+func localeOrDefault(ctx context.Context, locale string) string {
+	if locale == "" {
+		return values.Locale(ctx)
+	}
+	return locale
+}
+
+// renderConfig returns a copy of the template configuration with the options and
+// the link rewriting state of a single render.
+func (t *Template) renderConfig(opts []RenderOption) render.Config {
+	var o renderOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	cfg := *t.cfg
+	ctx := cfg.Context()
+	if o.locale != "" {
+		ctx = values.WithLocale(ctx, o.locale)
+	}
+	if o.location != nil {
+		ctx = values.WithLocation(ctx, o.location)
+	}
+	if o.clock != nil {
+		ctx = values.WithClock(ctx, o.clock)
+	}
+	cfg.SetContext(ctx)
+	if o.formats != nil {
+		cfg.DateFormats = o.formats
+	}
+	if cfg.LinkRewriter != nil {
+		cfg.Links = render.NewLinks(cfg.LinkRewriter, o.linkData)
+	}
+	return cfg
+}
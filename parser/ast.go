@@ -16,12 +16,13 @@ type ASTBlock struct {
 	syntax  BlockSyntax
 	Body    []ASTNode   // Body is the nodes before the first branch
 	Clauses []*ASTBlock // E.g. else and elseif w/in an if
+	End     Token       // End is the end tag, such as {% endif %}; clauses don't have one
 }
 
 // ASTRaw holds the text between the start and end of a raw tag.
 type ASTRaw struct {
+	Token  // the raw tag
 	Slices []string
-	sourcelessNode
 }
 
 // ASTTag is a tag {% tag %} that is not a block start or end.
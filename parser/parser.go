@@ -63,7 +63,7 @@ func (c Config) parseTokens(tokens []Token) (ASTNode, Error) { // nolint: gocycl
 					inComment = true
 				case tok.Name == "raw":
 					inRaw = true
-					rawTag = &ASTRaw{}
+					rawTag = &ASTRaw{Token: tok}
 					*ap = append(*ap, rawTag)
 				case cs.RequiresParent() && (sd == nil || !cs.CanHaveParent(sd)):
 					suffix := ""
@@ -84,6 +84,7 @@ func (c Config) parseTokens(tokens []Token) (ASTNode, Error) { // nolint: gocycl
 					bn.Clauses = append(bn.Clauses, n)
 					ap = &n.Body
 				case cs.IsBlockEnd():
+					bn.End = tok
 					pop := func() {
 						f := stack[len(stack)-1]
 						stack = stack[:len(stack)-1]
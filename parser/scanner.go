@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 // Scan breaks a string into a sequence of Tokens.
@@ -17,12 +18,15 @@ func Scan(data string, loc SourceLoc, delims []string) (tokens []Token) {
 
 	// TODO error on unterminated {{ and {%
 	// TODO probably an error when a tag contains a {{ or {%, at least outside of a string
+	if loc.ColNo == 0 {
+		loc.ColNo = 1
+	}
 	p, pe := 0, len(data)
 	for _, m := range tokenMatcher.FindAllStringSubmatchIndex(data, -1) {
 		ts, te := m[0], m[1]
 		if p < ts {
 			tokens = append(tokens, Token{Type: TextTokenType, SourceLoc: loc, Source: data[p:ts]})
-			loc.LineNo += strings.Count(data[p:ts], "\n")
+			loc = loc.advance(data[p:ts])
 		}
 		source := data[ts:te]
 		switch {
@@ -50,7 +54,7 @@ func Scan(data string, loc SourceLoc, delims []string) (tokens []Token) {
 			}
 			tokens = append(tokens, tok)
 		}
-		loc.LineNo += strings.Count(source, "\n")
+		loc = loc.advance(source)
 		p = te
 	}
 	if p < pe {
@@ -83,3 +87,14 @@ func formTokenMatcher(delims []string) *regexp.Regexp {
 
 	return tokenMatcher
 }
+
+// advance returns the location of the end of text that starts at s.
+func (s SourceLoc) advance(text string) SourceLoc {
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		s.LineNo += strings.Count(text, "\n")
+		s.ColNo = 1
+		text = text[i+1:]
+	}
+	s.ColNo += utf8.RuneCountInString(text)
+	return s
+}
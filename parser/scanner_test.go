@@ -160,3 +160,11 @@ func TestScan_delims(t *testing.T) {
 		})
 	}
 }
+
+func TestScan_loc(t *testing.T) {
+	tokens := Scan("ab{{ x }}\nçd {% tag %}", SourceLoc{LineNo: 1}, nil)
+	require.Len(t, tokens, 4)
+	require.Equal(t, SourceLoc{LineNo: 1, ColNo: 3}, tokens[1].SourceLoc)
+	require.Equal(t, SourceLoc{LineNo: 1, ColNo: 10}, tokens[2].SourceLoc)
+	require.Equal(t, SourceLoc{LineNo: 2, ColNo: 4}, tokens[3].SourceLoc)
+}
@@ -30,6 +30,7 @@ const (
 type SourceLoc struct {
 	Pathname string
 	LineNo   int
+	ColNo    int // ColNo is the column of the start of the token, counted in characters from 1.
 }
 
 // SourceLocation returns the token's source location, for use in error reporting.
@@ -38,6 +39,9 @@ func (c Token) SourceLocation() SourceLoc { return c.SourceLoc }
 // SourceText returns the token's source text, for use in error reporting.
 func (c Token) SourceText() string { return c.Source }
 
+// LocAt returns the source location of a byte offset in the token's source.
+func (c Token) LocAt(offset int) SourceLoc { return c.SourceLoc.advance(c.Source[:offset]) }
+
 // IsZero returns a boolean indicating whether the location doesn't have a set path.
 func (s SourceLoc) IsZero() bool {
 	return s.Pathname == "" && s.LineNo == 0
@@ -0,0 +1,88 @@
+package liquid
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/autopilot3/ap3-types-go/types/phone"
+	"github.com/nyaruka/phonenumbers"
+	"golang.org/x/text/language"
+
+	"github.com/autopilot3/liquid/values"
+)
+
+// phoneFormats are the styles of the phone_format filter.
+var phoneFormats = map[string]phonenumbers.PhoneNumberFormat{
+	"":              phonenumbers.INTERNATIONAL,
+	"international": phonenumbers.INTERNATIONAL,
+	"national":      phonenumbers.NATIONAL,
+	"e164":          phonenumbers.E164,
+	"rfc3966":       phonenumbers.RFC3966,
+}
+
+// phoneText returns the text of a phone number: a phone.International with a country code
+// is in international form.
+func phoneText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case phone.International:
+		if v.CountryCode.IsZero() {
+			return v.Number.String()
+		}
+		return "+" + strings.TrimPrefix(v.CountryCode.String(), "+") + v.Number.String()
+	case *phone.International:
+		if v == nil {
+			return ""
+		}
+		return phoneText(*v)
+	case string:
+		return strings.TrimSpace(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// phoneRegion returns the region of numbers without a country code: region if it isn't
+// empty, else the region of the default locale, if it has one, such as AU for en-AU.
+func phoneRegion(ctx context.Context, region string) string {
+	if region != "" {
+		return strings.ToUpper(region)
+	}
+	tag, err := language.Parse(values.Locale(ctx))
+	if err != nil {
+		return ""
+	}
+	if r, conf := tag.Region(); conf == language.Exact {
+		return r.String()
+	}
+	return ""
+}
+
+// phoneFormat is the phone_format filter. It formats a phone.International or a string
+// in a style: international (the default), national, e164 or rfc3966, for tel: links.
+//
+//	{{ contact.phone | phone_format: 'national', 'AU', 'No phone' }}
+//
+// The region is that of numbers without a country code. A number that can't be parsed
+// or isn't valid for its region is replaced by the fallback, or if the fallback is empty,
+// written as it is.
+func phoneFormat(ctx context.Context, v interface{}, style string, region string, fallback string) (string, error) {
+	format, ok := phoneFormats[strings.ToLower(style)]
+	if !ok {
+		return "", fmt.Errorf("unknown phone format %q", style)
+	}
+	s := phoneText(v)
+	if s == "" {
+		return fallback, nil
+	}
+	num, err := phonenumbers.Parse(s, phoneRegion(ctx, region))
+	if err != nil || !phonenumbers.IsValidNumber(num) {
+		if fallback != "" {
+			return fallback, nil
+		}
+		return s, nil
+	}
+	return phonenumbers.Format(num, format), nil
+}
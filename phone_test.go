@@ -0,0 +1,48 @@
+package liquid
+
+import (
+	"testing"
+
+	"github.com/autopilot3/ap3-types-go/types/phone"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhoneFormatFilter(t *testing.T) {
+	engine := NewEngine()
+	bindings := map[string]any{
+		"au":      phone.International{CountryCode: 61, Number: "412345678"},
+		"local":   phone.International{Number: "0412 345 678"},
+		"us":      "+1 650-253-0000",
+		"invalid": "12345",
+	}
+	tests := []struct {
+		name          string
+		liquid        string
+		expectedValue string
+	}{
+		{"international", `{{ au | phone_format }}`, "+61 412 345 678"},
+		{"national", `{{ au | phone_format: 'national' }}`, "0412 345 678"},
+		{"e164", `{{ au | phone_format: 'e164' }}`, "+61412345678"},
+		{"rfc3966", `{{ au | phone_format: 'rfc3966' }}`, "tel:+61-412-345-678"},
+		{"string", `{{ us | phone_format: 'national' }}`, "(650) 253-0000"},
+		{"region", `{{ local | phone_format: 'e164', 'au' }}`, "+61412345678"},
+		{"no region", `{{ local | phone_format: 'e164' }}`, "0412 345 678"},
+		{"fallback", `{{ invalid | phone_format: 'national', 'AU', 'No phone' }}`, "No phone"},
+		{"invalid", `{{ invalid | phone_format: 'national', 'AU' }}`, "12345"},
+		{"missing", `{{ missing | phone_format: 'national', '', 'No phone' }}`, "No phone"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			str, err := engine.ParseAndRenderString(test.liquid, bindings)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedValue, str)
+		})
+	}
+
+	str, err := NewEngine().SetLocale("en-AU").ParseAndRenderString(`{{ local | phone_format: 'international' }}`, bindings)
+	require.NoError(t, err)
+	require.Equal(t, "+61 412 345 678", str)
+
+	_, err = engine.ParseAndRenderString(`{{ au | phone_format: 'short' }}`, bindings)
+	require.Error(t, err)
+}
@@ -0,0 +1,170 @@
+package liquid
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+
+	"github.com/autopilot3/liquid/expressions"
+)
+
+// pluralForms maps the CLDR plural category names to plural forms.
+var pluralForms = map[string]plural.Form{
+	"zero":  plural.Zero,
+	"one":   plural.One,
+	"two":   plural.Two,
+	"few":   plural.Few,
+	"many":  plural.Many,
+	"other": plural.Other,
+}
+
+// ordinalSuffixes are the ordinal number suffixes of some languages, by plural form.
+// Other languages use the English suffixes.
+var ordinalSuffixes = map[string]map[plural.Form]string{
+	"en": {plural.One: "st", plural.Two: "nd", plural.Few: "rd", plural.Other: "th"},
+	"fr": {plural.One: "er", plural.Other: "e"},
+	"nl": {plural.Other: "e"},
+	"sv": {plural.One: ":a", plural.Two: ":a", plural.Other: ":e"},
+	"es": {plural.Other: "º"},
+	"it": {plural.Other: "º"},
+	"pt": {plural.Other: "º"},
+	"de": {plural.Other: "."},
+	"da": {plural.Other: "."},
+	"nb": {plural.Other: "."},
+	"no": {plural.Other: "."},
+	"fi": {plural.Other: "."},
+	"pl": {plural.Other: "."},
+	"cs": {plural.Other: "."},
+	"tr": {plural.Other: "."},
+	"hu": {plural.Other: "."},
+}
+
+// pluralDigits returns the decimal digits of a number, in the form that
+// plural.Rules.MatchDigits takes. A string keeps its visible fraction digits,
+// so that "1.0" and 1 can have different plural forms.
+func pluralDigits(n interface{}) (digits []byte, exp, scale int, err error) {
+	var s string
+	switch n := n.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s = fmt.Sprint(n)
+	case float32:
+		s = strconv.FormatFloat(float64(n), 'f', -1, 32)
+	case float64:
+		s = strconv.FormatFloat(n, 'f', -1, 64)
+	case string:
+		s = strings.TrimSpace(n)
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return nil, 0, 0, fmt.Errorf("not a number: %q", n)
+		}
+	default:
+		return nil, 0, 0, fmt.Errorf("not a number: %v", n)
+	}
+	s = strings.TrimLeft(s, "+-")
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	intPart = strings.TrimLeft(intPart, "0")
+	for _, ch := range intPart + fracPart {
+		if ch < '0' || '9' < ch {
+			return nil, 0, 0, fmt.Errorf("not a number: %v", n)
+		}
+		digits = append(digits, byte(ch-'0'))
+	}
+	return digits, len(intPart), len(fracPart), nil
+}
+
+func localeTag(ctx context.Context, locale string) language.Tag {
+	tag, err := language.Parse(localeOrDefault(ctx, locale))
+	if err != nil {
+		return language.English
+	}
+	return tag
+}
+
+// pluralize is the pluralize filter. It returns the form of a word for a count:
+//
+//	{{ count | pluralize: 'item', 'items' }}
+//	{{ count | pluralize: one: '# plik', few: '# pliki', many: '# plików', other: '# pliku' }}
+//
+// The first form has a singular and a plural word. The second form has a message for each
+// CLDR plural category of the locale; "other" is required. As in ICU messages, a # in the
+// result is replaced by the count. The named argument locale overrides the default locale.
+func pluralize(ctx context.Context, n interface{}, args ...interface{}) (string, error) {
+	var (
+		words []string
+		named expressions.NamedArgs
+	)
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case expressions.NamedArgs:
+			named = arg
+		default:
+			words = append(words, fmt.Sprint(arg))
+		}
+	}
+	locale, _ := named["locale"].(string)
+	digits, exp, scale, err := pluralDigits(n)
+	if err != nil {
+		return "", err
+	}
+	form := plural.Cardinal.MatchDigits(localeTag(ctx, locale), digits, exp, scale)
+	var msg string
+	switch {
+	case len(words) == 2 && len(named) <= 1:
+		msg = words[1]
+		if form == plural.One {
+			msg = words[0]
+		}
+	case len(words) == 0:
+		var ok bool
+		msg, ok = pluralMessage(named, form)
+		if !ok {
+			return "", fmt.Errorf("missing the plural form \"other\"")
+		}
+	default:
+		return "", fmt.Errorf("expected a singular and a plural word, or named plural forms")
+	}
+	return strings.ReplaceAll(msg, "#", fmt.Sprint(n)), nil
+}
+
+// pluralMessage returns the named message for form, or for "other" if there isn't one.
+// It reports false if there isn't an "other" message.
+func pluralMessage(named expressions.NamedArgs, form plural.Form) (string, bool) {
+	other, ok := named["other"]
+	if !ok {
+		return "", false
+	}
+	for name, msg := range named {
+		if f, ok := pluralForms[name]; ok && f == form {
+			return fmt.Sprint(msg), true
+		}
+	}
+	return fmt.Sprint(other), true
+}
+
+// ordinal is the ordinal filter. It returns an integer with the ordinal suffix
+// of the locale, such as 1st, 2nd, 3rd in English and 1er, 2e in French. In a locale
+// without ordinal suffixes, it returns the integer.
+func ordinal(ctx context.Context, n interface{}, locale func(string) string) (string, error) {
+	digits, exp, scale, err := pluralDigits(n)
+	if err != nil {
+		return "", err
+	}
+	if scale > 0 {
+		return "", fmt.Errorf("not an integer: %v", n)
+	}
+	tag := localeTag(ctx, locale(""))
+	base, _ := tag.Base()
+	suffixes, ok := ordinalSuffixes[base.String()]
+	if !ok {
+		return fmt.Sprint(n), nil
+	}
+	form := plural.Ordinal.MatchDigits(tag, digits, exp, 0)
+	suffix, ok := suffixes[form]
+	if !ok {
+		suffix = suffixes[plural.Other]
+	}
+	return fmt.Sprint(n) + suffix, nil
+}
@@ -0,0 +1,58 @@
+package liquid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluralFilters(t *testing.T) {
+	engine := NewEngine()
+	polish := `pluralize: one: '# plik', few: '# pliki', many: '# plików', other: '# pliku'`
+	tests := []struct {
+		liquid, locale, expected string
+	}{
+		{`{{ 1 | pluralize: 'item', 'items' }}`, "", "item"},
+		{`{{ 0 | pluralize: 'item', 'items' }}`, "", "items"},
+		{`{{ 2 | pluralize: 'item', 'items' }}`, "", "items"},
+		{`{{ '1.0' | pluralize: 'item', 'items' }}`, "en", "items"},
+		{`{{ 0 | pluralize: 'article', 'articles' }}`, "fr", "article"},
+		{`{{ 1 | pluralize: 'article', 'articles', locale: 'fr' }}`, "en", "article"},
+		{`{{ 1 | ` + polish + ` }}`, "pl", "1 plik"},
+		{`{{ 3 | ` + polish + ` }}`, "pl", "3 pliki"},
+		{`{{ 5 | ` + polish + ` }}`, "pl", "5 plików"},
+		{`{{ 22 | ` + polish + ` }}`, "pl", "22 pliki"},
+		{`{{ 1.5 | ` + polish + ` }}`, "pl", "1.5 pliku"},
+		{`{{ 5 | pluralize: one: '# item', other: '# items', locale: 'pl' }}`, "", "5 items"},
+		{`{{ 21 | pluralize: one: '# файл', few: '# файла', many: '# файлов', other: '# файла' }}`, "ru", "21 файл"},
+		{`{{ 0 | pluralize: zero: 'لا ملفات', one: 'ملف', two: 'ملفان', few: '# ملفات', many: '# ملفًا', other: '# ملف' }}`, "ar", "لا ملفات"},
+
+		{`{{ 1 | ordinal }}`, "", "1st"},
+		{`{{ 2 | ordinal }}`, "", "2nd"},
+		{`{{ 3 | ordinal }}`, "", "3rd"},
+		{`{{ 11 | ordinal }}`, "", "11th"},
+		{`{{ 22 | ordinal }}`, "", "22nd"},
+		{`{{ 113 | ordinal }}`, "", "113th"},
+		{`{{ 1 | ordinal }}`, "fr", "1er"},
+		{`{{ 2 | ordinal }}`, "fr-CA", "2e"},
+		{`{{ 2 | ordinal: 'sv' }}`, "fr", "2:a"},
+		{`{{ 3 | ordinal }}`, "de", "3."},
+		{`{{ 3 | ordinal }}`, "ja", "3"},
+		{`{{ 1 | ordinal: 'ru' }}`, "", "1"},
+	}
+	for _, test := range tests {
+		out, err := engine.ParseAndRenderString(test.liquid, nil, WithLocale(test.locale))
+		require.NoError(t, err, test.liquid)
+		require.Equal(t, test.expected, out, test.liquid)
+	}
+
+	for _, liquid := range []string{
+		`{{ 1 | pluralize: one: 'one' }}`,
+		`{{ 1 | pluralize: 'one' }}`,
+		`{{ 'x' | pluralize: 'one', 'other' }}`,
+		`{{ 1.5 | ordinal }}`,
+	} {
+		_, err := engine.ParseAndRenderString(liquid, nil)
+		require.Error(t, err, liquid)
+	}
+}
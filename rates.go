@@ -0,0 +1,100 @@
+package liquid
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bojanz/currency"
+
+	"github.com/autopilot3/liquid/render"
+)
+
+// A RateProvider supplies exchange rates for the convert_currency filter.
+//
+// See Engine.SetRateProvider.
+type RateProvider = render.RateProvider
+
+// StaticRates is an in-memory RateProvider, for tests and for rates that are fetched
+// ahead of a batch of renders. Rates holds the number of units of each currency that one
+// unit of Base is worth. Rates between two other currencies are derived from their rates
+// to Base.
+type StaticRates struct {
+	Base  string
+	Rates map[string]string
+}
+
+// Rate implements RateProvider.
+func (r StaticRates) Rate(ctx context.Context, from, to string) (string, error) {
+	if from == to {
+		return "1", nil
+	}
+	fromRate, err := r.baseRate(from)
+	if err != nil {
+		return "", err
+	}
+	toRate, err := r.baseRate(to)
+	if err != nil {
+		return "", err
+	}
+	if from == r.Base {
+		return toRate, nil
+	}
+	a, err := currency.NewAmount(toRate, to)
+	if err != nil {
+		return "", err
+	}
+	a, err = a.Div(fromRate)
+	if err != nil {
+		return "", err
+	}
+	return a.Number(), nil
+}
+
+// baseRate returns the rate from Base to a currency.
+func (r StaticRates) baseRate(currencyCode string) (string, error) {
+	if currencyCode == r.Base {
+		return "1", nil
+	}
+	rate, ok := r.Rates[currencyCode]
+	if !ok {
+		return "", fmt.Errorf("no exchange rate from %s to %s", r.Base, currencyCode)
+	}
+	return rate, nil
+}
+
+// SetRateProvider sets the exchange rates of the convert_currency filter, which converts
+// an amount of money to another currency:
+//
+//	{{ product.price | convert_currency: 'EUR' | price: 'currency_value', 'two' }}
+func (e *Engine) SetRateProvider(p RateProvider) *Engine {
+	e.cfg.RateProvider = p
+	return e
+}
+
+// convertCurrency is the convert_currency filter. It converts a Money value, or a price map
+// with an amount in thousandths and a currency, to a Money value in another currency.
+// The result isn't rounded; the formatting filters and money_round round it.
+func convertCurrency(ctx context.Context, v interface{}, to string) (Money, error) {
+	m, err := moneyFromLiquid(v)
+	if err != nil {
+		return Money{}, err
+	}
+	to = strings.ToUpper(strings.TrimSpace(to))
+	if to == m.Currency() {
+		return m, nil
+	}
+	cfg := render.ContextConfig(ctx)
+	if cfg == nil || cfg.RateProvider == nil {
+		return Money{}, fmt.Errorf("no rate provider")
+	}
+	rate, err := cfg.RateProvider.Rate(ctx, m.Currency(), to)
+	if err != nil {
+		return Money{}, err
+	}
+	a, err := m.amount.Convert(to, rate)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{a}, nil
+}
@@ -0,0 +1,58 @@
+package liquid
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticRates(t *testing.T) {
+	rates := StaticRates{Base: "USD", Rates: map[string]string{"EUR": "0.9", "GBP": "0.75"}}
+	tests := []struct {
+		from, to, expected string
+	}{
+		{"USD", "EUR", "0.9"},
+		{"EUR", "EUR", "1"},
+		{"EUR", "USD", "1.111111111111111111"},
+		{"EUR", "GBP", "0.8333333333333333333"},
+	}
+	for _, test := range tests {
+		rate, err := rates.Rate(context.Background(), test.from, test.to)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, rate, test.from+test.to)
+	}
+	_, err := rates.Rate(context.Background(), "USD", "JPY")
+	require.EqualError(t, err, "no exchange rate from USD to JPY")
+}
+
+func TestConvertCurrencyFilter(t *testing.T) {
+	engine := NewEngine().SetRateProvider(StaticRates{Base: "USD", Rates: map[string]string{"EUR": "0.9215", "JPY": "151.2"}})
+	bindings := map[string]any{
+		"usd": map[string]any{"amount": int64(19990), "currency": "USD"},
+		"eur": map[string]any{"amount": int64(10000), "currency": "EUR"},
+	}
+	tests := []struct {
+		name          string
+		liquid        string
+		expectedValue string
+	}{
+		{"convert", `{{ usd | convert_currency: 'EUR' }}`, "18.420785 EUR"},
+		{"price", `{{ usd | convert_currency: 'EUR' | price: 'currency_value', 'two', 'en' }}`, "€18.42"},
+		{"currency digits", `{{ usd | convert_currency: 'jpy' | money: '', 'en' }}`, "¥3,022"},
+		{"same currency", `{{ usd | convert_currency: 'USD' | price: 'currency_value', 'two', 'en' }}`, "$19.99"},
+		{"cross rate", `{{ eur | convert_currency: 'JPY' | money_round }}`, "1641 JPY"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			str, err := engine.ParseAndRenderString(test.liquid, bindings)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedValue, str)
+		})
+	}
+
+	_, err := engine.ParseAndRenderString(`{{ usd | convert_currency: 'GBP' }}`, bindings)
+	require.Error(t, err)
+	_, err = NewEngine().ParseAndRenderString(`{{ usd | convert_currency: 'EUR' }}`, bindings)
+	require.Error(t, err)
+}
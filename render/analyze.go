@@ -0,0 +1,396 @@
+package render
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/autopilot3/liquid/expressions"
+	"github.com/autopilot3/liquid/parser"
+)
+
+// A VariableUsage is a use of a variable in a template.
+type VariableUsage struct {
+	// Path is the variable path. The elements of a loop are written with [], as in
+	// order.lines[].sku for {{ line.sku }} in {% for line in order.lines %}.
+	Path string
+	// Line and Column are the position of the start of the path. Columns are counted in
+	// characters from 1.
+	Line, Column int
+	// Tag is the name of the tag that the variable is used in, such as if or for,
+	// or "object" for an {{ object }}.
+	Tag string
+	// Filters are the names of the filters applied to the variable, in order.
+	Filters []string
+	// Condition is true if the variable is used in the condition of an if, unless,
+	// elsif, case or when tag.
+	Condition bool
+	// Loop is true if the variable is the source of a for or tablerow loop.
+	Loop bool
+	// Operator and Operand are the comparison that the variable is the left operand of,
+	// if any, as in contact.age > 18. The path of an Operand is resolved like Path.
+	Operator string
+	Operand  *Operand
+}
+
+// An Operand is an operand of a comparison: a variable path, or if Path is empty,
+// a literal value.
+type Operand struct {
+	Path    string
+	Literal interface{}
+}
+
+// A VariableReport lists the usages of a variable path.
+type VariableReport struct {
+	Path   string
+	Usages []VariableUsage
+	// ConditionOnly is true if the path is only used in conditions, so that rendering
+	// the template doesn't write its value.
+	ConditionOnly bool
+}
+
+// conditionTags are the tags whose arguments are conditions.
+var conditionTags = map[string]bool{
+	"if":     true,
+	"unless": true,
+	"elsif":  true,
+	"case":   true,
+	"when":   true,
+}
+
+// loopScope is the variable of an enclosing loop, and the paths of the elements it
+// iterates over; sources is empty for a range.
+type loopScope struct {
+	name    string
+	sources []string
+}
+
+// branchTags are the tags whose bodies may not be rendered, or may be rendered more than
+// once.
+var branchTags = map[string]bool{
+	"if":       true,
+	"unless":   true,
+	"case":     true,
+	"for":      true,
+	"tablerow": true,
+}
+
+// elementFilters are the filters that return an element of their input, and
+// passThroughFilters those that return their input, if it's set.
+var (
+	elementFilters     = map[string]bool{"first": true, "last": true}
+	passThroughFilters = map[string]bool{"default": true}
+)
+
+type variableAnalyzer struct {
+	loops  []loopScope
+	locals map[string]bool
+	// aliases are the paths of the variables that aliases may be assigned from
+	aliases map[string][]string
+	usages  []VariableUsage
+	// aliased are the indexes of the usages that are the variables that aliases are
+	// assigned from
+	aliased map[int]bool
+	// branches is the number of enclosing blocks whose bodies are branches
+	branches int
+}
+
+func newVariableAnalyzer(node Node) *variableAnalyzer {
+	a := &variableAnalyzer{locals: map[string]bool{}, aliases: map[string][]string{}, aliased: map[int]bool{}}
+	a.node(node)
+	return a
+}
+
+// AnalyzeVariables returns the variables used in a render tree, sorted by path.
+// A variable that is assigned from another, as in {% assign offers = contact.offers %},
+// is reported as that variable, and as each of them if it's assigned from several in
+// different branches. So is one assigned through default, or through first or last as an
+// element of it. Other variables that are assigned or captured before their use, and the
+// forloop object, aren't reported.
+func AnalyzeVariables(node Node) []VariableReport {
+	a := newVariableAnalyzer(node)
+	var (
+		reports []VariableReport
+		byPath  = map[string]int{}
+	)
+	for _, u := range a.usages {
+		i, ok := byPath[u.Path]
+		if !ok {
+			i = len(reports)
+			byPath[u.Path] = i
+			reports = append(reports, VariableReport{Path: u.Path, ConditionOnly: true})
+		}
+		reports[i].Usages = append(reports[i].Usages, u)
+		reports[i].ConditionOnly = reports[i].ConditionOnly && u.Condition
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Path < reports[j].Path })
+	return reports
+}
+
+func (a *variableAnalyzer) node(node Node) {
+	switch n := node.(type) {
+	case *SeqNode:
+		a.nodes(n.Children)
+	case *ObjectNode:
+		trees, _ := argTrees("object", n.Args)
+		a.expression(n.Token, "object", trees...)
+	case *TagNode:
+		a.tag(n.Token)
+	case *BlockNode:
+		a.block(n)
+	}
+}
+
+func (a *variableAnalyzer) nodes(nodes []Node) {
+	for _, n := range nodes {
+		a.node(n)
+	}
+}
+
+func (a *variableAnalyzer) tag(tok parser.Token) {
+	switch tok.Name {
+	case "assign":
+		stmt, err := expressions.ParseStatement(expressions.AssignStatementSelector, tok.Args)
+		if err != nil {
+			return
+		}
+		value := stmt.Assignment.Value
+		used := a.expression(tok, tok.Name, value)
+		// The variable is an alias of the variables it's assigned from, if any.
+		var sources []string
+		for _, src := range aliasSources(value, "") {
+			for _, i := range used[src.node.Position().Start] {
+				sources = append(sources, a.usages[i].Path+src.suffix)
+				a.aliased[i] = true
+			}
+		}
+		a.assign(stmt.Assignment.Variable, sources)
+	case "include":
+		trees, _ := argTrees(tok.Name, tok.Args)
+		a.expression(tok, tok.Name, trees...)
+	}
+}
+
+// assign records the assignment of a variable, as an alias of sources if there are any.
+// An assignment in a branch adds to the sources that the variable may already be an
+// alias of.
+func (a *variableAnalyzer) assign(name string, sources []string) {
+	if a.branches > 0 && len(a.aliases[name]) > 0 {
+		sources = append(a.aliases[name], sources...)
+	}
+	delete(a.aliases, name)
+	delete(a.locals, name)
+	if len(sources) == 0 {
+		a.locals[name] = true
+		return
+	}
+	seen := map[string]bool{}
+	for _, s := range sources {
+		if !seen[s] {
+			seen[s] = true
+			a.aliases[name] = append(a.aliases[name], s)
+		}
+	}
+}
+
+// An aliasSource is a variable that an assigned value may be. The value is an element of
+// the variable if suffix is [].
+type aliasSource struct {
+	node   expressions.Node
+	suffix string
+}
+
+// aliasSources returns the variables that an assigned value is: the value itself if it's
+// a variable, or the sources of the input of a filter that returns its input or an
+// element of it. Other filters' values aren't aliases.
+func aliasSources(n expressions.Node, suffix string) []aliasSource {
+	if _, ok := expressions.Path(n); ok {
+		return []aliasSource{{n, suffix}}
+	}
+	f, ok := n.(*expressions.Filter)
+	switch {
+	case !ok:
+		return nil
+	case elementFilters[f.Name] && len(f.Args) == 0:
+		return aliasSources(f.Input, "[]"+suffix)
+	case passThroughFilters[f.Name]:
+		return aliasSources(f.Input, suffix)
+	}
+	return nil
+}
+
+func (a *variableAnalyzer) block(n *BlockNode) {
+	if branchTags[n.Name] {
+		a.branches++
+		defer func() { a.branches-- }()
+	}
+	switch {
+	case n.Name == "for" || n.Name == "tablerow":
+		stmt, err := expressions.ParseStatement(expressions.LoopStatementSelector, n.Args)
+		if err != nil {
+			break
+		}
+		collection := stmt.Loop.Collection
+		used := a.expression(n.Token, n.Name, collection)
+		scope := loopScope{name: stmt.Loop.Variable}
+		for _, i := range used[collection.Position().Start] {
+			a.usages[i].Loop = true
+			scope.sources = append(scope.sources, a.usages[i].Path)
+		}
+		a.loops = append(a.loops, scope)
+		a.nodes(n.Body)
+		a.loops = a.loops[:len(a.loops)-1]
+		for _, c := range n.Clauses {
+			a.block(c)
+		}
+		return
+	case n.Name == "capture":
+		a.nodes(n.Body)
+		a.assign(strings.TrimSpace(n.Args), nil)
+		return
+	case conditionTags[n.Name]:
+		trees, _ := argTrees(n.Name, n.Args)
+		a.expression(n.Token, n.Name, trees...)
+	}
+	a.nodes(n.Body)
+	for _, c := range n.Clauses {
+		a.block(c)
+	}
+}
+
+// expression records the variable usages of the expression trees of the arguments of
+// a token. It returns the indexes of the usages by the offset of their references; a
+// reference to an alias of several variables is a usage of each.
+func (a *variableAnalyzer) expression(tok parser.Token, tag string, trees ...expressions.Node) map[int][]int {
+	start := strings.LastIndex(tok.Source, tok.Args)
+	used := map[int][]int{}
+	for _, ref := range references(trees...) {
+		line, col := position(tok, start+ref.offset)
+		for _, path := range a.resolve(ref.path) {
+			u := VariableUsage{
+				Path:      path,
+				Line:      line,
+				Column:    col,
+				Tag:       tag,
+				Filters:   ref.filters,
+				Condition: conditionTags[tag],
+			}
+			operands := []Operand{{}}
+			if ref.operand != nil {
+				operands = []Operand{*ref.operand}
+				if p := ref.operand.Path; p != "" {
+					// A comparison with a local variable, whose type isn't known, has
+					// no operand.
+					operands = operands[:0]
+					for _, op := range a.resolve(p) {
+						operands = append(operands, Operand{Path: op})
+					}
+				}
+				if len(operands) == 0 {
+					operands = []Operand{{}}
+				} else {
+					u.Operator = ref.operator
+				}
+			}
+			for _, operand := range operands {
+				if u.Operator != "" {
+					operand := operand
+					u.Operand = &operand
+				}
+				used[ref.offset] = append(used[ref.offset], len(a.usages))
+				a.usages = append(a.usages, u)
+			}
+		}
+	}
+	return used
+}
+
+// resolve returns the paths that a reference may be, with the variables of the enclosing
+// loops replaced by their sources and aliases by the variables they're assigned from.
+// It returns none for local variables.
+func (a *variableAnalyzer) resolve(path string) []string {
+	root, rest := path, ""
+	if i := strings.IndexByte(path, '.'); i >= 0 {
+		root, rest = path[:i], path[i:]
+	}
+	var paths []string
+	for i := len(a.loops) - 1; i >= 0; i-- {
+		if a.loops[i].name == root {
+			for _, source := range a.loops[i].sources {
+				paths = append(paths, source+"[]"+rest)
+			}
+			return paths
+		}
+	}
+	if a.locals[root] || (len(a.loops) > 0 && root == "forloop") {
+		return nil
+	}
+	if sources, ok := a.aliases[root]; ok {
+		for _, source := range sources {
+			paths = append(paths, source+rest)
+		}
+		return paths
+	}
+	return []string{path}
+}
+
+// position returns the line and column of a byte offset in the source of a token.
+func position(tok parser.Token, offset int) (line, col int) {
+	loc := tok.LocAt(offset)
+	return loc.LineNo, loc.ColNo
+}
+
+// FindVariables returns the variables that a render tree uses, by path, as
+// *expressions.VariableBind values. The source of a loop is a variable whose binding is
+// a loop, with the properties of its elements as attributes, as in the binding of
+// order.lines with the attribute sku for {% for line in order.lines %}{{ line.sku }}.
+// The elements of an array of arrays are its attribute "".
+//
+// Variables are resolved as they are by AnalyzeVariables. A variable that an alias is
+// assigned from isn't a variable of the assignment; the uses of the alias are.
+func FindVariables(node Node) map[string]interface{} {
+	a := newVariableAnalyzer(node)
+	vars := map[string]interface{}{}
+	for i, u := range a.usages {
+		if !a.aliased[i] {
+			bindVariable(vars, u.Path, u.Loop)
+		}
+	}
+	return vars
+}
+
+// bindVariable adds a variable path to the bindings of FindVariables. If loop is set,
+// the path is the source of a loop.
+func bindVariable(vars map[string]interface{}, path string, loop bool) {
+	levels := strings.Split(path, "[]")
+	bind, _ := vars[levels[0]].(*expressions.VariableBind)
+	if bind == nil {
+		bind = &expressions.VariableBind{}
+		vars[levels[0]] = bind
+	}
+	for i, level := range levels[1:] {
+		markLoop(bind)
+		// The elements of an array of arrays are the attribute "", as in matrix[][].v.
+		name := strings.TrimPrefix(level, ".")
+		if name == "" && i == len(levels)-2 && !loop {
+			// the element itself
+			return
+		}
+		attr := bind.Attributes[name]
+		if attr == nil {
+			attr = &expressions.VariableBind{}
+			bind.Attributes[name] = attr
+		}
+		bind = attr
+	}
+	if loop {
+		markLoop(bind)
+	}
+}
+
+// markLoop marks a binding as the source of a loop.
+func markLoop(bind *expressions.VariableBind) {
+	bind.Loop = true
+	if bind.Attributes == nil {
+		bind.Attributes = map[string]*expressions.VariableBind{}
+	}
+}
@@ -0,0 +1,496 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/autopilot3/liquid/values"
+)
+
+// unsafeURL replaces a URL whose scheme isn't allowed in an auto-escaped URL attribute.
+const unsafeURL = "about:invalid#zLiquidz"
+
+// htmlState is the part of an HTML document that the next output lands in.
+type htmlState uint8
+
+const (
+	htmlStateText        htmlState = iota // element content
+	htmlStateTagOpen                      // after "<"
+	htmlStateTagName                      // inside a start tag name
+	htmlStateEndTag                       // inside an end tag
+	htmlStateTag                          // inside a start tag, between attributes
+	htmlStateAttrName                     // inside an attribute name
+	htmlStateAfterName                    // after an attribute name, before "="
+	htmlStateBeforeValue                  // after "=", before the attribute value
+	htmlStateAttr                         // inside an attribute value
+	htmlStateDecl                         // inside <!DOCTYPE …> and similar
+	htmlStateComment                      // inside <!-- … -->
+	htmlStateScript                       // inside <script> element content
+	htmlStateStyle                        // inside <style> element content
+	htmlStateRCDATA                       // inside <textarea> or <title> element content
+)
+
+// attrKind classifies an attribute value by the language it contains.
+type attrKind uint8
+
+const (
+	attrNormal attrKind = iota
+	attrURL
+	attrScript
+	attrStyle
+)
+
+// urlPart records how much of a URL attribute value has been written.
+type urlPart uint8
+
+const (
+	urlPartNone     urlPart = iota // nothing yet; the next output starts the URL
+	urlPartPreQuery                // inside the scheme, authority or path
+	urlPartQuery                   // inside the query or fragment
+)
+
+// urlAttrs are the attributes whose values are URLs.
+var urlAttrs = map[string]bool{
+	"action": true, "background": true, "cite": true, "formaction": true,
+	"href": true, "icon": true, "longdesc": true, "manifest": true,
+	"poster": true, "src": true, "srcset": true, "usemap": true, "xmlns": true,
+}
+
+// safeURLSchemes are the schemes that can start an auto-escaped URL.
+var safeURLSchemes = map[string]bool{"http": true, "https": true, "mailto": true, "tel": true}
+
+// htmlContext tracks the HTML context of the rendered output, so that
+// object output can be escaped for the place it lands in.
+//
+// It sees the template text and the output of objects, but not the output
+// of tags; tags are expected to emit balanced markup.
+type htmlContext struct {
+	state   htmlState
+	attr    attrKind
+	delim   byte // the attribute value quote character; 0 if unquoted
+	url     urlPart
+	jsQuote byte // the quote character of the enclosing JavaScript string literal, if any
+	jsEsc   bool // the previous JavaScript character was a backslash
+	element string
+	name    []byte // the tag or attribute name being scanned
+	tail    []byte // the most recent characters, to find the end of a comment or raw text element
+}
+
+func newHTMLContext() *htmlContext {
+	return &htmlContext{}
+}
+
+// Write advances the context past b. It never fails.
+func (c *htmlContext) Write(b []byte) (int, error) {
+	for _, ch := range b {
+		c.next(ch)
+	}
+	return len(b), nil
+}
+
+func (c *htmlContext) next(ch byte) { // nolint: gocyclo
+	switch c.state {
+	case htmlStateText:
+		if ch == '<' {
+			c.state = htmlStateTagOpen
+		}
+	case htmlStateTagOpen:
+		switch {
+		case ch == '/':
+			c.state, c.name = htmlStateEndTag, c.name[:0]
+		case ch == '!':
+			c.state, c.tail = htmlStateDecl, append(c.tail[:0], '!')
+		case isASCIILetter(ch):
+			c.state, c.name = htmlStateTagName, append(c.name[:0], lower(ch))
+		default:
+			c.state = htmlStateText
+		}
+	case htmlStateTagName:
+		switch {
+		case ch == '>':
+			c.element = string(c.name)
+			c.endStartTag()
+		case isHTMLSpace(ch) || ch == '/':
+			c.element = string(c.name)
+			c.state = htmlStateTag
+		default:
+			c.name = append(c.name, lower(ch))
+		}
+	case htmlStateEndTag:
+		if ch == '>' {
+			c.state = htmlStateText
+		}
+	case htmlStateDecl:
+		c.tail = append(c.tail, ch)
+		if string(c.tail) == "!--" {
+			c.state, c.tail = htmlStateComment, c.tail[:0]
+		} else if ch == '>' {
+			c.state = htmlStateText
+		}
+	case htmlStateComment:
+		if c.seen(ch, "-->") {
+			c.state = htmlStateText
+		}
+	case htmlStateTag:
+		switch {
+		case ch == '>':
+			c.endStartTag()
+		case isHTMLSpace(ch) || ch == '/':
+		default:
+			c.state, c.name = htmlStateAttrName, append(c.name[:0], lower(ch))
+		}
+	case htmlStateAttrName:
+		switch {
+		case ch == '>':
+			c.endStartTag()
+		case ch == '=':
+			c.state = htmlStateBeforeValue
+		case isHTMLSpace(ch):
+			c.state = htmlStateAfterName
+		default:
+			c.name = append(c.name, lower(ch))
+		}
+	case htmlStateAfterName:
+		switch {
+		case ch == '>':
+			c.endStartTag()
+		case ch == '=':
+			c.state = htmlStateBeforeValue
+		case isHTMLSpace(ch) || ch == '/':
+		default:
+			c.state, c.name = htmlStateAttrName, append(c.name[:0], lower(ch))
+		}
+	case htmlStateBeforeValue:
+		switch {
+		case isHTMLSpace(ch):
+		case ch == '>':
+			c.endStartTag()
+		case ch == '"' || ch == '\'':
+			c.startAttr(ch)
+		default:
+			c.startAttr(0)
+			c.next(ch)
+		}
+	case htmlStateAttr:
+		switch {
+		case c.delim != 0 && ch == c.delim:
+			c.state = htmlStateTag
+		case c.delim == 0 && isHTMLSpace(ch):
+			c.state = htmlStateTag
+		case c.delim == 0 && ch == '>':
+			c.endStartTag()
+		case c.attr == attrURL:
+			if ch == '?' || ch == '#' {
+				c.url = urlPartQuery
+			} else if c.url == urlPartNone {
+				c.url = urlPartPreQuery
+			}
+		case c.attr == attrScript:
+			c.nextJS(ch)
+		}
+	case htmlStateScript:
+		if c.seen(ch, "</script") {
+			c.state, c.name = htmlStateEndTag, c.name[:0]
+			return
+		}
+		c.nextJS(ch)
+	case htmlStateStyle:
+		if c.seen(ch, "</style") {
+			c.state, c.name = htmlStateEndTag, c.name[:0]
+		}
+	case htmlStateRCDATA:
+		if c.seen(ch, "</"+c.element) {
+			c.state, c.name = htmlStateEndTag, c.name[:0]
+		}
+	}
+}
+
+// seen records ch, and reports whether the most recent characters are suffix,
+// ignoring case.
+func (c *htmlContext) seen(ch byte, suffix string) bool {
+	c.tail = append(c.tail, lower(ch))
+	if len(c.tail) > len(suffix) {
+		c.tail = c.tail[len(c.tail)-len(suffix):]
+	}
+	if string(c.tail) == suffix {
+		c.tail = c.tail[:0]
+		return true
+	}
+	return false
+}
+
+// nextJS tracks JavaScript string literals.
+func (c *htmlContext) nextJS(ch byte) {
+	switch {
+	case c.jsQuote == 0:
+		if ch == '"' || ch == '\'' || ch == '`' {
+			c.jsQuote = ch
+		}
+	case c.jsEsc:
+		c.jsEsc = false
+	case ch == '\\':
+		c.jsEsc = true
+	case ch == c.jsQuote:
+		c.jsQuote = 0
+	}
+}
+
+func (c *htmlContext) startAttr(delim byte) {
+	name := string(c.name)
+	c.state, c.delim, c.url, c.jsQuote, c.jsEsc = htmlStateAttr, delim, urlPartNone, 0, false
+	switch {
+	case urlAttrs[name]:
+		c.attr = attrURL
+	case strings.HasPrefix(name, "on"):
+		c.attr = attrScript
+	case name == "style":
+		c.attr = attrStyle
+	default:
+		c.attr = attrNormal
+	}
+}
+
+func (c *htmlContext) endStartTag() {
+	c.tail, c.jsQuote, c.jsEsc = c.tail[:0], 0, false
+	switch c.element {
+	case "script":
+		c.state = htmlStateScript
+	case "style":
+		c.state = htmlStateStyle
+	case "textarea", "title":
+		c.state = htmlStateRCDATA
+	default:
+		c.state = htmlStateText
+	}
+}
+
+// escape returns the text of value, escaped for the current context.
+func (c *htmlContext) escape(value interface{}) (string, error) {
+	value = values.ToLiquid(value)
+	if s, ok := value.(values.SafeHTML); ok {
+		return string(s), nil
+	}
+	if c.state == htmlStateBeforeValue {
+		// The object starts an unquoted attribute value.
+		c.startAttr(0)
+	}
+	switch c.state {
+	case htmlStateAttrName, htmlStateAfterName, htmlStateTag, htmlStateTagName:
+		// An object inside a tag but outside an attribute value can add attributes.
+		// Only allow output that can't.
+		s, err := objectString(value)
+		if err != nil {
+			return "", err
+		}
+		return escapeAttrName(s), nil
+	case htmlStateAttr:
+		var s string
+		switch c.attr {
+		case attrURL:
+			t, err := objectString(value)
+			if err != nil {
+				return "", err
+			}
+			s = escapeURL(t, c.url)
+		case attrScript:
+			t, err := escapeJS(value, c.jsQuote)
+			if err != nil {
+				return "", err
+			}
+			s = t
+		case attrStyle:
+			t, err := objectString(value)
+			if err != nil {
+				return "", err
+			}
+			s = escapeCSS(t)
+		default:
+			t, err := objectString(value)
+			if err != nil {
+				return "", err
+			}
+			s = t
+		}
+		if c.delim == 0 {
+			return escapeUnquotedAttr(s), nil
+		}
+		return html.EscapeString(s), nil
+	case htmlStateScript:
+		return escapeJS(value, c.jsQuote)
+	case htmlStateStyle:
+		s, err := objectString(value)
+		if err != nil {
+			return "", err
+		}
+		return escapeCSS(s), nil
+	default:
+		s, err := objectString(value)
+		if err != nil {
+			return "", err
+		}
+		return html.EscapeString(s), nil
+	}
+}
+
+// objectString returns the unescaped text that writeObject would write.
+func objectString(value interface{}) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := writeObject(buf, value); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func escapeAttrName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < utf8.RuneSelf && (isASCIILetter(byte(r)) || '0' <= r && r <= '9' || r == '-' || r == '_') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func escapeUnquotedAttr(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '&', '<', '>', '"', '\'', '=', '`', ' ', '\t', '\n', '\f', '\r':
+			fmt.Fprintf(&b, "&#%d;", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// escapeURL escapes s for a URL attribute value. At the start of the URL, a URL
+// with an unsafe scheme, such as javascript:, is replaced.
+func escapeURL(s string, part urlPart) string {
+	switch part {
+	case urlPartNone:
+		if i := strings.IndexAny(s, ":/?#"); i >= 0 && s[i] == ':' && !safeURLSchemes[strings.ToLower(s[:i])] {
+			return unsafeURL
+		}
+		return normalizeURL(s)
+	case urlPartPreQuery:
+		return normalizeURL(s)
+	default:
+		return url.QueryEscape(s)
+	}
+}
+
+// normalizeURL percent-encodes the characters that can't appear in a URL,
+// leaving the URL's structure and existing escapes alone.
+func normalizeURL(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case 'a' <= ch && ch <= 'z', 'A' <= ch && ch <= 'Z', '0' <= ch && ch <= '9':
+			b.WriteByte(ch)
+		case strings.IndexByte("-._~:/?#[]@!$&*+,;=%", ch) >= 0:
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+// escapeJS escapes a value for JavaScript. Inside a string literal, the value's
+// text is escaped; elsewhere, the value is written as a JSON literal.
+func escapeJS(value interface{}, quote byte) (string, error) {
+	if quote != 0 {
+		s, err := objectString(value)
+		if err != nil {
+			return "", err
+		}
+		return escapeJSString(s), nil
+	}
+	if s, ok := value.(string); ok {
+		return `"` + escapeJSString(s) + `"`, nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		s, err := objectString(value)
+		if err != nil {
+			return "", err
+		}
+		return `"` + escapeJSString(s) + `"`, nil
+	}
+	// json.Marshal escapes <, > and &, so this can't close the script element.
+	return strings.NewReplacer("\u2028", `\u2028`, "\u2029", `\u2029`).Replace(string(b)), nil
+}
+
+func escapeJSString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '"', '\'', '`', '<', '>', '&', '=', '/', '\u2028', '\u2029':
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			if r < ' ' {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// escapeCSS escapes everything but letters, digits, and a few harmless
+// characters as CSS hex escapes.
+func escapeCSS(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r < utf8.RuneSelf && (isASCIILetter(byte(r)) || '0' <= r && r <= '9'):
+			b.WriteRune(r)
+		case r == ' ' || r == '.' || r == ',' || r == '-' || r == '_' || r == '#' || r == '%':
+			b.WriteRune(r)
+		case r >= utf8.RuneSelf:
+			b.WriteRune(r)
+		default:
+			fmt.Fprintf(&b, `\%x `, r)
+		}
+	}
+	return b.String()
+}
+
+func isASCIILetter(ch byte) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z'
+}
+
+func isHTMLSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\f' || ch == '\r'
+}
+
+func lower(ch byte) byte {
+	if 'A' <= ch && ch <= 'Z' {
+		return ch + 'a' - 'A'
+	}
+	return ch
+}
+
+// snapshot returns a copy of the context, that doesn't share its buffers.
+func (c *htmlContext) snapshot() htmlContext {
+	s := *c
+	s.name = append([]byte(nil), c.name...)
+	s.tail = append([]byte(nil), c.tail...)
+	return s
+}
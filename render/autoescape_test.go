@@ -0,0 +1,70 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/autopilot3/liquid/parser"
+	"github.com/autopilot3/liquid/values"
+	"github.com/stretchr/testify/require"
+)
+
+var autoEscapeTests = []struct{ in, out string }{
+	// element text
+	{`<p>{{ s }}</p>`, `<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</p>`},
+	{`{{ n }} {{ array }}`, `123 a&lt;b, c&amp;d`},
+	{`<p>{{ safe }}</p>`, `<p><b>bold</b></p>`},
+	{`<title>{{ s }}</title>`, `<title>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</title>`},
+	{`<!-- {{ s }} -->`, `<!-- &lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; -->`},
+
+	// attributes
+	{`<a title="{{ quote }}">`, `<a title="&#34; onclick=&#34;x">`},
+	{`<a title='{{ quote }}'>`, `<a title='&#34; onclick=&#34;x'>`},
+	{`<a title={{ quote }}>`, `<a title=&#34;&#32;onclick&#61;&#34;x>`},
+	{`<a {{ quote }}>`, `<a onclickx>`},
+
+	// URL attributes
+	{`<a href="{{ js }}">`, `<a href="about:invalid#zLiquidz">`},
+	{`<a href="{{ url }}">`, `<a href="https://example.com/a%20b?x=1&amp;y=%3C">`},
+	{`<a href="/search?q={{ query }}">`, `<a href="/search?q=a%26b+c">`},
+	{`<a href="/p/{{ query }}">`, `<a href="/p/a&amp;b%20c">`},
+	{`<img src="{{ js }}">`, `<img src="about:invalid#zLiquidz">`},
+
+	// script
+	{`<script>var s = {{ s }};</script>`, `<script>var s = "\u003Cscript\u003Ealert(\u0022x\u0022)\u003C\u002Fscript\u003E";</script>`},
+	{`<script>var s = '{{ quote }}';</script>`, `<script>var s = '\u0022 onclick\u003D\u0022x';</script>`},
+	{`<script>var n = {{ n }};</script>{{ s }}`, `<script>var n = 123;</script>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;`},
+	{`<button onclick="f({{ quote }})">`, `<button onclick="f(&#34;\u0022 onclick\u003D\u0022x&#34;)">`},
+
+	// style
+	{`<style>p { color: {{ css }}; }</style>`, `<style>p { color: red\3b  background\3a  url\28 x\29 ; }</style>`},
+	{`<p style="color: {{ css }}">`, `<p style="color: red\3b  background\3a  url\28 x\29 ">`},
+}
+
+var autoEscapeTestBindings = map[string]interface{}{
+	"array": []string{"a<b", "c&d"},
+	"css":   "red; background: url(x)",
+	"js":    "javascript:alert(1)",
+	"n":     123,
+	"query": "a&b c",
+	"quote": `" onclick="x`,
+	"s":     `<script>alert("x")</script>`,
+	"safe":  values.SafeHTML("<b>bold</b>"),
+	"url":   "https://example.com/a b?x=1&y=<",
+}
+
+func TestRender_autoEscape(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AutoEscape = true
+	for i, test := range autoEscapeTests {
+		t.Run(fmt.Sprintf("%02d", i+1), func(t *testing.T) {
+			root, err := cfg.Compile(test.in, parser.SourceLoc{})
+			require.NoErrorf(t, err, test.in)
+			buf := new(bytes.Buffer)
+			err = Render(root, buf, autoEscapeTestBindings, cfg)
+			require.NoErrorf(t, err, test.in)
+			require.Equalf(t, test.out, buf.String(), test.in)
+		})
+	}
+}
@@ -36,6 +36,7 @@ func (c Config) compileNode(n parser.ASTNode) (Node, parser.Error) {
 			Token:   n.Token,
 			Body:    body,
 			Clauses: branches,
+			End:     n.End,
 		}
 		if cd.parser != nil {
 			r, err := cd.parser(node)
@@ -46,7 +47,7 @@ func (c Config) compileNode(n parser.ASTNode) (Node, parser.Error) {
 		}
 		return &node, nil
 	case *parser.ASTRaw:
-		return &RawNode{n.Slices, sourcelessNode{}}, nil
+		return &RawNode{n.Token, n.Slices}, nil
 	case *parser.ASTSeq:
 		children, err := c.compileNodes(n.Children)
 		if err != nil {
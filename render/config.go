@@ -3,6 +3,7 @@ package render
 import (
 	"context"
 
+	"github.com/autopilot3/liquid/i18n"
 	"github.com/autopilot3/liquid/parser"
 )
 
@@ -12,6 +13,25 @@ type Config struct {
 	grammar
 	AllowedTags          map[string]struct{}
 	AllowTagsWithDefault bool
+	// AutoEscape escapes the output of each object for its HTML context.
+	AutoEscape bool
+	// DateFormats are the named formats of the date filters.
+	DateFormats *DateFormats
+	// MoneyDisplay is how the money filters display amounts with a currency.
+	MoneyDisplay MoneyDisplay
+	// RateProvider supplies the exchange rates of the convert_currency filter.
+	RateProvider RateProvider
+	// LinkRewriter rewrites the input of the trackURL filter and, if RewriteAllLinks
+	// is set, the http and https hrefs of the rendered <a> elements.
+	LinkRewriter    LinkRewriter
+	RewriteAllLinks bool
+	// Links is the link rewriting state of a render. It is nil outside a render,
+	// or without a LinkRewriter.
+	Links *Links
+	// Catalog holds the messages of the t filter, and DefaultLocale is the locale
+	// to fall back to.
+	Catalog       i18n.Catalog
+	DefaultLocale string
 }
 
 type grammar struct {
@@ -30,9 +50,17 @@ func NewConfigWitchContext(ctx context.Context) Config {
 		blockDefs: map[string]*blockSyntax{},
 	}
 	return Config{
-		parser.NewConfig(g, ctx),
-		g,
-		nil,
-		false,
+		Config:  parser.NewConfig(g, ctx),
+		grammar: g,
 	}
 }
+
+type configKey struct{}
+
+// ContextConfig returns the configuration of the render that a filter is called in, from
+// the context.Context that the filter takes as its first parameter. It returns nil
+// outside a render.
+func ContextConfig(ctx context.Context) *Config {
+	c, _ := ctx.Value(configKey{}).(*Config)
+	return c
+}
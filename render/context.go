@@ -51,12 +51,6 @@ type Context interface {
 	WrapError(err error) Error
 
 	GetConfig() *Config
-
-	IsFindVars() bool
-
-	SetLoopVar(name string, sourceName string) int
-	RemoveLoopVar(key int)
-	SetVar(name string)
 }
 
 type rendererContext struct {
@@ -65,35 +59,6 @@ type rendererContext struct {
 	cn   *BlockNode
 }
 
-func (c rendererContext) IsFindVars() bool {
-	return c.ctx.findVariablesOnly
-}
-
-func (c rendererContext) SetLoopVar(name string, sourceName string) int {
-	loopVars, ok := c.ctx.bindings[expressions.LoopVarsKey]
-	if !ok {
-		loopVars = expressions.NewLoopVars()
-		c.ctx.bindings[expressions.LoopVarsKey] = loopVars
-	}
-	return loopVars.(*expressions.LoopVarsStack).Set(name, sourceName)
-}
-
-func (c rendererContext) SetVar(name string) {
-	assignedVars, ok := c.ctx.bindings[expressions.AssignedVarsKey]
-	if !ok {
-		assignedVars = make(map[string]struct{})
-		c.ctx.bindings[expressions.AssignedVarsKey] = assignedVars
-	}
-	assignedVars.(map[string]struct{})[name] = struct{}{}
-}
-
-func (c rendererContext) RemoveLoopVar(key int) {
-	loopVars, ok := c.ctx.bindings[expressions.LoopVarsKey]
-	if ok {
-		loopVars.(*expressions.LoopVarsStack).Remove(key)
-	}
-}
-
 func (c rendererContext) Errorf(format string, a ...interface{}) Error {
 	return renderErrorf(c.node, format, a...)
 }
@@ -154,6 +119,11 @@ func (c rendererContext) RenderFile(filename string, b map[string]interface{}) (
 
 // InnerString renders the children to a string.
 func (c rendererContext) InnerString() (string, error) {
+	if c.ctx.html != nil {
+		// The children aren't written to the output here, so they don't
+		// change its HTML context.
+		defer func(saved htmlContext) { *c.ctx.html = saved }(c.ctx.html.snapshot())
+	}
 	buf := new(bytes.Buffer)
 	if err := c.RenderChildren(buf); err != nil {
 		return "", err
@@ -0,0 +1,303 @@
+package render
+
+import (
+	"strings"
+	"time"
+
+	"github.com/autopilot3/ap3-types-go/types/date"
+)
+
+// A DateTimeFormat is a named datetime format.
+type DateTimeFormat struct {
+	// Layout is a time.Time.Format layout, such as "Jan 02 2006 3:04 PM".
+	Layout string
+	// LowerMeridiem writes AM and PM in lower case.
+	LowerMeridiem bool
+}
+
+// An HourCycle is a preference for 12-hour or 24-hour times.
+type HourCycle int
+
+// The hour cycles.
+const (
+	// HourCycleDefault uses the hour cycle of each format.
+	HourCycleDefault HourCycle = iota
+	// HourCycle12 uses the 12-hour variant of a format, such as mdy12 for mdy24.
+	HourCycle12
+	// HourCycle24 uses the 24-hour variant of a format, such as mdy24 for mdy12.
+	HourCycle24
+)
+
+// defaultDateFormats are the built-in named date formats.
+var defaultDateFormats = map[string]string{
+	"mdy":   "01/02/2006",
+	"dmy":   "02/01/2006",
+	"ymd":   "2006/01/02",
+	"ydm":   "2006/02/01",
+	"mdyaw": "Monday, January 2, 2006",
+	"mdya":  "January 2, 2006",
+	"mdys":  "1/2/06",
+	"dmyaw": "Monday, 2 January, 2006",
+	"dmya":  "2 January, 2006",
+	"dmys":  "2/1/06",
+	"d":     "2",
+	"dd":    "02",
+	"m":     "1",
+	"mm":    "01",
+	"yy":    "06",
+	"yyyy":  "2006",
+}
+
+// defaultDateTimeFormats are the built-in named datetime formats.
+var defaultDateTimeFormats = map[string]DateTimeFormat{
+	"mdy12":   {Layout: "Jan 02 2006 3:04 PM"},
+	"mdy24":   {Layout: "Jan 02 2006 15:04"},
+	"dmy12":   {Layout: "02 Jan 2006 3:04 PM"},
+	"dmy24":   {Layout: "02 Jan 2006 15:04"},
+	"ymd12":   {Layout: "2006 Jan 02 3:04 PM"},
+	"ymd24":   {Layout: "2006 Jan 02 15:04"},
+	"ydm12":   {Layout: "2006 02 Jan 3:04 PM"},
+	"ydm24":   {Layout: "2006 02 Jan 15:04"},
+	"mdy24aw": {Layout: "15:04 Monday, January 2, 2006"},
+	"mdy12aw": {Layout: "3:04PM Monday, January 2, 2006", LowerMeridiem: true},
+	"mdyaw":   {Layout: "Monday, January 2, 2006"},
+	"mdy24a":  {Layout: "15:04 January 2, 2006"},
+	"mdy12a":  {Layout: "3:04PM January 2, 2006", LowerMeridiem: true},
+	"mdya":    {Layout: "January 2, 2006"},
+	"mdy24n":  {Layout: "15:04 01/02/2006"},
+	"mdy12n":  {Layout: "3:04PM 01/02/2006", LowerMeridiem: true},
+	"mdy24nd": {Layout: "01/02/2006 15:04"},
+	"mdy12nd": {Layout: "01/02/2006 3:04PM", LowerMeridiem: true},
+	"mdy":     {Layout: "01/02/2006"},
+	"mdys24":  {Layout: "15:04 1/2/06"},
+	"mdys12":  {Layout: "3:04PM 1/2/06", LowerMeridiem: true},
+	"mdys24d": {Layout: "1/2/06 15:04"},
+	"mdys12d": {Layout: "1/2/06 3:04PM", LowerMeridiem: true},
+	"mdys":    {Layout: "1/2/06"},
+	"dmy24aw": {Layout: "15:04 Monday, 2 January, 2006"},
+	"dmy12aw": {Layout: "3:04PM Monday, 2 January, 2006", LowerMeridiem: true},
+	"dmyaw":   {Layout: "Monday, 2 January, 2006"},
+	"dmy24a":  {Layout: "15:04 2 January, 2006"},
+	"dmy12a":  {Layout: "3:04PM 2 January, 2006", LowerMeridiem: true},
+	"dmya":    {Layout: "2 January, 2006"},
+	"dmy24n":  {Layout: "15:04 02/01/2006"},
+	"dmy12n":  {Layout: "3:04PM 02/01/2006", LowerMeridiem: true},
+	"dmy24nd": {Layout: "02/01/2006 15:04"},
+	"dmy12nd": {Layout: "02/01/2006 3:04PM", LowerMeridiem: true},
+	"dmy":     {Layout: "02/01/2006"},
+	"dmys24":  {Layout: "15:04 2/1/06"},
+	"dmys12":  {Layout: "3:04PM 2/1/06", LowerMeridiem: true},
+	"dmys24d": {Layout: "2/1/06 15:04"},
+	"dmys12d": {Layout: "2/1/06 3:04PM", LowerMeridiem: true},
+	"dmys":    {Layout: "2/1/06"},
+	"h24":     {Layout: "15"},
+	"h12":     {Layout: "3"},
+	"min":     {Layout: "04"},
+	"p":       {Layout: "PM", LowerMeridiem: true},
+	"d":       {Layout: "2"},
+	"dd":      {Layout: "02"},
+	"dow":     {Layout: "Monday"},
+	"m":       {Layout: "1"},
+	"mm":      {Layout: "01"},
+	"mon":     {Layout: "January"},
+	"yy":      {Layout: "06"},
+	"yyyy":    {Layout: "2006"},
+}
+
+// DateFormats is a registry of the named formats of the dateFormatOrDefault,
+// dateTimeFormatOrDefault and timeInTimezone filters, with the month and day names
+// and the hour cycle to format them with.
+//
+// Configure a registry before rendering with it; its methods aren't safe to call
+// during a render.
+type DateFormats struct {
+	dates            map[string]string
+	dateTimes        map[string]DateTimeFormat
+	months           [12]string
+	shortMonths      [12]string
+	days             [7]string
+	shortDays        [7]string
+	localized        bool
+	hourCycle        HourCycle
+	dateFallback     string
+	dateTimeFallback string
+}
+
+// NewDateFormats returns a registry of the built-in formats.
+func NewDateFormats() *DateFormats {
+	f := &DateFormats{
+		dates:     make(map[string]string, len(defaultDateFormats)),
+		dateTimes: make(map[string]DateTimeFormat, len(defaultDateTimeFormats)),
+	}
+	for name, layout := range defaultDateFormats {
+		f.dates[name] = layout
+	}
+	for name, format := range defaultDateTimeFormats {
+		f.dateTimes[name] = format
+	}
+	return f
+}
+
+// Clone returns a copy of the registry, for example to add the formats of a tenant
+// to those of an engine. See liquid.WithDateFormats.
+func (f *DateFormats) Clone() *DateFormats {
+	c := *f
+	c.dates = make(map[string]string, len(f.dates))
+	for name, layout := range f.dates {
+		c.dates[name] = layout
+	}
+	c.dateTimes = make(map[string]DateTimeFormat, len(f.dateTimes))
+	for name, format := range f.dateTimes {
+		c.dateTimes[name] = format
+	}
+	return &c
+}
+
+// AddDateFormat adds or replaces a named date format. The layout is a time.Time.Format layout.
+func (f *DateFormats) AddDateFormat(name, layout string) *DateFormats {
+	f.dates[name] = layout
+	return f
+}
+
+// AddDateTimeFormat adds or replaces a named datetime format.
+func (f *DateFormats) AddDateTimeFormat(name string, format DateTimeFormat) *DateFormats {
+	f.dateTimes[name] = format
+	return f
+}
+
+// SetMonthNames sets the names of the months, January first, and their abbreviations,
+// that replace January and Jan in the layouts.
+func (f *DateFormats) SetMonthNames(names, abbreviations [12]string) *DateFormats {
+	f.months, f.shortMonths = names, abbreviations
+	f.localized = true
+	return f
+}
+
+// SetDayNames sets the names of the days of the week, Sunday first, and their abbreviations,
+// that replace Monday and Mon in the layouts.
+func (f *DateFormats) SetDayNames(names, abbreviations [7]string) *DateFormats {
+	f.days, f.shortDays = names, abbreviations
+	f.localized = true
+	return f
+}
+
+// SetHourCycle sets the preference for 12-hour or 24-hour times. A datetime format whose
+// name contains 12 or 24 is replaced by the format with the other number, if there is one.
+func (f *DateFormats) SetHourCycle(c HourCycle) *DateFormats {
+	f.hourCycle = c
+	return f
+}
+
+// SetFallback sets the layouts of dates and datetimes whose format isn't in the registry.
+// With an empty layout, a date is written as 2006-01-02, and a datetime as by time.Time.String.
+func (f *DateFormats) SetFallback(dateLayout, dateTimeLayout string) *DateFormats {
+	f.dateFallback, f.dateTimeFallback = dateLayout, dateTimeLayout
+	return f
+}
+
+// FormatDate formats a date with a named format.
+func (f *DateFormats) FormatDate(d date.Date, name string) string {
+	t, err := d.Time()
+	if err != nil {
+		return d.String()
+	}
+	if layout, ok := f.dates[name]; ok {
+		return f.format(t, layout, false)
+	}
+	if f.dateFallback != "" {
+		return f.format(t, f.dateFallback, false)
+	}
+	return d.String()
+}
+
+// FormatDateTime formats a time with a named format.
+func (f *DateFormats) FormatDateTime(t time.Time, name string) string {
+	if format, ok := f.dateTimes[f.hourCycleName(name)]; ok {
+		return f.format(t, format.Layout, format.LowerMeridiem)
+	}
+	if f.dateTimeFallback != "" {
+		return f.format(t, f.dateTimeFallback, false)
+	}
+	return t.String()
+}
+
+// hourCycleName returns the name of the variant of a datetime format for the hour cycle.
+func (f *DateFormats) hourCycleName(name string) string {
+	from, to := "24", "12"
+	switch f.hourCycle {
+	case HourCycle12:
+	case HourCycle24:
+		from, to = to, from
+	default:
+		return name
+	}
+	if !strings.Contains(name, from) {
+		return name
+	}
+	if variant := strings.Replace(name, from, to, 1); f.dateTimes[variant].Layout != "" {
+		return variant
+	}
+	return name
+}
+
+// nameTokens are the layout elements that SetMonthNames and SetDayNames localize,
+// longest first.
+var nameTokens = []string{"January", "Monday", "Jan", "Mon"}
+
+// format formats t with a layout and the registry's month and day names.
+func (f *DateFormats) format(t time.Time, layout string, lower bool) string {
+	if !f.localized {
+		return formatLayout(t, layout, lower)
+	}
+	var b strings.Builder
+	for layout != "" {
+		i, token := len(layout), ""
+		for _, tok := range nameTokens {
+			if j := strings.Index(layout, tok); j >= 0 && j < i {
+				i, token = j, tok
+			}
+		}
+		b.WriteString(formatLayout(t, layout[:i], lower))
+		if token == "" {
+			break
+		}
+		b.WriteString(f.name(t, token))
+		layout = layout[i+len(token):]
+	}
+	return b.String()
+}
+
+// name returns the month or day name of t for a layout element.
+func (f *DateFormats) name(t time.Time, token string) string {
+	var name string
+	switch token {
+	case "January":
+		name = f.months[t.Month()-1]
+	case "Jan":
+		name = f.shortMonths[t.Month()-1]
+	case "Monday":
+		name = f.days[t.Weekday()]
+	case "Mon":
+		name = f.shortDays[t.Weekday()]
+	}
+	if name == "" {
+		return t.Format(token)
+	}
+	return name
+}
+
+func formatLayout(t time.Time, layout string, lower bool) string {
+	if layout == "" {
+		return ""
+	}
+	formatted := t.Format(layout)
+	if lower {
+		return lowerMeridiem(formatted)
+	}
+	return formatted
+}
+
+func lowerMeridiem(value string) string {
+	value = strings.ReplaceAll(value, "AM", "am")
+	return strings.ReplaceAll(value, "PM", "pm")
+}
@@ -0,0 +1,61 @@
+package render
+
+import "context"
+
+// A Link is a URL that a LinkRewriter rewrites.
+type Link struct {
+	// URL is the link's URL, with HTML character references decoded.
+	URL string
+	// Sequence is the number of links that were rewritten before the link during the
+	// render. The inputs of trackURL are rewritten as they're rendered, and the hrefs of
+	// RewriteAllLinks after the render, so it isn't the link's position in the output.
+	Sequence int
+	// Auto is true if the link is the href of an <a> element in the rendered output,
+	// and false if it is the input of the trackURL filter.
+	Auto bool
+	// Data is the per-render data from WithLinkData, such as the recipient ID.
+	Data map[string]interface{}
+}
+
+// A LinkRewriter replaces the URLs of links, for example with click-tracking URLs.
+type LinkRewriter interface {
+	RewriteLink(ctx context.Context, link Link) (string, error)
+}
+
+// LinkRewriterFunc adapts a function to a LinkRewriter.
+type LinkRewriterFunc func(ctx context.Context, link Link) (string, error)
+
+// RewriteLink calls f(ctx, link).
+func (f LinkRewriterFunc) RewriteLink(ctx context.Context, link Link) (string, error) {
+	return f(ctx, link)
+}
+
+// Links is the link rewriting state of a single render.
+type Links struct {
+	rewriter  LinkRewriter
+	data      map[string]interface{}
+	next      int
+	rewritten map[string]bool // the URLs that Rewrite has returned
+}
+
+// NewLinks returns the state of a render whose links a LinkRewriter rewrites
+// with per-render data.
+func NewLinks(r LinkRewriter, data map[string]interface{}) *Links {
+	return &Links{rewriter: r, data: data, rewritten: map[string]bool{}}
+}
+
+// Rewrite rewrites a URL, which is the href of an <a> element if auto is true.
+func (l *Links) Rewrite(ctx context.Context, u string, auto bool) (string, error) {
+	out, err := l.rewriter.RewriteLink(ctx, Link{URL: u, Sequence: l.next, Auto: auto, Data: l.data})
+	if err != nil {
+		return "", err
+	}
+	l.next++
+	l.rewritten[out] = true
+	return out, nil
+}
+
+// Rewritten reports whether Rewrite has returned a URL.
+func (l *Links) Rewritten(u string) bool {
+	return l.rewritten[u]
+}
@@ -0,0 +1,32 @@
+package render
+
+import "context"
+
+// A SymbolPosition places the currency symbol of an amount of money.
+type SymbolPosition string
+
+// The positions of the currency symbol.
+const (
+	// SymbolLocale places the symbol where the locale does.
+	SymbolLocale SymbolPosition = ""
+	// SymbolBefore places the symbol before the number, as in €12.34.
+	SymbolBefore SymbolPosition = "before"
+	// SymbolAfter places the symbol after the number, as in 12.34 €.
+	SymbolAfter SymbolPosition = "after"
+)
+
+// MoneyDisplay configures how amounts of money are displayed.
+type MoneyDisplay struct {
+	// Accounting displays negative amounts in parentheses, as in ($3.00),
+	// in locales that have an accounting format.
+	Accounting bool
+	// Symbol is the position of the currency symbol.
+	Symbol SymbolPosition
+}
+
+// A RateProvider supplies exchange rates for the convert_currency filter.
+type RateProvider interface {
+	// Rate returns the number of units of the currency to that one unit of the currency
+	// from is worth, as a decimal string such as "0.9215".
+	Rate(ctx context.Context, from, to string) (string, error)
+}
@@ -1,6 +1,8 @@
 package render
 
 import (
+	"context"
+
 	"github.com/autopilot3/liquid/expressions"
 )
 
@@ -9,17 +11,27 @@ import (
 // This type has a clumsy name so that render.Context, in the public API, can
 // have a clean name that doesn't stutter.
 type nodeContext struct {
-	bindings          map[string]interface{}
-	config            Config
-	findVariablesOnly bool
+	bindings map[string]interface{}
+	config   Config
+	html     *htmlContext  // non-nil if output is auto-escaped
+	partial  *partialState // non-nil if the render is partial
 }
 
 // newNodeContext creates a new evaluation context.
 func newNodeContext(scope map[string]interface{}, c Config) nodeContext {
-	return nodeContext{
+	// Filters that take a context.Context find the render configuration in it.
+	c.SetContext(context.WithValue(c.Context(), configKey{}, &c))
+	ctx := nodeContext{
 		bindings: deepCopyBindings(scope),
 		config:   c,
 	}
+	if c.AutoEscape {
+		ctx.html = newHTMLContext()
+	}
+	if len(c.AllowedTags) > 0 {
+		ctx.partial = newPartialState(c)
+	}
+	return ctx
 }
 
 func deepCopyBindings(scope map[string]interface{}) map[string]interface{} {
@@ -45,18 +57,7 @@ func deepCopyValue(v interface{}) interface{} {
 	}
 }
 
-func newFindVariablesNodeContext(c Config) nodeContext {
-	return nodeContext{
-		bindings:          make(map[string]interface{}),
-		config:            c,
-		findVariablesOnly: true,
-	}
-}
-
 // Evaluate evaluates an expression within the template context.
 func (c nodeContext) Evaluate(expr expressions.Expression) (out interface{}, err error) {
-	if c.findVariablesOnly {
-		return expr.Evaluate(expressions.NewVariablesContext(c.bindings, c.config.Config.Config))
-	}
 	return expr.Evaluate(expressions.NewContext(c.bindings, c.config.Config.Config))
 }
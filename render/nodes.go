@@ -2,6 +2,7 @@ package render
 
 import (
 	"io"
+	"strings"
 
 	"github.com/autopilot3/liquid/expressions"
 	"github.com/autopilot3/liquid/parser"
@@ -20,12 +21,13 @@ type BlockNode struct {
 	renderer func(io.Writer, Context) error
 	Body     []Node
 	Clauses  []*BlockNode
+	End      parser.Token // the end tag; clauses don't have one
 }
 
 // RawNode holds the text between the start and end of a raw tag.
 type RawNode struct {
-	slices []string
-	sourcelessNode
+	parser.Token // the raw tag
+	slices       []string
 }
 
 // TagNode renders itself via a render function that is created during parsing.
@@ -61,3 +63,8 @@ func (n *sourcelessNode) SourceLocation() parser.SourceLoc {
 func (n *sourcelessNode) SourceText() string {
 	panic("unexpected call on sourceless node")
 }
+
+// Text returns the text between the raw tag and its end tag.
+func (n *RawNode) Text() string {
+	return strings.Join(n.slices, "")
+}
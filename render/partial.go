@@ -0,0 +1,686 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/autopilot3/liquid/expressions"
+	"github.com/autopilot3/liquid/parser"
+	"github.com/autopilot3/liquid/values"
+)
+
+// A partialState is the state of a partial render, which evaluates the expressions whose
+// variables are all known, and writes the rest of the template as a residual template
+// that can be rendered later with the other variables.
+//
+// The known variables are the paths in Config.AllowedTags and their properties, and the
+// local variables that are assigned from them or iterate over them. With
+// Config.AllowTagsWithDefault, an {{ object }} whose unknown variables all have a default
+// filter is evaluated too.
+type partialState struct {
+	known       map[string]struct{}
+	withDefault bool
+	// locals are the variables that are assigned or iterated over, and whether they're known
+	locals map[string]bool
+	// unwritten are the known local variables whose values can't be written to the
+	// residual template, which doesn't assign them
+	unwritten map[string]bool
+	// residual is the nesting of the residual blocks that the renderer is in
+	residual int
+	// written is the number of residual tokens that have been written
+	written int
+	// raw is the number of outputs that have been written as raw text
+	raw int
+	// loop is the innermost loop that is being unrolled, unless a residual loop is inside it
+	loop *partialLoop
+	// unrolled are the loops that are being unrolled, by the variables they define. A nil
+	// loop is a residual loop, whose variables hide those of the loops around it.
+	unrolled map[string]*partialLoop
+}
+
+// A partialLoop is a loop over a known value, whose body is rendered partially for each
+// element.
+type partialLoop struct {
+	// residual is the nesting of the residual blocks around the loop
+	residual int
+	// failed is set if the unrolled body can't be written to the residual template,
+	// because a residual part of it uses a loop variable whose value can't be written,
+	// or has a loop control tag
+	failed bool
+}
+
+// loopControlTags are the tags that only work in the loop that contains them.
+var loopControlTags = map[string]bool{
+	"break":    true,
+	"continue": true,
+	"cycle":    true,
+}
+
+// loopVariables are the variables that loops define, besides the loop variable.
+var loopVariables = []string{"forloop", "tablerowloop"}
+
+func newPartialState(c Config) *partialState {
+	return &partialState{
+		known:       c.AllowedTags,
+		withDefault: c.AllowTagsWithDefault,
+		locals:      map[string]bool{},
+		unwritten:   map[string]bool{},
+		unrolled:    map[string]*partialLoop{},
+	}
+}
+
+// knownPath returns true if the value of a variable path is known.
+func (p *partialState) knownPath(path string) bool {
+	root := path
+	if i := strings.IndexByte(path, '.'); i >= 0 {
+		root = path[:i]
+	}
+	if known, ok := p.locals[root]; ok {
+		return known
+	}
+	for tag := range p.known {
+		if path == tag || strings.HasPrefix(path, tag+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// knownArgs returns true if the arguments of a tag are expressions whose variables are
+// all known. The tag of an {{ object }} is "object".
+func (p *partialState) knownArgs(tag, args string) bool {
+	trees, ok := argTrees(tag, args)
+	return ok && p.knownExpr(tag == "object", trees...)
+}
+
+// knownExpr returns true if the variables of expression trees are all known. The variables
+// of an object that have a default filter count as known if withDefault is set.
+func (p *partialState) knownExpr(object bool, trees ...expressions.Node) bool {
+	for _, ref := range references(trees...) {
+		if p.knownPath(ref.path) || (object && p.withDefault && hasFilter(ref.filters, "default")) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func hasFilter(filters []string, name string) bool {
+	for _, f := range filters {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// write writes template source to the residual template.
+func (p *partialState) write(w *trimWriter, source string) {
+	p.written++
+	_, _ = w.Write([]byte(source))
+}
+
+// writeOutput writes the output of a known object or tag to the residual template.
+// Output that contains Liquid delimiters is written so that it isn't rendered again.
+func (p *partialState) writeOutput(w *trimWriter, render func(io.Writer) error) error {
+	buf := new(bytes.Buffer)
+	if err := render(buf); err != nil {
+		return err
+	}
+	out := buf.String()
+	if raw := rawSource(out); raw != out {
+		p.raw++
+		out = raw
+	}
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+// writeResidual writes a residual tag or object to the residual template.
+func (p *partialState) writeResidual(w *trimWriter, ctx nodeContext, tok parser.Token, tag string) error {
+	source, err := p.residualSource(ctx, tok, tag)
+	if err != nil {
+		return err
+	}
+	p.write(w, source)
+	return nil
+}
+
+// residualSource returns the source of a residual tag or object, with residualArgs.
+func (p *partialState) residualSource(ctx nodeContext, tok parser.Token, tag string) (string, error) {
+	args, err := p.residualArgs(ctx, tag, tok.Args)
+	if err != nil || args == tok.Args {
+		return tok.Source, err
+	}
+	i := strings.LastIndex(tok.Source, tok.Args)
+	return tok.Source[:i] + args + tok.Source[i+len(tok.Args):], nil
+}
+
+// residualArgs returns the arguments of a residual tag, or the expression of an object,
+// with the known values that it uses written in: each part of its expressions whose
+// variables are all known is replaced by its value, if that has a literal. The known
+// value at the head of a chain of filters is written with parse_json if it doesn't.
+//
+// The residual template doesn't define the variables of unrolled loops, so a use of one
+// that is left fails the loop. It doesn't define the known paths or the unwritten locals
+// either, so a use of one of those that is left is an error: the residual template would
+// render differently.
+func (p *partialState) residualArgs(ctx nodeContext, tag, args string) (string, error) {
+	trees, ok := argTrees(tag, args)
+	if !ok {
+		return args, nil
+	}
+	filtered := tag == "object" || tag == "assign" || tag == "for" || tag == "tablerow"
+	out := args
+	for i := len(trees) - 1; i >= 0; i-- {
+		tree := trees[i]
+		rewritten := expressions.Rewrite(tree, func(n expressions.Node) expressions.Node {
+			if value, ok := p.evaluate(ctx, n); ok && hasLiteral(value) {
+				return &expressions.Literal{Value: value}
+			}
+			return n
+		})
+		if filtered {
+			rewritten = p.rewriteHead(ctx, rewritten)
+		}
+		for _, ref := range references(rewritten) {
+			name := rootName(ref.path)
+			if loop := p.unrolled[name]; loop != nil {
+				loop.failed = true
+				continue
+			}
+			if _, local := p.locals[name]; p.knownPath(ref.path) && (!local || p.unwritten[name]) {
+				return "", fmt.Errorf("the value of %s can't be written to the residual template", ref.path)
+			}
+		}
+		span := tree.Position()
+		out = out[:span.Start] + expressions.FormatRewrite(args, tree, rewritten) + out[span.End:]
+	}
+	return out, nil
+}
+
+// rewriteHead replaces the known value at the head of a chain of filters with an
+// expression that evaluates to it.
+func (p *partialState) rewriteHead(ctx nodeContext, n expressions.Node) expressions.Node {
+	if f, ok := n.(*expressions.Filter); ok {
+		c := *f
+		c.Input = p.rewriteHead(ctx, f.Input)
+		return &c
+	}
+	if value, ok := p.evaluate(ctx, n); ok {
+		if v, ok := valueNode(value); ok {
+			return v
+		}
+	}
+	return n
+}
+
+// evaluate returns the value of an expression tree whose variables are all known, and
+// that uses a variable that the residual template doesn't define: a known path, an
+// unwritten local, or a variable of an unrolled loop. The other known assignments are
+// written to the residual template, so the expressions that only use them are left as
+// they are.
+func (p *partialState) evaluate(ctx nodeContext, n expressions.Node) (interface{}, bool) {
+	if _, ok := n.(*expressions.Literal); ok || !p.knownExpr(false, n) {
+		return nil, false
+	}
+	for _, ref := range references(n) {
+		name := rootName(ref.path)
+		if _, local := p.locals[name]; !local || p.unwritten[name] || p.unrolled[name] != nil {
+			value, err := ctx.Evaluate(expressions.Compile(n))
+			return value, err == nil
+		}
+	}
+	return nil, false
+}
+
+// unroll sets the loop that defines variables, and returns a function that restores the
+// loops that defined them before.
+func (p *partialState) unroll(loop *partialLoop, names ...string) func() {
+	saved := make(map[string]*partialLoop, len(names))
+	for _, name := range names {
+		if l, ok := p.unrolled[name]; ok {
+			saved[name] = l
+		}
+		p.unrolled[name] = loop
+	}
+	return func() {
+		for _, name := range names {
+			if l, ok := saved[name]; ok {
+				p.unrolled[name] = l
+			} else {
+				delete(p.unrolled, name)
+			}
+		}
+	}
+}
+
+// setLocals sets whether variables are known, and returns a function that restores them.
+func (p *partialState) setLocals(known bool, names ...string) func() {
+	saved := make(map[string]bool, len(names))
+	for _, name := range names {
+		if v, ok := p.locals[name]; ok {
+			saved[name] = v
+		}
+		p.locals[name] = known
+	}
+	return func() {
+		for _, name := range names {
+			if v, ok := saved[name]; ok {
+				p.locals[name] = v
+			} else {
+				delete(p.locals, name)
+			}
+		}
+	}
+}
+
+// renderTagPartial partially renders a tag. It returns false if the tag is rendered
+// as usual.
+func (n *TagNode) renderTagPartial(w *trimWriter, ctx nodeContext) (bool, Error) {
+	p := ctx.partial
+	if n.Name == "assign" {
+		stmt, err := expressions.ParseStatement(expressions.AssignStatementSelector, n.Args)
+		if err != nil {
+			p.write(w, n.Source)
+			return true, nil
+		}
+		name := stmt.Assignment.Variable
+		delete(p.unwritten, name)
+		if !p.knownExpr(false, stmt.Assignment.Value) {
+			p.locals[name] = false
+			return true, wrapRenderError(p.writeResidual(w, ctx, n.Token, n.Name), n)
+		}
+		if err := n.renderer(w, rendererContext{ctx, n, nil}); err != nil {
+			return true, wrapRenderError(err, n)
+		}
+		// The residual template assigns the value too, for the expressions that are left.
+		// An assignment in a residual block is only known when that's rendered. A value
+		// that can't be written stays known, for the expressions that only use known
+		// values; its assignment is left out.
+		p.locals[name] = p.residual == 0
+		if v, ok := valueNode(ctx.bindings[name]); ok {
+			p.write(w, retag(n.Token, "assign", name+" = "+expressions.Format(v)))
+		} else if p.residual > 0 {
+			return true, renderErrorf(n, "the value of %s can't be written to the residual template", name)
+		} else {
+			p.unwritten[name] = true
+			w.TrimLeft(n.TrimLeft)
+			w.TrimRight(n.TrimRight)
+		}
+		return true, nil
+	}
+	if loopControlTags[n.Name] && p.residual > 0 && (p.loop == nil || p.residual > p.loop.residual) {
+		// A loop control tag in a residual part of an unrolled loop would be outside a
+		// loop in the residual template.
+		if p.loop != nil {
+			p.loop.failed = true
+		}
+		p.write(w, n.Source)
+		return true, nil
+	}
+	if !p.knownArgs(n.Name, n.Args) {
+		return true, wrapRenderError(p.writeResidual(w, ctx, n.Token, n.Name), n)
+	}
+	return false, nil
+}
+
+// renderPartial partially renders a block. A block whose expressions are known is
+// rendered as usual; its body is rendered partially. The others are written with their
+// own start, clause and end tags, and their bodies rendered partially.
+func (n *BlockNode) renderPartial(w *trimWriter, ctx nodeContext) error {
+	p := ctx.partial
+	switch n.Name {
+	case "if", "unless":
+		return n.renderIfPartial(w, ctx)
+	case "case":
+		for _, c := range n.Clauses {
+			if !p.knownArgs(c.Name, c.Args) {
+				return n.renderResidual(w, ctx)
+			}
+		}
+	case "for", "tablerow":
+		stmt, err := expressions.ParseStatement(expressions.LoopStatementSelector, n.Args)
+		if err != nil {
+			break
+		}
+		names := append([]string{stmt.Loop.Variable}, loopVariables...)
+		if !p.knownExpr(false, stmt.Loop.Collection) {
+			return n.renderResidual(w, ctx, names...)
+		}
+		return n.renderLoopPartial(w, ctx, names)
+	case "capture":
+		return n.renderCapturePartial(w, ctx)
+	}
+	if !p.knownArgs(n.Name, n.Args) {
+		return n.renderResidual(w, ctx)
+	}
+	return n.renderer(w, rendererContext{ctx, nil, n})
+}
+
+// renderIfPartial partially renders an if or unless block. Branches whose conditions are
+// known to be false are removed. A branch whose condition is known to be true is
+// rendered in place of the block, or if it follows a residual branch, becomes its else.
+func (n *BlockNode) renderIfPartial(w *trimWriter, ctx nodeContext) error {
+	p := ctx.partial
+	open := false // a residual block has been written
+	for i, b := range append([]*BlockNode{n}, n.Clauses...) {
+		known := b.Name == "else" || p.knownArgs(b.Name, b.Args)
+		if !known {
+			if open || i == 0 {
+				if err := p.writeResidual(w, ctx, b.Token, b.Name); err != nil {
+					return err
+				}
+			} else {
+				args, err := p.residualArgs(ctx, b.Name, b.Args)
+				if err != nil {
+					return err
+				}
+				p.write(w, retag(b.Token, "if", args))
+			}
+			open = true
+			if err := ctx.renderResidualNodes(w, b.Body); err != nil {
+				return err
+			}
+			continue
+		}
+		if b.Name != "else" {
+			expr, err := expressions.Parse(b.Args)
+			if err != nil {
+				return err
+			}
+			if i == 0 && n.Name == "unless" {
+				expr = expressions.Not(expr)
+			}
+			value, err := ctx.Evaluate(expr)
+			if err != nil {
+				return err
+			}
+			if value == nil || value == false {
+				continue
+			}
+		}
+		if !open {
+			return ctx.renderNodes(w, b.Body)
+		}
+		if b.Name == "else" {
+			p.write(w, b.Source)
+		} else {
+			p.write(w, retag(b.Token, "else", ""))
+		}
+		if err := ctx.renderResidualNodes(w, b.Body); err != nil {
+			return err
+		}
+		break
+	}
+	if open {
+		p.write(w, n.endSource())
+	}
+	return nil
+}
+
+// renderLoopPartial unrolls a loop over a known value. The loop is written as a residual
+// block instead if its unrolled body can't be written.
+func (n *BlockNode) renderLoopPartial(w *trimWriter, ctx nodeContext, names []string) error {
+	p := ctx.partial
+	loop := &partialLoop{residual: p.residual}
+	saved := p.loop
+	p.loop = loop
+	restoreLocals, restoreLoops := p.setLocals(true, names...), p.unroll(loop, names...)
+	buf := new(bytes.Buffer)
+	err := n.renderer(buf, rendererContext{ctx, nil, n})
+	p.loop = saved
+	restoreLocals()
+	restoreLoops()
+	switch {
+	case err != nil:
+		return err
+	case loop.failed:
+		return n.renderResidual(w, ctx, names...)
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// renderCapturePartial captures the partially rendered body of a capture block. The
+// residual template captures it too, for the expressions that are left.
+func (n *BlockNode) renderCapturePartial(w *trimWriter, ctx nodeContext) error {
+	p := ctx.partial
+	name := strings.TrimSpace(n.Args)
+	written, raw := p.written, p.raw
+	buf := new(bytes.Buffer)
+	tw := trimWriter{w: buf}
+	if err := ctx.renderNodes(&tw, n.Body); err != nil {
+		return err
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	known := p.written == written && p.residual == 0
+	p.locals[name] = known
+	delete(p.unwritten, name)
+	value := buf.String()
+	if known && p.raw != raw {
+		// The body is known, but output in it is written as raw text, which isn't its value.
+		plain := ctx
+		plain.partial = nil
+		out := new(bytes.Buffer)
+		if err := plain.RenderSequence(out, n.Body); err != nil {
+			return err
+		}
+		value = out.String()
+	}
+	if ctx.config.AutoEscape {
+		ctx.bindings[name] = values.SafeHTML(value)
+	} else {
+		ctx.bindings[name] = value
+	}
+	p.write(w, n.Source+buf.String()+n.endSource())
+	return nil
+}
+
+// renderResidual writes a block to the residual template. The variables of a residual
+// loop, names, aren't known in its body, and the loop control tags there belong to it.
+func (n *BlockNode) renderResidual(w *trimWriter, ctx nodeContext, names ...string) error {
+	p := ctx.partial
+	if err := p.writeResidual(w, ctx, n.Token, n.Name); err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		defer p.setLocals(false, names...)()
+		defer p.unroll(nil, names...)()
+		defer func(loop *partialLoop) { p.loop = loop }(p.loop)
+		p.loop = nil
+	}
+	if err := ctx.renderResidualNodes(w, n.Body); err != nil {
+		return err
+	}
+	for _, c := range n.Clauses {
+		if err := p.writeResidual(w, ctx, c.Token, c.Name); err != nil {
+			return err
+		}
+		if err := ctx.renderResidualNodes(w, c.Body); err != nil {
+			return err
+		}
+	}
+	p.write(w, n.endSource())
+	return nil
+}
+
+// endSource returns the source of the end tag of a block.
+func (n *BlockNode) endSource() string {
+	if n.End.Source != "" {
+		return n.End.Source
+	}
+	tok := n.Token
+	tok.TrimLeft, tok.TrimRight = false, false
+	return retag(tok, "end"+n.Name, "")
+}
+
+func (c nodeContext) renderNodes(w *trimWriter, nodes []Node) Error {
+	for _, n := range nodes {
+		if err := n.render(w, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderResidualNodes renders the body of a residual block.
+func (c nodeContext) renderResidualNodes(w *trimWriter, nodes []Node) Error {
+	c.partial.residual++
+	defer func() { c.partial.residual-- }()
+	return c.renderNodes(w, nodes)
+}
+
+// retag returns the source of a tag with the delimiters and trim flags of a token.
+func retag(tok parser.Token, name, args string) string {
+	start, end := tok.Source[:2], tok.Source[len(tok.Source)-2:]
+	if tok.TrimLeft {
+		start += "-"
+	}
+	if tok.TrimRight {
+		end = "-" + end
+	}
+	if args != "" {
+		name += " " + strings.TrimSpace(args)
+	}
+	return start + " " + name + " " + end
+}
+
+// rootName returns the variable of a path.
+func rootName(path string) string {
+	if i := strings.IndexByte(path, '.'); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+// valueNode returns an expression tree that evaluates to a value: a literal, or the JSON
+// of the value parsed by the parse_json filter. Values that aren't made of nil, booleans,
+// numbers, strings, arrays and maps, such as times, have none.
+func valueNode(value interface{}) (expressions.Node, bool) {
+	if hasLiteral(value) {
+		return &expressions.Literal{Value: value}, true
+	}
+	if s, ok := jsonSource(value); ok {
+		return &expressions.Filter{Input: &expressions.Literal{Value: s}, Name: "parse_json"}, true
+	}
+	return nil, false
+}
+
+// hasLiteral returns true if a value can be written as a Liquid literal in a tag or an
+// object. A string that contains both kinds of quote, or Liquid delimiters, can't.
+func hasLiteral(value interface{}) bool {
+	switch v := value.(type) {
+	case nil, bool, int, int64:
+		return true
+	case float64:
+		return !math.IsInf(v, 0) && !math.IsNaN(v)
+	case string:
+		if strings.Contains(v, "'") && strings.Contains(v, `"`) {
+			return false
+		}
+		for _, delim := range []string{"{{", "}}", "{%", "%}"} {
+			if strings.Contains(v, delim) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// jsonSource returns the JSON of a value, to be written in a single-quoted Liquid string
+// in a tag or an object: the quotes, braces and percent signs in its strings are escaped,
+// and the braces that close nested objects are separated.
+func jsonSource(value interface{}) (string, bool) {
+	if !jsonable(reflect.ValueOf(value)) {
+		return "", false
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	var out strings.Builder
+	inString, escaped := false, false
+	for i, c := range string(b) {
+		switch {
+		case inString && escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString && strings.ContainsRune(`'{}%`, c):
+			fmt.Fprintf(&out, `\u%04x`, c)
+			continue
+		case c == '}' && i > 0 && b[i-1] == '}':
+			out.WriteByte(' ')
+		}
+		out.WriteRune(c)
+	}
+	return out.String(), true
+}
+
+// jsonable returns true if a value is made of nil, booleans, numbers, strings, arrays and
+// maps with string keys, which parse_json parses back into the values they're made of.
+func jsonable(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid, reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Float32, reflect.Float64:
+		return !math.IsInf(v.Float(), 0) && !math.IsNaN(v.Float())
+	case reflect.Interface:
+		return v.IsNil() || jsonable(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return false // JSON writes bytes as base64
+		}
+		for i := 0; i < v.Len(); i++ {
+			if !jsonable(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return false
+		}
+		for _, k := range v.MapKeys() {
+			if !jsonable(v.MapIndex(k)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// rawSource returns the source of a residual template that writes text. Text that contains
+// Liquid delimiters is wrapped in a raw block. If it would end the raw block, or a tag or
+// object in it would run past the end, each { in it is written by an object instead.
+func rawSource(text string) string {
+	if !strings.Contains(text, "{{") && !strings.Contains(text, "{%") {
+		return text
+	}
+	raw := "{% raw %}" + text + "{% endraw %}"
+	tokens := parser.Scan(raw, parser.SourceLoc{}, nil)
+	ends := 0
+	for _, tok := range tokens {
+		if tok.Type == parser.TagTokenType && tok.Name == "endraw" {
+			ends++
+		}
+	}
+	if last := tokens[len(tokens)-1]; ends == 1 && last.Source == "{% endraw %}" {
+		return raw
+	}
+	return strings.ReplaceAll(text, "{", "{{ '{' }}")
+}
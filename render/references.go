@@ -0,0 +1,137 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/autopilot3/liquid/expressions"
+)
+
+// A reference is a use of a variable in an expression tree.
+type reference struct {
+	// path is the variable and its properties, such as "order.lines" for order.lines[0].sku.
+	// Indexing ends the path.
+	path string
+	// offset is the byte offset of the reference in the source of the tag arguments.
+	offset int
+	// filters are the names of the filters that are applied to the value the reference is
+	// the receiver of, in order.
+	filters []string
+	// operator is the comparison that the reference is the left operand of, such as "=="
+	// or "contains", and operand is its right operand. A comparison of two references is
+	// recorded on the left one; a literal on the left is swapped to the right, as in
+	// a < 10 for 10 > a.
+	operator string
+	operand  *Operand
+}
+
+// swappedOperators are the operators of comparisons with swapped operands.
+var swappedOperators = map[string]string{
+	"==": "==",
+	"!=": "!=",
+	">=": "<=",
+	"<=": ">=",
+	"<":  ">",
+	">":  "<",
+}
+
+// argTrees parses the arguments of a tag or clause into the expression trees that they
+// evaluate. It returns false if they aren't expressions. Tags without arguments, and
+// cycle tags, whose values are literals, have none.
+func argTrees(tag, args string) ([]expressions.Node, bool) {
+	switch {
+	case tag == "cycle" || strings.TrimSpace(args) == "":
+		return nil, true
+	case tag == "when":
+		stmt, err := expressions.ParseStatement(expressions.WhenStatementSelector, args)
+		if err != nil {
+			return nil, false
+		}
+		return stmt.When.Values, true
+	case tag == "for" || tag == "tablerow":
+		stmt, err := expressions.ParseStatement(expressions.LoopStatementSelector, args)
+		if err != nil {
+			return nil, false
+		}
+		return []expressions.Node{stmt.Loop.Collection}, true
+	case tag == "assign":
+		stmt, err := expressions.ParseStatement(expressions.AssignStatementSelector, args)
+		if err != nil {
+			return nil, false
+		}
+		return []expressions.Node{stmt.Assignment.Value}, true
+	}
+	tree, err := expressions.ParseTree(args)
+	if err != nil {
+		return nil, false
+	}
+	return []expressions.Node{tree}, true
+}
+
+// references returns the variable references of expression trees, in source order.
+func references(trees ...expressions.Node) []reference {
+	var refs []reference
+	var visit func(n expressions.Node, filters []string)
+	visit = func(n expressions.Node, filters []string) {
+		if path, ok := expressions.Path(n); ok {
+			refs = append(refs, reference{path: path, offset: n.Position().Start, filters: filters})
+			return
+		}
+		switch n := n.(type) {
+		case *expressions.Property:
+			visit(n.Object, filters)
+		case *expressions.Index:
+			visit(n.Sequence, filters)
+			visit(n.Index, nil)
+		case *expressions.Filter:
+			// The filters of a chain are applied innermost first.
+			visit(n.Input, append([]string{n.Name}, filters...))
+			for _, arg := range n.Args {
+				visit(arg, nil)
+			}
+			for _, arg := range n.Named {
+				visit(arg.Value, nil)
+			}
+		case *expressions.Comparison:
+			left := len(refs)
+			visit(n.Left, nil)
+			right := len(refs)
+			visit(n.Right, nil)
+			compare(refs[left:], right-left, n)
+		case *expressions.Logical:
+			visit(n.Left, nil)
+			visit(n.Right, nil)
+		case *expressions.Range:
+			visit(n.Start, nil)
+			visit(n.End, nil)
+		}
+	}
+	for _, tree := range trees {
+		visit(tree, nil)
+	}
+	return refs
+}
+
+// compare records a comparison on the references of its operands; right is the index in
+// refs of the references of its right operand.
+func compare(refs []reference, right int, n *expressions.Comparison) {
+	operand := func(n expressions.Node) *Operand {
+		if path, ok := expressions.Path(n); ok {
+			return &Operand{Path: path}
+		}
+		if lit, ok := n.(*expressions.Literal); ok {
+			return &Operand{Literal: lit.Value}
+		}
+		return nil
+	}
+	_, leftPath := expressions.Path(n.Left)
+	_, rightPath := expressions.Path(n.Right)
+	_, leftLiteral := n.Left.(*expressions.Literal)
+	switch {
+	case leftPath:
+		if o := operand(n.Right); o != nil {
+			refs[0].operator, refs[0].operand = n.Op, o
+		}
+	case leftLiteral && rightPath && swappedOperators[n.Op] != "":
+		refs[right].operator, refs[right].operand = swappedOperators[n.Op], operand(n.Left)
+	}
+}
@@ -0,0 +1,59 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/autopilot3/liquid/expressions"
+	"github.com/stretchr/testify/require"
+)
+
+var referenceTests = []struct {
+	in       string
+	expected []reference
+}{
+	{`a`, []reference{{path: "a"}}},
+	{`a.b.c`, []reference{{path: "a.b.c"}}},
+	{`a.b[0].c`, []reference{{path: "a.b"}}},
+	{`a[b.c]`, []reference{{path: "a"}, {path: "b.c", offset: 2}}},
+	{`a | f: b | g: x: c`, []reference{
+		{path: "a", filters: []string{"f", "g"}},
+		{path: "b", offset: 7},
+		{path: "c", offset: 17},
+	}},
+	{`a == b`, []reference{{path: "a", operator: "==", operand: &Operand{Path: "b"}}, {path: "b", offset: 5}}},
+	{`a > 1 and 2 > b`, []reference{
+		{path: "a", operator: ">", operand: &Operand{Literal: 1}},
+		{path: "b", offset: 14, operator: "<", operand: &Operand{Literal: 2}},
+	}},
+	{`'x' contains a`, []reference{{path: "a", offset: 13}}},
+	{`a contains 'x'`, []reference{{path: "a", operator: "contains", operand: &Operand{Literal: "x"}}}},
+	{`a.b[0] > 1`, []reference{{path: "a.b"}}},
+}
+
+func TestReferences(t *testing.T) {
+	for i, test := range referenceTests {
+		t.Run(test.in, func(t *testing.T) {
+			tree, err := expressions.ParseTree(test.in)
+			require.NoErrorf(t, err, test.in)
+			require.Equalf(t, test.expected, references(tree), "%d: %s", i, test.in)
+		})
+	}
+}
+
+func TestArgTrees(t *testing.T) {
+	trees, ok := argTrees("when", `'a', b`)
+	require.True(t, ok)
+	require.Len(t, trees, 2)
+	require.Equal(t, []reference{{path: "b", offset: 5}}, references(trees...))
+
+	trees, ok = argTrees("for", `x in a.b reversed`)
+	require.True(t, ok)
+	require.Equal(t, []reference{{path: "a.b", offset: 5}}, references(trees...))
+
+	trees, ok = argTrees("cycle", `'a', 'b'`)
+	require.True(t, ok)
+	require.Empty(t, trees)
+
+	_, ok = argTrees("if", `a ==`)
+	require.False(t, ok)
+}
@@ -8,7 +8,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/autopilot3/liquid/expressions"
 	"github.com/autopilot3/liquid/values"
 )
 
@@ -24,28 +23,6 @@ func Render(node Node, w io.Writer, vars map[string]interface{}, c Config) Error
 	return nil
 }
 
-func FindVariables(node Node, c Config) (map[string]interface{}, Error) {
-	tw := trimWriter{w: io.Discard}
-	ctx := newFindVariablesNodeContext(c)
-	if err := node.render(&tw, ctx); err != nil {
-		return nil, err
-	}
-	for k, v := range ctx.bindings {
-		if v == nil {
-			delete(ctx.bindings, k)
-		}
-	}
-	delete(ctx.bindings, expressions.LatestVarNameKey)
-	delete(ctx.bindings, expressions.LoopVarsKey)
-	if assignedVars, ok := ctx.bindings[expressions.AssignedVarsKey]; ok {
-		for name := range assignedVars.(map[string]struct{}) {
-			delete(ctx.bindings, name)
-		}
-		delete(ctx.bindings, expressions.AssignedVarsKey)
-	}
-	return ctx.bindings, nil
-}
-
 // RenderASTSequence renders a sequence of nodes.
 func (c nodeContext) RenderSequence(w io.Writer, seq []Node) Error {
 	tw := trimWriter{w: w}
@@ -70,71 +47,55 @@ func (n *BlockNode) render(w *trimWriter, ctx nodeContext) Error {
 	if renderer == nil {
 		panic(fmt.Errorf("unset renderer for %v", n))
 	}
-	err := renderer(w, rendererContext{ctx, nil, n})
-
-	if len(ctx.config.AllowedTags) > 0 {
-		end, ok := ctx.config.findBlockDef("end" + n.Name)
-		if ok {
-			w.Write([]byte("{% " + end.TagName() + " %}"))
-		}
+	if ctx.partial != nil {
+		return wrapRenderError(n.renderPartial(w, ctx), n)
 	}
+	err := renderer(w, rendererContext{ctx, nil, n})
 	return wrapRenderError(err, n)
 }
 
 func (n *RawNode) render(w *trimWriter, ctx nodeContext) Error {
+	if ctx.partial != nil {
+		// the residual template keeps the raw text raw
+		ctx.partial.write(w, "{% raw %}"+strings.Join(n.slices, "")+"{% endraw %}")
+		return nil
+	}
 	for _, s := range n.slices {
 		_, err := io.WriteString(w, s)
 		if err != nil {
 			return wrapRenderError(err, n)
 		}
+		if ctx.html != nil {
+			_, _ = io.WriteString(ctx.html, s)
+		}
 	}
 	return nil
 }
 
 func (n *ObjectNode) render(w *trimWriter, ctx nodeContext) Error {
-	var (
-		value interface{}
-		err   error
-	)
-	if len(ctx.config.AllowedTags) != 0 {
-		allowed := false
-		if ctx.config.AllowTagsWithDefault && strings.Contains(n.Source, "default:") {
-			allowed = true
-		}
-		if !allowed {
-			for tag := range ctx.config.AllowedTags {
-				if strings.Contains(n.Source, tag) {
-					allowed = true
-					break
-				}
-			}
-		}
-		if allowed {
-			w.TrimLeft(n.TrimLeft)
-			value, err = ctx.Evaluate(n.expr)
-			if err != nil {
-				return wrapRenderError(err, n)
-			}
-			if err := wrapRenderError(writeObject(w, value), n); err != nil {
-				return err
-			}
-			w.TrimRight(n.TrimRight)
-		} else {
-			w.TrimLeft(n.TrimLeft)
-			writeObject(w, n.Source)
-			w.TrimRight(n.TrimRight)
+	if ctx.partial != nil && !ctx.partial.knownArgs("object", n.Args) {
+		return wrapRenderError(ctx.partial.writeResidual(w, ctx, n.Token, "object"), n)
+	}
+	w.TrimLeft(n.TrimLeft)
+	value, err := ctx.Evaluate(n.expr)
+	if err != nil {
+		return wrapRenderError(err, n)
+	}
+	write := func(w io.Writer) error {
+		if ctx.html != nil {
+			return writeEscapedObject(w, value, ctx.html)
 		}
+		return writeObject(w, value)
+	}
+	if ctx.partial != nil {
+		err = ctx.partial.writeOutput(w, write)
 	} else {
-		w.TrimLeft(n.TrimLeft)
-		value, err = ctx.Evaluate(n.expr)
-		if err != nil {
-			return wrapRenderError(err, n)
-		}
-		if err := wrapRenderError(writeObject(w, value), n); err != nil {
-			return err
-		}
-		w.TrimRight(n.TrimRight)
+		err = write(w)
+	}
+	if err := wrapRenderError(err, n); err != nil {
+		return err
 	}
+	w.TrimRight(n.TrimRight)
 	return nil
 }
 
@@ -148,17 +109,43 @@ func (n *SeqNode) render(w *trimWriter, ctx nodeContext) Error {
 }
 
 func (n *TagNode) render(w *trimWriter, ctx nodeContext) Error {
+	if ctx.partial != nil {
+		if done, err := n.renderTagPartial(w, ctx); done {
+			return err
+		}
+	}
 	w.TrimLeft(n.TrimLeft)
-	err := wrapRenderError(n.renderer(w, rendererContext{ctx, n, nil}), n)
+	render := func(w io.Writer) error { return n.renderer(w, rendererContext{ctx, n, nil}) }
+	var err error
+	if ctx.partial != nil {
+		err = ctx.partial.writeOutput(w, render)
+	} else {
+		err = render(w)
+	}
 	w.TrimRight(n.TrimRight)
-	return err
+	return wrapRenderError(err, n)
 }
 
 func (n *TextNode) render(w *trimWriter, ctx nodeContext) Error {
 	_, err := io.WriteString(w, n.Source)
+	if ctx.html != nil {
+		_, _ = io.WriteString(ctx.html, n.Source)
+	}
 	return wrapRenderError(err, n)
 }
 
+// writeEscapedObject writes a value used in an object node, escaped for the
+// HTML context that it lands in.
+func writeEscapedObject(w io.Writer, value interface{}, html *htmlContext) error {
+	s, err := html.escape(value)
+	if err != nil {
+		return err
+	}
+	_, _ = io.WriteString(html, s)
+	_, err = io.WriteString(w, s)
+	return err
+}
+
 // writeObject writes a value used in an object node
 func writeObject(w io.Writer, value interface{}) error {
 	value = values.ToLiquid(value)
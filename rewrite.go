@@ -0,0 +1,238 @@
+package liquid
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/autopilot3/liquid/expressions"
+)
+
+// An Edit changes the expressions of a template, for Engine.RewriteSource. It's called
+// for each node of each expression tree, children first as by expressions.Rewrite, and
+// returns the node or its replacement. The nodes that it creates should have empty spans,
+// unless they replace the node with the same span. It isn't called for the variables
+// that are local to the template, nor for their properties and indexes.
+type Edit func(Expr) Expr
+
+// RenameVariable returns an Edit that renames a variable path, such as contact.custom.x,
+// and the paths that it's a prefix of. Renaming contact.custom to contact.fields renames
+// contact.custom.x to contact.fields.x, but not contact.customer. An index by a string
+// is a property, so that contact['custom'].x is renamed too. The paths of variables
+// that are assigned from, or iterate over, the path aren't renamed.
+func RenameVariable(from, to string) Edit {
+	return func(n Expr) Expr {
+		if path, _ := propertyPath(n); path == from {
+			return pathExpr(to)
+		}
+		return n
+	}
+}
+
+// propertyPath returns the path of a variable and its properties, in which an index by a
+// string literal, as in contact['custom'], is a property.
+func propertyPath(n Expr) (string, bool) {
+	switch n := n.(type) {
+	case *expressions.Variable:
+		return n.Name, true
+	case *expressions.Property:
+		if p, ok := propertyPath(n.Object); ok {
+			return p + "." + n.Name, true
+		}
+	case *expressions.Index:
+		lit, ok := n.Index.(*expressions.Literal)
+		if !ok {
+			break
+		}
+		if name, ok := lit.Value.(string); ok {
+			if p, ok := propertyPath(n.Sequence); ok {
+				return p + "." + name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// pathExpr returns the tree of a variable path.
+func pathExpr(path string) Expr {
+	names := strings.Split(path, ".")
+	var n Expr = &expressions.Variable{Name: names[0]}
+	for _, name := range names[1:] {
+		n = &expressions.Property{Object: n, Name: name}
+	}
+	return n
+}
+
+// ReplaceFilter returns an Edit that replaces a filter with another. order lists the
+// positional arguments of the replacement, as indexes of the arguments of the filter,
+// so that order 1, 0 swaps two arguments. Without an order, the arguments are kept.
+// Named arguments are kept.
+func ReplaceFilter(name, replacement string, order ...int) Edit {
+	return func(n Expr) Expr {
+		f, ok := n.(*expressions.Filter)
+		if !ok || f.Name != name {
+			return n
+		}
+		c := *f
+		c.Name = replacement
+		if order != nil {
+			c.Args = nil
+			for _, i := range order {
+				if i >= 0 && i < len(f.Args) {
+					c.Args = append(c.Args, f.Args[i])
+				}
+			}
+		}
+		return &c
+	}
+}
+
+// ChangeLiteralArg returns an Edit that changes a literal positional argument of a
+// filter, counted from 0, to the value that fn returns for its value. Arguments that
+// aren't literals aren't changed.
+func ChangeLiteralArg(name string, arg int, fn func(interface{}) interface{}) Edit {
+	return func(n Expr) Expr {
+		f, ok := n.(*expressions.Filter)
+		if !ok || f.Name != name || arg >= len(f.Args) {
+			return n
+		}
+		lit, ok := f.Args[arg].(*expressions.Literal)
+		if !ok {
+			return n
+		}
+		c := *f
+		c.Args = append([]Expr(nil), f.Args...)
+		c.Args[arg] = &expressions.Literal{Span: lit.Span, Value: fn(lit.Value)}
+		return &c
+	}
+}
+
+// RewriteSource applies edits to the expressions of a template, and returns the
+// rewritten source. Only the changed parts of the expressions are rewritten: the rest of
+// the source, including text, raw blocks, comments, and the spacing of the expressions,
+// is kept byte for byte.
+//
+// The expressions are those of objects, and of the arguments of the if, unless, elsif,
+// case, when, assign, for and tablerow tags. The variables that are local to the template,
+// which are loop variables in their loops and variables after they're assigned or
+// captured, aren't edited, so that contact in {% for contact in list %} isn't renamed as
+// the contact variable.
+func (e *Engine) RewriteSource(source []byte, edits ...Edit) ([]byte, SourceError) {
+	t, err := e.ParseTemplate(source)
+	if err != nil {
+		return nil, err
+	}
+	src := string(source)
+	lines := lineOffsets(src)
+	var (
+		out strings.Builder
+		p   int // the offset in src up to which out has been written
+		// locals counts the scopes of the variables that are local to the template:
+		// loop variables in the bodies of their loops, and assigned and captured
+		// variables after their tags.
+		locals = map[string]int{}
+	)
+	rewrite := func(loc Location, tokSource, args string, exprs []Expr) {
+		start := lines[loc.Line-1]
+		for i := 1; i < loc.Column; i++ {
+			_, size := utf8.DecodeRuneInString(src[start:])
+			start += size
+		}
+		start += strings.LastIndex(tokSource, args)
+		for _, expr := range exprs {
+			text := expressions.FormatRewrite(args, expr, applyEdits(expr, edits, locals))
+			span := expr.Position()
+			if text == args[span.Start:span.End] {
+				continue
+			}
+			out.WriteString(src[p : start+span.Start])
+			out.WriteString(text)
+			p = start + span.End
+		}
+	}
+	var visit func(n Node)
+	visit = func(n Node) {
+		switch n := n.(type) {
+		case *ObjectNode:
+			if n.Expr != nil {
+				rewrite(n.Location, n.Source, n.Args, []Expr{n.Expr})
+			}
+		case *TagNode:
+			rewrite(n.Location, n.Source, n.Args, n.Exprs)
+			if n.Name == "assign" {
+				if stmt, err := expressions.ParseStatement(expressions.AssignStatementSelector, n.Args); err == nil {
+					locals[stmt.Assignment.Variable]++
+				}
+			}
+		case *BlockNode:
+			rewrite(n.Location, n.Source, n.Args, n.Exprs)
+			loopVar := ""
+			if n.Name == "for" || n.Name == "tablerow" {
+				if stmt, err := expressions.ParseStatement(expressions.LoopStatementSelector, n.Args); err == nil {
+					loopVar = stmt.Loop.Variable
+				}
+			}
+			if loopVar != "" {
+				locals[loopVar]++
+			}
+			for _, c := range n.Body {
+				visit(c)
+			}
+			if loopVar != "" {
+				locals[loopVar]--
+			}
+			for _, c := range n.Clauses {
+				visit(c)
+			}
+			if n.Name == "capture" {
+				locals[strings.TrimSpace(n.Args)]++
+			}
+		}
+	}
+	t.Walk(func(n Node) bool {
+		visit(n)
+		return false
+	})
+	out.WriteString(src[p:])
+	return []byte(out.String()), nil
+}
+
+// applyEdits applies edits to an expression tree, except to the local variables and
+// their properties and indexes.
+func applyEdits(expr Expr, edits []Edit, locals map[string]int) Expr {
+	return expressions.Rewrite(expr, func(n Expr) Expr {
+		if isLocal(n, locals) {
+			return n
+		}
+		for _, edit := range edits {
+			n = edit(n)
+		}
+		return n
+	})
+}
+
+// isLocal reports whether a node is a local variable, or a property or an index of one.
+func isLocal(n Expr, locals map[string]int) bool {
+	for {
+		switch e := n.(type) {
+		case *expressions.Variable:
+			return locals[e.Name] > 0
+		case *expressions.Property:
+			n = e.Object
+		case *expressions.Index:
+			n = e.Sequence
+		default:
+			return false
+		}
+	}
+}
+
+// lineOffsets returns the offsets of the starts of the lines of a source.
+func lineOffsets(source string) []int {
+	offsets := []int{0}
+	for i, c := range source {
+		if c == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
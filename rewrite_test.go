@@ -0,0 +1,57 @@
+package liquid
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var rewriteSourceTests = []struct {
+	in, expected string
+}{
+	{`{{contact.custom.x|upcase}} {{ contact.customer }}`, `{{contact.fields.x|upcase}} {{ contact.customer }}`},
+	{`{% raw %}{{ contact.custom.x }}{% endraw %}{{ 'contact.custom.x' }}`, `{% raw %}{{ contact.custom.x }}{% endraw %}{{ 'contact.custom.x' }}`},
+	{`{% comment %}{{ contact.custom.x }}{% endcomment %}`, `{% comment %}{{ contact.custom.x }}{% endcomment %}`},
+	{"{%- if contact.custom.x  ==  1 and contact.custom -%}\n{% elsif  x > contact.custom.y %}{% endif %}", "{%- if contact.fields.x  ==  1 and contact.fields -%}\n{% elsif  x > contact.fields.y %}{% endif %}"},
+	{`{% assign v = contact.custom.x | default: a[contact.custom] %}{% for i in contact.custom.list reversed %}{% endfor %}`, `{% assign v = contact.fields.x | default: a[contact.fields] %}{% for i in contact.fields.list reversed %}{% endfor %}`},
+	{`{% case contact.custom.x %}{% when 1, contact.custom.y %}{% endcase %}`, `{% case contact.fields.x %}{% when 1, contact.fields.y %}{% endcase %}`},
+	{"é {{ 'é' }}\n  {{  contact.custom.x  }}", "é {{ 'é' }}\n  {{  contact.fields.x  }}"},
+	{`{{ contact['custom'].x }}{{ contact.custom['x'] }}{{ contact[custom].x }}`, `{{ contact.fields.x }}{{ contact.fields['x'] }}{{ contact[custom].x }}`},
+	// Local variables
+	{`{% for contact in list %}{{ contact.custom.x }}{% endfor %}{{ contact.custom }}`, `{% for contact in list %}{{ contact.custom.x }}{% endfor %}{{ contact.fields }}`},
+	{`{{ contact.custom }}{% assign contact = x %}{{ contact.custom | upcase }}{{ a[contact.custom] }}`, `{{ contact.fields }}{% assign contact = x %}{{ contact.custom | upcase }}{{ a[contact.custom] }}`},
+	{`{% capture contact %}{{ contact.custom }}{% endcapture %}{{ contact.custom }}`, `{% capture contact %}{{ contact.fields }}{% endcapture %}{{ contact.custom }}`},
+	{`{% for c in contact.custom %}{{ c.x | decimal: '2', '$' }}{% endfor %}`, `{% for c in contact.fields %}{{ c.x | decimalWithDelimiter: '$', '2' }}{% endfor %}`},
+	// Filters
+	{`{{ n | decimal:'2' , '$' }}`, `{{ n | decimalWithDelimiter:'$' , '2' }}`},
+	{`{{ n | decimal: '2', '$' | append: 'x' }}`, `{{ n | decimalWithDelimiter: '$', '2' | append: 'x' }}`},
+	{`{{ d | date: '%Y' }}{{ d | date }}`, `{{ d | date: '%d/%m/%Y' }}{{ d | date }}`},
+	{`{{ d | date: format }}`, `{{ d | date: format }}`},
+}
+
+func TestEngine_RewriteSource(t *testing.T) {
+	engine := NewEngine()
+	edits := []Edit{
+		RenameVariable("contact.custom", "contact.fields"),
+		ReplaceFilter("decimal", "decimalWithDelimiter", 1, 0),
+		ChangeLiteralArg("date", 0, func(v interface{}) interface{} {
+			return strings.Replace(v.(string), "%Y", "%d/%m/%Y", 1)
+		}),
+	}
+	for _, test := range rewriteSourceTests {
+		t.Run(test.in, func(t *testing.T) {
+			out, err := engine.RewriteSource([]byte(test.in), edits...)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, string(out))
+		})
+	}
+
+	// Changing the number of arguments rewrites the argument list
+	out, err := engine.RewriteSource([]byte(`{{ n | decimal:'2','$' }}`), ReplaceFilter("decimal", "round", 0))
+	require.NoError(t, err)
+	require.Equal(t, `{{ n | round: '2' }}`, string(out))
+
+	_, err = engine.RewriteSource([]byte(`{% if %}`))
+	require.Error(t, err)
+}
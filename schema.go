@@ -0,0 +1,294 @@
+package liquid
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/autopilot3/liquid/expressions"
+)
+
+// SchemaDraft is the JSON Schema dialect of the schemas that BindingsSchema returns.
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// A Schema is a JSON Schema document, or a subschema of one. It has the keywords that
+// describe the shape of template bindings; it marshals to JSON with encoding/json.
+type Schema struct {
+	Schema     string             `json:"$schema,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Pattern    string             `json:"pattern,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+}
+
+// UnmarshalJSON unmarshals a JSON Schema. A type that is an array of types, such as
+// ["string", "null"], is read as its first type other than null.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type schema Schema
+	var v struct {
+		*schema
+		Type interface{} `json:"type,omitempty"`
+	}
+	v.schema = (*schema)(s)
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch t := v.Type.(type) {
+	case string:
+		s.Type = t
+	case []interface{}:
+		for _, name := range t {
+			if name, ok := name.(string); ok && name != "null" {
+				s.Type = name
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// filterTypeHints are the types of the values that filters are applied to.
+var filterTypeHints = map[string]func() *Schema{
+	"date":                    dateTimeSchema,
+	"dateTimeFormatOrDefault": dateTimeSchema,
+	"timeInTimezone":          dateTimeSchema,
+	"date_add":                dateTimeSchema,
+	"date_diff":               dateTimeSchema,
+	"start_of":                dateTimeSchema,
+	"end_of":                  dateTimeSchema,
+	"business_days_add":       dateTimeSchema,
+	"time_ago":                dateTimeSchema,
+	"time_until":              dateTimeSchema,
+	"dateFormatOrDefault":     func() *Schema { return &Schema{Type: "string", Format: "date"} },
+	"price":                   moneySchema,
+	"money":                   moneySchema,
+	"money_plus":              moneySchema,
+	"money_times":             moneySchema,
+	"money_round":             moneySchema,
+	"convert_currency":        moneySchema,
+	"abs":                     numberSchema,
+	"ceil":                    numberSchema,
+	"floor":                   numberSchema,
+	"round":                   numberSchema,
+	"plus":                    numberSchema,
+	"minus":                   numberSchema,
+	"times":                   numberSchema,
+	"divided_by":              numberSchema,
+	"modulo":                  numberSchema,
+	"at_least":                numberSchema,
+	"at_most":                 numberSchema,
+	"compactNumber":           numberSchema,
+	"percentage":              numberSchema,
+	"signedNumber":            numberSchema,
+	"signedPercentage":        numberSchema,
+	"significantDigits":       numberSchema,
+	"pluralize":               numberSchema,
+	"ordinal":                 numberSchema,
+	"join":                    arraySchema,
+	"sort":                    arraySchema,
+	"sort_natural":            arraySchema,
+	"uniq":                    arraySchema,
+	"compact":                 arraySchema,
+	"map":                     arraySchema,
+	"phone_format":            func() *Schema { return &Schema{Type: "string"} },
+}
+
+func dateTimeSchema() *Schema { return &Schema{Type: "string", Format: "date-time"} }
+func numberSchema() *Schema   { return &Schema{Type: "number"} }
+func arraySchema() *Schema    { return &Schema{Type: "array", Items: &Schema{}} }
+
+// moneySchema is the schema of the price maps, with an amount in thousandths.
+func moneySchema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"amount":   {Type: "integer"},
+			"currency": {Type: "string", Pattern: "^[A-Z]{3}$"},
+		},
+		Required: []string{"amount", "currency"},
+	}
+}
+
+// BindingsSchema returns a JSON Schema of the bindings that the template uses, for
+// validating the payloads that it's rendered with. The variables that FindVariables finds
+// are the properties of the schema; loops make arrays. A variable's type comes from the
+// first filter applied to it, such as a date-time for date and a price map for price.
+//
+// A variable is required if it's written or filtered without the default filter; the
+// properties that contain it are required too.
+func (t *Template) BindingsSchema() (*Schema, SourceError) {
+	vars, err := t.FindVariables()
+	if err != nil {
+		return nil, err
+	}
+	root := &Schema{Schema: SchemaDraft, Type: "object", Properties: map[string]*Schema{}}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if bind, ok := vars[name].(*expressions.VariableBind); ok {
+			addSchemaBind(root, schemaPath(name), bind)
+		}
+	}
+	for _, report := range t.AnalyzeVariables() {
+		s := root
+		for _, segment := range schemaPath(report.Path) {
+			if s = s.child(segment, false); s == nil {
+				break
+			}
+		}
+		if s == nil {
+			continue
+		}
+		for _, u := range report.Usages {
+			if len(u.Filters) > 0 {
+				if hint, ok := filterTypeHints[u.Filters[0]]; ok {
+					s.hint(hint())
+					break
+				}
+			}
+		}
+		if schemaRequired(report.Usages) {
+			root.require(schemaPath(report.Path))
+		}
+	}
+	return root, nil
+}
+
+// schemaPath splits a variable path into property names, with "[]" for the elements
+// of an array, as in matrix, [], [], v for matrix[][].v. The empty path, of the
+// elements of an array of arrays in a VariableBind, has none.
+func schemaPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	var segments []string
+	for _, s := range strings.Split(path, ".") {
+		name := strings.TrimRight(s, "[]")
+		segments = append(segments, name)
+		for i := len(name); i < len(s); i += 2 {
+			segments = append(segments, "[]")
+		}
+	}
+	return segments
+}
+
+func addSchemaBind(s *Schema, path []string, bind *expressions.VariableBind) {
+	for _, segment := range path {
+		if s = s.child(segment, true); s == nil {
+			return
+		}
+	}
+	if bind.Loop {
+		s.Type = "array"
+		if s.Items == nil {
+			s.Items = &Schema{}
+		}
+		s = s.Items
+	}
+	names := make([]string, 0, len(bind.Attributes))
+	for name := range bind.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		addSchemaBind(s, schemaPath(name), bind.Attributes[name])
+	}
+}
+
+// child returns the schema of a property, or with "[]", of the elements of an array.
+// The size property, and the first and last properties of an array, aren't properties
+// of the bindings. The schema is created if create is true.
+func (s *Schema) child(name string, create bool) *Schema {
+	switch {
+	case name == "size":
+		return nil
+	case name == "[]" || (s.Type == "array" && (name == "first" || name == "last")):
+		if s.Items == nil && create {
+			s.Type, s.Items = "array", &Schema{}
+		}
+		return s.Items
+	}
+	if c, ok := s.Properties[name]; ok || !create {
+		return c
+	}
+	if s.Type == "" {
+		s.Type = "object"
+	}
+	if s.Type != "object" {
+		return nil
+	}
+	if s.Properties == nil {
+		s.Properties = map[string]*Schema{}
+	}
+	c := &Schema{}
+	s.Properties[name] = c
+	return c
+}
+
+// hint sets the type of a schema that doesn't have one, and adds the properties of an
+// object type to an object.
+func (s *Schema) hint(h *Schema) {
+	switch s.Type {
+	case "":
+		*s = *h
+	case "object":
+		if h.Type != "object" {
+			return
+		}
+		for name, p := range h.Properties {
+			if _, ok := s.Properties[name]; !ok {
+				s.Properties[name] = p
+			}
+		}
+		for _, name := range h.Required {
+			s.addRequired(name)
+		}
+	}
+}
+
+// require marks a path, and the properties that contain it, as required.
+func (s *Schema) require(path []string) {
+	for _, segment := range path {
+		if segment != "[]" {
+			if _, ok := s.Properties[segment]; ok {
+				s.addRequired(segment)
+			}
+		}
+		if s = s.child(segment, false); s == nil {
+			return
+		}
+	}
+}
+
+func (s *Schema) addRequired(name string) {
+	i := sort.SearchStrings(s.Required, name)
+	if i < len(s.Required) && s.Required[i] == name {
+		return
+	}
+	s.Required = append(s.Required, "")
+	copy(s.Required[i+1:], s.Required[i:])
+	s.Required[i] = name
+}
+
+// schemaRequired returns true if a usage requires a value: it isn't a condition or the
+// source of a loop, which renders nothing without one, and it doesn't have a default.
+func schemaRequired(usages []VariableUsage) bool {
+	for _, u := range usages {
+		if u.Condition || u.Loop {
+			continue
+		}
+		hasDefault := false
+		for _, f := range u.Filters {
+			hasDefault = hasDefault || f == "default"
+		}
+		if !hasDefault {
+			return true
+		}
+	}
+	return false
+}
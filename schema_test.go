@@ -0,0 +1,90 @@
+package liquid
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate_BindingsSchema(t *testing.T) {
+	engine := NewEngine()
+	tpl, err := engine.ParseString(`Hi {{ contact.first_name | default: 'there' }},
+{% if contact.vip %}VIP{% endif %}
+Ordered {{ order.created | dateTimeFormatOrDefault: 'mdy12', '' }}
+{% for line in order.lines %}
+	{{ line.sku }} {{ line.quantity | times: 1 }} {{ line.total | price: 'currency_value', 'two' }}
+	{% for tag in line.tags %}{{ tag }}{% endfor %}
+{% endfor %}
+{{ order.lines.size }} items`)
+	require.NoError(t, err)
+
+	schema, err := tpl.BindingsSchema()
+	require.NoError(t, err)
+	bs, jerr := json.MarshalIndent(schema, "", "  ")
+	require.NoError(t, jerr)
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"contact": {
+				"type": "object",
+				"properties": {
+					"first_name": {},
+					"vip": {}
+				}
+			},
+			"order": {
+				"type": "object",
+				"properties": {
+					"created": {"type": "string", "format": "date-time"},
+					"lines": {
+						"type": "array",
+						"items": {
+							"type": "object",
+							"properties": {
+								"quantity": {"type": "number"},
+								"sku": {},
+								"tags": {"type": "array", "items": {}},
+								"total": {
+									"type": "object",
+									"properties": {
+										"amount": {"type": "integer"},
+										"currency": {"type": "string", "pattern": "^[A-Z]{3}$"}
+									},
+									"required": ["amount", "currency"]
+								}
+							},
+							"required": ["quantity", "sku", "tags", "total"]
+						}
+					}
+				},
+				"required": ["created", "lines"]
+			}
+		},
+		"required": ["order"]
+	}`, string(bs))
+}
+
+func TestTemplate_BindingsSchema_arrayOfArrays(t *testing.T) {
+	tpl, err := NewEngine().ParseString(`{% for m in matrix %}{% for c in m %}{{ c.v }}{% endfor %}{% endfor %}`)
+	require.NoError(t, err)
+	schema, err := tpl.BindingsSchema()
+	require.NoError(t, err)
+	bs, jerr := json.Marshal(schema)
+	require.NoError(t, jerr)
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"matrix": {
+				"type": "array",
+				"items": {
+					"type": "array",
+					"items": {"type": "object", "properties": {"v": {}}, "required": ["v"]}
+				}
+			}
+		},
+		"required": ["matrix"]
+	}`, string(bs))
+}
@@ -25,7 +25,7 @@ func (c exprCase) test(caseValue interface{}, ctx render.Context) (bool, error)
 		if err != nil {
 			return false, err
 		}
-		if values.Equal(caseValue, whenValue) {
+		if values.EqualContext(ctx.GetConfig().Context(), caseValue, whenValue) {
 			return true, nil
 		}
 	}
@@ -63,21 +63,12 @@ func caseTagCompiler(node render.BlockNode) (func(io.Writer, render.Context) err
 			return err
 		}
 
-		cfg := ctx.GetConfig()
-		if len(cfg.AllowedTags) > 0 && len(cases) > 0 {
-			return ctx.RenderBlock(w, cases[0].body())
-		}
-
 		for _, clause := range cases {
 			b, err := clause.test(sel, ctx)
 			if err != nil {
 				return err
 			}
-			if ctx.IsFindVars() {
-				if err := ctx.RenderBlock(w, clause.body()); err != nil {
-					return err
-				}
-			} else if b {
+			if b {
 				return ctx.RenderBlock(w, clause.body())
 			}
 		}
@@ -116,24 +107,12 @@ func ifTagCompiler(polarity bool) func(render.BlockNode) (func(io.Writer, render
 			branches = append(branches, branchRec{test, c})
 		}
 		return func(w io.Writer, ctx render.Context) error {
-			cfg := ctx.GetConfig()
-			if len(cfg.AllowedTags) > 0 && len(branches) > 0 {
-				for _, b := range branches {
-					w.Write([]byte(b.body.SourceText()))
-					ctx.RenderBlock(w, b.body)
-				}
-				return nil
-			}
 			for _, b := range branches {
 				value, err := ctx.Evaluate(b.test)
 				if err != nil {
 					return err
 				}
-				if ctx.IsFindVars() {
-					if err := ctx.RenderBlock(w, b.body); err != nil {
-						return err
-					}
-				} else if value != nil && value != false {
+				if value != nil && value != false {
 					return ctx.RenderBlock(w, b.body)
 				}
 			}
@@ -81,19 +81,6 @@ func (loop loopRenderer) render(w io.Writer, ctx render.Context) error {
 	if err != nil {
 		return err
 	}
-	var loopVarIdx int
-	if ctx.IsFindVars() {
-		varInt := ctx.Get(expressions.LatestVarNameKey)
-		// no loop var
-		if varInt == nil {
-			return nil
-		}
-		loopSourceVarName := varInt.(string)
-		val = []map[string]interface{}{
-			{}, // empty object
-		}
-		loopVarIdx = ctx.SetLoopVar(loop.Variable, loopSourceVarName)
-	}
 	iter := makeIterator(val)
 	if iter == nil {
 		return nil
@@ -104,7 +91,6 @@ func (loop loopRenderer) render(w io.Writer, ctx render.Context) error {
 	defer func(index, forloop interface{}) {
 		ctx.Set(forloopVarName, index)
 		ctx.Set(loop.Variable, forloop)
-		ctx.RemoveLoopVar(loopVarIdx)
 	}(ctx.Get(forloopVarName), ctx.Get(loop.Variable))
 	cycleMap := map[string]int{}
 loop:
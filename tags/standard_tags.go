@@ -6,6 +6,7 @@ import (
 
 	"github.com/autopilot3/liquid/expressions"
 	"github.com/autopilot3/liquid/render"
+	"github.com/autopilot3/liquid/values"
 )
 
 // AddStandardTags defines the standard Liquid tags.
@@ -38,9 +39,6 @@ func assignTag(source string) (func(io.Writer, render.Context) error, error) {
 		if err != nil {
 			return err
 		}
-		if ctx.IsFindVars() {
-			ctx.SetVar(stmt.Assignment.Variable)
-		}
 		ctx.Set(stmt.Assignment.Variable, value)
 		return nil
 	}, nil
@@ -54,6 +52,12 @@ func captureTagCompiler(node render.BlockNode) (func(io.Writer, render.Context)
 		if err != nil {
 			return err
 		}
+		if ctx.GetConfig().AutoEscape {
+			// The captured output has already been escaped. Filters that change
+			// markup, such as upcase, return strings, which are escaped again.
+			ctx.Set(varname, values.SafeHTML(s))
+			return nil
+		}
 		ctx.Set(varname, s)
 		return nil
 	}, nil
@@ -3,6 +3,7 @@ package liquid
 import (
 	"bytes"
 
+	"github.com/autopilot3/liquid/filters"
 	"github.com/autopilot3/liquid/parser"
 	"github.com/autopilot3/liquid/render"
 )
@@ -13,6 +14,7 @@ import (
 type Template struct {
 	root render.Node
 	cfg  *render.Config
+	loc  parser.SourceLoc
 }
 
 func newTemplate(cfg *render.Config, source []byte, path string, line int) (*Template, SourceError) {
@@ -21,7 +23,16 @@ func newTemplate(cfg *render.Config, source []byte, path string, line int) (*Tem
 	if err != nil {
 		return nil, err
 	}
-	return &Template{root, cfg}, nil
+	return &Template{root, cfg, loc}, nil
+}
+
+// line returns the number of a source line that the parser numbered n. The parser
+// numbers the lines of a template parsed without a line from 0; they are reported from 1.
+func (t *Template) line(n int) int {
+	if t.loc.LineNo == 0 {
+		return n + 1
+	}
+	return n
 }
 
 // GetRoot returns the root node of the abstract syntax tree (AST) representing
@@ -31,24 +42,61 @@ func (t *Template) GetRoot() render.Node {
 }
 
 // Render executes the template with the specified variable bindings.
-func (t *Template) Render(vars Bindings) ([]byte, SourceError) {
+func (t *Template) Render(vars Bindings, opts ...RenderOption) ([]byte, SourceError) {
+	cfg := t.renderConfig(opts)
 	buf := new(bytes.Buffer)
-	err := render.Render(t.root, buf, vars, *t.cfg)
+	err := render.Render(t.root, buf, vars, cfg)
 	if err != nil {
 		return nil, err
 	}
+	if cfg.Links != nil && cfg.RewriteAllLinks {
+		bs, err := rewriteLinks(cfg.Context(), cfg.Links, buf.Bytes())
+		if err != nil {
+			return nil, parser.WrapError(err, parser.Token{SourceLoc: t.loc, Source: "<a href>"})
+		}
+		return bs, nil
+	}
 	return buf.Bytes(), nil
 }
 
+// RenderText executes the template with the specified variable bindings, and converts
+// the rendered HTML to plain text, such as the text/plain part of a multipart email.
+// See the html_to_text filter for the conversion rules.
+func (t *Template) RenderText(vars Bindings, opts ...RenderOption) (string, SourceError) {
+	bs, err := t.Render(vars, opts...)
+	if err != nil {
+		return "", err
+	}
+	return filters.HTMLToText(string(bs)), nil
+}
+
 // RenderString is a convenience wrapper for Render, that has string input and output.
-func (t *Template) RenderString(b Bindings) (string, SourceError) {
-	bs, err := t.Render(b)
+func (t *Template) RenderString(b Bindings, opts ...RenderOption) (string, SourceError) {
+	bs, err := t.Render(b, opts...)
 	if err != nil {
 		return "", err
 	}
 	return string(bs), nil
 }
 
+// FindVariables returns the variables that the template uses, by path. The value of each
+// is an *expressions.VariableBind, which is a loop for the source of a loop, with the
+// properties of its elements as attributes. See AnalyzeVariables for how variables are
+// resolved; unlike the report, the bindings are nested by loop.
 func (t *Template) FindVariables() (map[string]interface{}, SourceError) {
-	return render.FindVariables(t.root, *t.cfg)
+	return render.FindVariables(t.root), nil
+}
+
+// AnalyzeVariables returns the variables that the template uses, sorted by path, with
+// the position, tag and filters of each usage.
+//
+// Lines are numbered from the line passed to ParseTemplateLocation, or from 1.
+func (t *Template) AnalyzeVariables() []VariableReport {
+	reports := render.AnalyzeVariables(t.root)
+	for _, r := range reports {
+		for i := range r.Usages {
+			r.Usages[i].Line = t.line(r.Usages[i].Line)
+		}
+	}
+	return reports
 }
@@ -18,6 +18,15 @@ func TestTemplate_RenderString(t *testing.T) {
 	require.Equal(t, "Hello world", out)
 }
 
+func TestTemplate_RenderText(t *testing.T) {
+	engine := NewEngine()
+	tpl, err := engine.ParseTemplate([]byte(`<h1>Hi {{ page.title }}</h1><p>Read <a href="https://example.com/{{ x }}">more</a>.<br>Thanks</p>`))
+	require.NoError(t, err)
+	out, err := tpl.RenderText(testBindings)
+	require.NoError(t, err)
+	require.Equal(t, "Hi Introduction\n\nRead more (https://example.com/123).\nThanks", out)
+}
+
 func TestTemplate_SetSourcePath(t *testing.T) {
 	engine := NewEngine()
 	engine.RegisterTag("sourcepath", func(c render.Context) (string, error) {
@@ -108,3 +117,44 @@ func BenchmarkTemplate_Render(b *testing.B) {
 		tpl.Render(bindings)
 	}
 }
+
+func TestTemplate_AnalyzeVariables(t *testing.T) {
+	engine := NewEngine()
+	tpl, err := engine.ParseString(`Hi {{ contact.first_name | default: 'there' | capitalize }}
+{% if contact.vip and order.total > 100 %}VIP{% endif %}
+{% assign total = order.total | times: 1.1 %}{{ total }}
+{% for line in order.lines %}{{ forloop.index }}
+  {% for component in line.components %}{{ component.name | upcase }}{% endfor %}
+{% endfor %}`)
+	require.NoError(t, err)
+
+	reports := tpl.AnalyzeVariables()
+	require.Equal(t, []VariableReport{
+		{Path: "contact.first_name", Usages: []VariableUsage{
+			{Path: "contact.first_name", Line: 1, Column: 7, Tag: "object", Filters: []string{"default", "capitalize"}},
+		}},
+		{Path: "contact.vip", ConditionOnly: true, Usages: []VariableUsage{
+			{Path: "contact.vip", Line: 2, Column: 7, Tag: "if", Condition: true},
+		}},
+		{Path: "order.lines", Usages: []VariableUsage{
+			{Path: "order.lines", Line: 4, Column: 16, Tag: "for", Loop: true},
+		}},
+		{Path: "order.lines[].components", Usages: []VariableUsage{
+			{Path: "order.lines[].components", Line: 5, Column: 23, Tag: "for", Loop: true},
+		}},
+		{Path: "order.lines[].components[].name", Usages: []VariableUsage{
+			{Path: "order.lines[].components[].name", Line: 5, Column: 44, Tag: "object", Filters: []string{"upcase"}},
+		}},
+		{Path: "order.total", Usages: []VariableUsage{
+			{Path: "order.total", Line: 2, Column: 23, Tag: "if", Condition: true, Operator: ">", Operand: &render.Operand{Literal: 100}},
+			{Path: "order.total", Line: 3, Column: 19, Tag: "assign", Filters: []string{"times"}},
+		}},
+	}, reports)
+
+	tpl, err = engine.ParseTemplateLocation([]byte("\n{{ a }}"), "email.html", 10)
+	require.NoError(t, err)
+	reports = tpl.AnalyzeVariables()
+	require.Len(t, reports, 1)
+	require.Equal(t, 11, reports[0].Usages[0].Line)
+	require.Equal(t, 4, reports[0].Usages[0].Column)
+}
@@ -0,0 +1,43 @@
+package liquid
+
+import (
+	"context"
+
+	"github.com/autopilot3/liquid/expressions"
+	"github.com/autopilot3/liquid/i18n"
+	"github.com/autopilot3/liquid/render"
+	"github.com/autopilot3/liquid/values"
+)
+
+// SetCatalog sets the message catalog of the t filter, and the locale to fall back to
+// when a message isn't in the catalog for the render locale or its parent locales.
+//
+// The t filter looks up a message key in the locale set by SetLocale or WithLocale,
+// and replaces each {{ name }} in the message by a named argument:
+//
+//	{{ 'footer.unsubscribe' | t: name: contact.first_name }}
+func (e *Engine) SetCatalog(c i18n.Catalog, defaultLocale string) *Engine {
+	e.cfg.Catalog, e.cfg.DefaultLocale = c, defaultLocale
+	return e
+}
+
+// translate is the t filter.
+func translate(ctx context.Context, key string, vars expressions.NamedArgs) string {
+	var (
+		catalog       i18n.Catalog
+		defaultLocale string
+	)
+	if cfg := render.ContextConfig(ctx); cfg != nil {
+		catalog, defaultLocale = cfg.Catalog, cfg.DefaultLocale
+	}
+	locale := values.Locale(ctx)
+	if locale == "" {
+		locale = defaultLocale
+	}
+	if catalog != nil {
+		if msg, _, ok := i18n.Lookup(catalog, locale, defaultLocale, key); ok {
+			return i18n.Interpolate(msg, vars)
+		}
+	}
+	return "translation missing: " + locale + "." + key
+}
@@ -0,0 +1,57 @@
+package liquid
+
+import (
+	"testing"
+
+	"github.com/autopilot3/liquid/i18n"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_SetCatalog(t *testing.T) {
+	catalog, err := i18n.ParseYAML([]byte(`
+en:
+  footer:
+    unsubscribe: "Unsubscribe, {{ name }}"
+  greeting: Hello
+fr:
+  footer:
+    unsubscribe: "Se désabonner, {{ name }}"
+  greeting: Bonjour
+fr-CA:
+  greeting: Allô
+`))
+	require.NoError(t, err)
+	engine := NewEngine().SetCatalog(catalog, "en")
+	bindings := map[string]interface{}{"contact": map[string]interface{}{"first_name": "Ann"}}
+	tpl, err := engine.ParseString(`{{ 'greeting' | t }} {{ 'footer.unsubscribe' | t: name: contact.first_name }}`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		locale, expected string
+	}{
+		{"", "Hello Unsubscribe, Ann"},
+		{"en-AU", "Hello Unsubscribe, Ann"},
+		{"fr", "Bonjour Se désabonner, Ann"},
+		{"fr-CA", "Allô Se désabonner, Ann"},
+		{"de", "Hello Unsubscribe, Ann"},
+	}
+	for _, test := range tests {
+		out, err := tpl.RenderString(bindings, WithLocale(test.locale))
+		require.NoError(t, err)
+		require.Equal(t, test.expected, out, test.locale)
+	}
+
+	out, err := engine.ParseAndRenderString(`{{ 'missing' | t }}`, nil, WithLocale("fr"))
+	require.NoError(t, err)
+	require.Equal(t, "translation missing: fr.missing", out)
+
+	out, err = NewEngine().SetLocale("fr").ParseAndRenderString(`{{ 'greeting' | t }}`, nil)
+	require.NoError(t, err)
+	require.Equal(t, "translation missing: fr.greeting", out)
+
+	vars, err := engine.ParseString(`{{ 'footer.unsubscribe' | t: name: contact.first_name }}`)
+	require.NoError(t, err)
+	found, err := vars.FindVariables()
+	require.NoError(t, err)
+	require.Contains(t, found, "contact.first_name")
+}
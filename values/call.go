@@ -1,6 +1,7 @@
 package values
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 )
@@ -13,7 +14,13 @@ import (
 // The function should return one or two values; the second value,
 // if present, should be an error.
 func Call(fn reflect.Value, args []interface{}) (interface{}, error) {
-	in, err := convertCallArguments(fn, args)
+	return CallContext(context.Background(), fn, args)
+}
+
+// CallContext is like Call, but converts strings to times with the default time zone
+// and the clock of ctx, as by ParseDateContext.
+func CallContext(ctx context.Context, fn reflect.Value, args []interface{}) (interface{}, error) {
+	in, err := convertCallArguments(ctx, fn, args)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +48,7 @@ func convertCallResults(results []reflect.Value) (interface{}, error) {
 }
 
 // Convert args to match the input types of function fn.
-func convertCallArguments(fn reflect.Value, args []interface{}) (results []reflect.Value, err error) {
+func convertCallArguments(ctx context.Context, fn reflect.Value, args []interface{}) (results []reflect.Value, err error) {
 	rt := fn.Type()
 	if len(args) > rt.NumIn() && !rt.IsVariadic() {
 		return nil, &CallParityError{NumArgs: len(args), NumParams: rt.NumIn()}
@@ -64,11 +71,11 @@ func convertCallArguments(fn reflect.Value, args []interface{}) (results []refle
 		}
 		switch {
 		case isDefaultFunctionType(typ):
-			results[i] = makeConstantFunction(typ, arg)
+			results[i] = makeConstantFunction(ctx, typ, arg)
 		case arg == nil:
 			results[i] = reflect.Zero(typ)
 		default:
-			results[i] = reflect.ValueOf(MustConvert(arg, typ))
+			results[i] = reflect.ValueOf(mustConvert(ctx, arg, typ))
 		}
 	}
 
@@ -89,9 +96,9 @@ func isDefaultFunctionType(typ reflect.Type) bool {
 	return typ.Kind() == reflect.Func && typ.NumIn() == 1 && typ.NumOut() == 1
 }
 
-func makeConstantFunction(typ reflect.Type, arg interface{}) reflect.Value {
+func makeConstantFunction(ctx context.Context, typ reflect.Type, arg interface{}) reflect.Value {
 	return reflect.MakeFunc(typ, func(args []reflect.Value) []reflect.Value {
-		return []reflect.Value{reflect.ValueOf(MustConvert(arg, typ.Out(0)))}
+		return []reflect.Value{reflect.ValueOf(mustConvert(ctx, arg, typ.Out(0)))}
 	})
 }
 
@@ -1,6 +1,7 @@
 package values
 
 import (
+	"context"
 	"reflect"
 	"time"
 
@@ -14,7 +15,13 @@ var (
 )
 
 // Equal returns a bool indicating whether a == b after conversion.
-func Equal(a, b interface{}) bool { // nolint: gocyclo
+func Equal(a, b interface{}) bool {
+	return EqualContext(context.Background(), a, b)
+}
+
+// EqualContext is like Equal, but a string compared with a time is parsed with
+// the default time zone and the clock of ctx, as by ParseDateContext.
+func EqualContext(ctx context.Context, a, b interface{}) bool { // nolint: gocyclo
 	a, b = ToLiquid(a), ToLiquid(b)
 	if a == nil || b == nil {
 		return a == b
@@ -25,7 +32,7 @@ func Equal(a, b interface{}) bool { // nolint: gocyclo
 		// we have a time comparison, try to convert b to time.Time
 		// there should be only two cases: b is a user input string or a time.Time which is our variabeles from crm
 		if rb.Kind() == reflect.String {
-			db, err := ParseDate(rb.String())
+			db, err := ParseDateContext(ctx, rb.String())
 			if err == nil {
 				return ra.Interface().(time.Time).Equal(db)
 			} else {
@@ -49,7 +56,7 @@ func Equal(a, b interface{}) bool { // nolint: gocyclo
 			return false
 		}
 		for i := 0; i < ra.Len(); i++ {
-			if !Equal(ra.Index(i).Interface(), rb.Index(i).Interface()) {
+			if !EqualContext(ctx, ra.Index(i).Interface(), rb.Index(i).Interface()) {
 				return false
 			}
 		}
@@ -74,6 +81,12 @@ func Equal(a, b interface{}) bool { // nolint: gocyclo
 
 // Less returns a bool indicating whether a < b.
 func Less(a, b interface{}) bool {
+	return LessContext(context.Background(), a, b)
+}
+
+// LessContext is like Less, but a string compared with a time or a date is parsed with
+// the default time zone and the clock of ctx, as by ParseDateContext.
+func LessContext(ctx context.Context, a, b interface{}) bool {
 	a, b = ToLiquid(a), ToLiquid(b)
 	if a == nil && b == nil {
 		return false
@@ -107,7 +120,7 @@ func Less(a, b interface{}) bool {
 			// we have a time comparison, try to convert b to time.time
 			// there should be only two cases: b is a user input string or a time.Time which is our variabeles from crm
 			if rb.Kind() == reflect.String {
-				db, err := ParseDate(rb.String())
+				db, err := ParseDateContext(ctx, rb.String())
 				if err == nil {
 					return ra.Interface().(time.Time).Before(db)
 				}
@@ -120,7 +133,7 @@ func Less(a, b interface{}) bool {
 			// we have a time comparison, try to convert a to time.time
 			// there should be only two cases: a is a user input string or a time.Time which is our variabeles from crm
 			if ra.Kind() == reflect.String {
-				da, err := ParseDate(ra.String())
+				da, err := ParseDateContext(ctx, ra.String())
 				if err == nil {
 					return da.Before(rb.Interface().(time.Time))
 				}
@@ -133,7 +146,7 @@ func Less(a, b interface{}) bool {
 	dVar := date.Date(1)
 	if reflect.TypeOf(a) == reflect.TypeOf(dVar) {
 		if rb.Kind() == reflect.String {
-			db, err := ParseDate(rb.String())
+			db, err := ParseDateContext(ctx, rb.String())
 			if err == nil {
 				d := date.NewFromUTCTime(db)
 				return a.(date.Date) < d
@@ -141,7 +154,7 @@ func Less(a, b interface{}) bool {
 		}
 	} else if reflect.TypeOf(b) == reflect.TypeOf(dVar) {
 		if ra.Kind() == reflect.String {
-			da, err := ParseDate(ra.String())
+			da, err := ParseDateContext(ctx, ra.String())
 			if err == nil {
 				d := date.NewFromUTCTime(da)
 				return d < b.(date.Date)
@@ -1,6 +1,7 @@
 package values
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -64,7 +65,13 @@ func convertValueToFloat(value interface{}, typ reflect.Type) (float64, error) {
 // Convert value to the type. This is a more aggressive conversion, that will
 // recursively create new map and slice values as necessary. It doesn't
 // handle circular references.
-func Convert(value interface{}, typ reflect.Type) (interface{}, error) { // nolint: gocyclo
+func Convert(value interface{}, typ reflect.Type) (interface{}, error) {
+	return ConvertContext(context.Background(), value, typ)
+}
+
+// ConvertContext is like Convert, but a string is converted to a time with the default
+// time zone and the clock of ctx, as by ParseDateContext.
+func ConvertContext(ctx context.Context, value interface{}, typ reflect.Type) (interface{}, error) { // nolint: gocyclo
 	value = ToLiquid(value)
 	rv := reflect.ValueOf(value)
 	// int.Convert(string) returns "\x01" not "1", so guard against that in the following test
@@ -72,7 +79,7 @@ func Convert(value interface{}, typ reflect.Type) (interface{}, error) { // noli
 		return rv.Convert(typ).Interface(), nil
 	}
 	if typ == timeType && rv.Kind() == reflect.String {
-		return ParseDate(value.(string))
+		return ParseDateContext(ctx, value.(string))
 	}
 	// currently unused:
 	// case reflect.PtrTo(r.Type()) == typ:
@@ -126,7 +133,7 @@ func Convert(value interface{}, typ reflect.Type) (interface{}, error) { // noli
 				if item.Key == nil {
 					k = reflect.Zero(typ.Key())
 				} else {
-					kc, err := Convert(item.Key, typ.Key())
+					kc, err := ConvertContext(ctx, item.Key, typ.Key())
 					if err != nil {
 						return nil, err
 					}
@@ -135,7 +142,7 @@ func Convert(value interface{}, typ reflect.Type) (interface{}, error) { // noli
 				if item.Value == nil {
 					v = reflect.Zero(et)
 				} else {
-					ec, err := Convert(item.Value, et)
+					ec, err := ConvertContext(ctx, item.Value, et)
 					if err != nil {
 						return nil, err
 					}
@@ -193,7 +200,7 @@ func Convert(value interface{}, typ reflect.Type) (interface{}, error) { // noli
 		case reflect.Array, reflect.Slice:
 			result := reflect.MakeSlice(typ, 0, rv.Len())
 			for i := 0; i < rv.Len(); i++ {
-				item, err := Convert(rv.Index(i).Interface(), typ.Elem())
+				item, err := ConvertContext(ctx, rv.Index(i).Interface(), typ.Elem())
 				if err != nil {
 					return nil, err
 				}
@@ -203,7 +210,7 @@ func Convert(value interface{}, typ reflect.Type) (interface{}, error) { // noli
 		case reflect.Map:
 			result := reflect.MakeSlice(typ, 0, rv.Len())
 			for _, key := range rv.MapKeys() {
-				item, err := Convert(rv.MapIndex(key).Interface(), typ.Elem())
+				item, err := ConvertContext(ctx, rv.MapIndex(key).Interface(), typ.Elem())
 				if err != nil {
 					return nil, err
 				}
@@ -226,7 +233,11 @@ func Convert(value interface{}, typ reflect.Type) (interface{}, error) { // noli
 
 // MustConvert is like Convert, but panics if conversion fails.
 func MustConvert(value interface{}, t reflect.Type) interface{} {
-	out, err := Convert(value, t)
+	return mustConvert(context.Background(), value, t)
+}
+
+func mustConvert(ctx context.Context, value interface{}, t reflect.Type) interface{} {
+	out, err := ConvertContext(ctx, value, t)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,17 @@
+package values
+
+import "context"
+
+type localeKey struct{}
+
+// WithLocale returns a copy of ctx with a default locale, such as "en-AU", for filters
+// that format numbers, dates and messages.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// Locale returns the default locale of ctx, or "" if it doesn't have one.
+func Locale(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
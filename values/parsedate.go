@@ -1,6 +1,7 @@
 package values
 
 import (
+	"context"
 	"reflect"
 	"time"
 )
@@ -44,16 +45,82 @@ var dateLayouts = []string{
 	"Jan 2 2006",
 }
 
-// ParseDate tries a few heuristics to parse a date from a string
+// ParseDate tries a few heuristics to parse a date from a string.
+// A date without a time zone is in the local time zone.
+// "now" is the current time, and "today" is the start of the current day.
 func ParseDate(s string) (time.Time, error) {
-	if s == "now" {
-		return time.Now(), nil
+	return ParseDateInLocation(s, time.Local)
+}
+
+// ParseDateInLocation is like ParseDate, but a date without a time zone is in loc.
+func ParseDateInLocation(s string, loc *time.Location) (time.Time, error) {
+	return parseDate(s, loc, time.Now)
+}
+
+// ParseDateContext is like ParseDate, but with the default time zone and the clock of ctx.
+func ParseDateContext(ctx context.Context, s string) (time.Time, error) {
+	return parseDate(s, LocationOrLocal(ctx), clock(ctx))
+}
+
+func parseDate(s string, loc *time.Location, now Clock) (time.Time, error) {
+	switch s {
+	case "now":
+		return now().In(loc), nil
+	case "today":
+		y, m, d := now().In(loc).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, loc), nil
 	}
 	for _, layout := range dateLayouts {
-		t, err := time.ParseInLocation(layout, s, time.Local)
+		t, err := time.ParseInLocation(layout, s, loc)
 		if err == nil {
 			return t, nil
 		}
 	}
 	return zeroTime, conversionError("", s, reflect.TypeOf(zeroTime))
 }
+
+// A Clock returns the current time.
+type Clock func() time.Time
+
+type clockKey struct{}
+
+// WithClock returns a copy of ctx with a clock, for "now", "today", and the filters
+// that compute times relative to the current time.
+func WithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, c)
+}
+
+// clock returns the clock of ctx, or time.Now if it doesn't have one.
+func clock(ctx context.Context) Clock {
+	if c, _ := ctx.Value(clockKey{}).(Clock); c != nil {
+		return c
+	}
+	return time.Now
+}
+
+// Now returns the current time of the clock of ctx, in the default time zone of ctx.
+func Now(ctx context.Context) time.Time {
+	return clock(ctx)().In(LocationOrLocal(ctx))
+}
+
+type locationKey struct{}
+
+// WithLocation returns a copy of ctx with a default time zone, for filters that
+// parse or format dates without an explicit time zone.
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// Location returns the default time zone of ctx, or nil if it doesn't have one.
+func Location(ctx context.Context) *time.Location {
+	loc, _ := ctx.Value(locationKey{}).(*time.Location)
+	return loc
+}
+
+// LocationOrLocal returns the default time zone of ctx, or time.Local if it doesn't have one.
+func LocationOrLocal(ctx context.Context) *time.Location {
+	if loc := Location(ctx); loc != nil {
+		return loc
+	}
+	return time.Local
+}
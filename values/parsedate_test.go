@@ -1,7 +1,9 @@
 package values
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -15,3 +17,51 @@ func TestConstant(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, timeMustParse("2017-07-09T10:40:00Z"), dt)
 }
+
+func TestParseDateInLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Australia/Sydney")
+	require.NoError(t, err)
+	dt, err := ParseDateInLocation("2017-07-09 10:40:00", loc)
+	require.NoError(t, err)
+	require.Equal(t, timeMustParse("2017-07-09T00:40:00Z"), dt.UTC())
+
+	dt, err = ParseDateInLocation("2017-07-09 10:40:00 -0700", loc)
+	require.NoError(t, err)
+	require.Equal(t, timeMustParse("2017-07-09T17:40:00Z"), dt.UTC())
+
+	dt, err = ParseDateInLocation("now", loc)
+	require.NoError(t, err)
+	require.Equal(t, loc, dt.Location())
+}
+
+func TestLocation(t *testing.T) {
+	ctx := context.Background()
+	require.Nil(t, Location(ctx))
+	require.Equal(t, time.Local, LocationOrLocal(ctx))
+	ctx = WithLocation(ctx, time.UTC)
+	require.Equal(t, time.UTC, Location(ctx))
+	require.Equal(t, time.UTC, LocationOrLocal(ctx))
+}
+
+func TestParseDateContext(t *testing.T) {
+	loc, err := time.LoadLocation("Australia/Sydney")
+	require.NoError(t, err)
+	fixed := timeMustParse("2017-07-09T15:40:00Z")
+	ctx := WithClock(WithLocation(context.Background(), loc), func() time.Time { return fixed })
+
+	dt, err := ParseDateContext(ctx, "now")
+	require.NoError(t, err)
+	require.Equal(t, fixed.In(loc), dt)
+	require.Equal(t, fixed.In(loc), Now(ctx))
+
+	dt, err = ParseDateContext(ctx, "today")
+	require.NoError(t, err)
+	require.Equal(t, timeMustParse("2017-07-09T14:00:00Z"), dt.UTC())
+
+	dt, err = ParseDateContext(ctx, "2017-07-09 10:40:00")
+	require.NoError(t, err)
+	require.Equal(t, timeMustParse("2017-07-09T00:40:00Z"), dt.UTC())
+
+	before := time.Now()
+	require.False(t, Now(context.Background()).Before(before))
+}
@@ -0,0 +1,7 @@
+package values
+
+// SafeHTML is a string of trusted markup.
+//
+// When auto-escaping is enabled, a SafeHTML value is written verbatim instead
+// of being escaped for its HTML context.
+type SafeHTML string
@@ -222,6 +222,8 @@ func (sv stringValue) Contains(substr Value) bool {
 	switch sv.value.(type) {
 	case language.LanguageCode:
 		return strings.Contains(string(sv.value.(language.LanguageCode)), s)
+	case SafeHTML:
+		return strings.Contains(string(sv.value.(SafeHTML)), s)
 	default:
 		return strings.Contains(sv.value.(string), s)
 	}
@@ -229,7 +231,7 @@ func (sv stringValue) Contains(substr Value) bool {
 
 func (sv stringValue) PropertyValue(iv Value) Value {
 	if iv.Interface() == sizeKey {
-		return ValueOf(len(sv.value.(string)))
+		return ValueOf(reflect.ValueOf(sv.value).Len())
 	}
 	return nilValue
 }
@@ -0,0 +1,172 @@
+package liquid
+
+import (
+	"github.com/autopilot3/liquid/expressions"
+	"github.com/autopilot3/liquid/parser"
+	"github.com/autopilot3/liquid/render"
+)
+
+// An Expr is an expression tree: a *expressions.Literal, *expressions.Variable,
+// *expressions.Property, *expressions.Index, *expressions.Filter,
+// *expressions.Comparison, *expressions.Logical or *expressions.Range. Its positions
+// are byte offsets in the Args of the node that contains it.
+type Expr = expressions.Node
+
+// A Location is the position of a node in a template. Lines are numbered from the line
+// passed to ParseTemplateLocation, or from 1; columns are counted in characters from 1.
+type Location struct {
+	Path         string
+	Line, Column int
+}
+
+// Position returns the location; it makes a Location a Node's position.
+func (l Location) Position() Location { return l }
+
+// A Node is a node of a template that Template.Walk visits: a *TextNode, *ObjectNode,
+// *TagNode, *BlockNode or *RawNode.
+type Node interface {
+	// Position returns the location of the start of the node.
+	Position() Location
+}
+
+// A TextNode is text outside of tags and objects.
+type TextNode struct {
+	Location
+	Source string
+}
+
+// An ObjectNode is an {{ object }}.
+type ObjectNode struct {
+	Location
+	Source string
+	// Args is the expression between the braces, and Expr its tree.
+	Args string
+	Expr Expr
+}
+
+// A TagNode is a tag that isn't a block, such as {% assign %}.
+type TagNode struct {
+	Location
+	Source string
+	Name   string
+	Args   string
+	// Exprs are the expression trees of the arguments; the value of an assign.
+	Exprs []Expr
+}
+
+// A BlockNode is a block, such as {% if %}…{% endif %}, or one of its clauses, such as
+// {% else %}. Source is the source of its start tag.
+type BlockNode struct {
+	Location
+	Source string
+	Name   string
+	Args   string
+	// Exprs are the expression trees of the arguments: the condition of an if, unless,
+	// elsif or case, the values of a when, and the collection of a for or tablerow.
+	Exprs []Expr
+	// Body are the nodes before the first clause.
+	Body []Node
+	// Clauses are the clauses of a block; a clause doesn't have clauses.
+	Clauses []*BlockNode
+}
+
+// A RawNode is a {% raw %}…{% endraw %} block. Source is the source of its start tag, and
+// Text the text between its tags.
+type RawNode struct {
+	Location
+	Source string
+	Text   string
+}
+
+// Walk calls fn for each node of the template, in source order. If fn returns true for
+// a block, Walk visits the nodes of its body, and then its clauses and their bodies.
+func (t *Template) Walk(fn func(Node) bool) {
+	w := walker{t}
+	for _, n := range w.nodes([]render.Node{t.root}) {
+		walk(n, fn)
+	}
+}
+
+func walk(n Node, fn func(Node) bool) {
+	if !fn(n) {
+		return
+	}
+	if b, ok := n.(*BlockNode); ok {
+		for _, c := range b.Body {
+			walk(c, fn)
+		}
+		for _, c := range b.Clauses {
+			walk(c, fn)
+		}
+	}
+}
+
+// A walker converts the render nodes of a template to Nodes.
+type walker struct {
+	t *Template
+}
+
+func (w walker) nodes(nodes []render.Node) []Node {
+	var out []Node
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *render.SeqNode:
+			out = append(out, w.nodes(n.Children)...)
+		case *render.TextNode:
+			out = append(out, &TextNode{w.location(n.Token), n.Source})
+		case *render.ObjectNode:
+			expr, _ := expressions.ParseTree(n.Args)
+			out = append(out, &ObjectNode{w.location(n.Token), n.Source, n.Args, expr})
+		case *render.TagNode:
+			out = append(out, &TagNode{w.location(n.Token), n.Source, n.Name, n.Args, tagExprs(n.Token)})
+		case *render.BlockNode:
+			out = append(out, w.block(n))
+		case *render.RawNode:
+			out = append(out, &RawNode{w.location(n.Token), n.Source, n.Text()})
+		}
+	}
+	return out
+}
+
+func (w walker) block(n *render.BlockNode) *BlockNode {
+	b := &BlockNode{
+		Location: w.location(n.Token),
+		Source:   n.Source,
+		Name:     n.Name,
+		Args:     n.Args,
+		Exprs:    tagExprs(n.Token),
+		Body:     w.nodes(n.Body),
+	}
+	for _, c := range n.Clauses {
+		b.Clauses = append(b.Clauses, w.block(c))
+	}
+	return b
+}
+
+func (w walker) location(tok parser.Token) Location {
+	return Location{tok.SourceLoc.Pathname, w.t.line(tok.SourceLoc.LineNo), tok.SourceLoc.ColNo}
+}
+
+// tagExprs returns the expression trees of the arguments of a standard tag. Their
+// positions are offsets in the arguments.
+func tagExprs(tok parser.Token) []Expr {
+	switch tok.Name {
+	case "if", "unless", "elsif", "case":
+		if expr, err := expressions.ParseTree(tok.Args); err == nil {
+			return []Expr{expr}
+		}
+	case "assign":
+		if stmt, err := expressions.ParseStatement(expressions.AssignStatementSelector, tok.Args); err == nil {
+			return []Expr{stmt.Assignment.Value}
+		}
+	case "for", "tablerow":
+		if stmt, err := expressions.ParseStatement(expressions.LoopStatementSelector, tok.Args); err == nil {
+			return []Expr{stmt.Loop.Collection}
+		}
+	case "when":
+		if stmt, err := expressions.ParseStatement(expressions.WhenStatementSelector, tok.Args); err == nil {
+			return stmt.When.Values
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package liquid
+
+import (
+	"testing"
+
+	"github.com/autopilot3/liquid/expressions"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate_Walk(t *testing.T) {
+	engine := NewEngine()
+	tpl, err := engine.ParseString(`Hi {{ name | upcase }}
+{% assign n = 2 %}{% if n > 1 %}{% for x in xs %}{{ x }}{% endfor %}{% else %}{% raw %}{{ r }}{% endraw %}{% endif %}`)
+	require.NoError(t, err)
+
+	var visited []Node
+	tpl.Walk(func(n Node) bool {
+		visited = append(visited, n)
+		return true
+	})
+	require.Len(t, visited, 9)
+	require.Equal(t, &TextNode{Location{"", 1, 1}, "Hi "}, visited[0])
+	require.Equal(t, &ObjectNode{
+		Location: Location{"", 1, 4},
+		Source:   "{{ name | upcase }}",
+		Args:     "name | upcase",
+		Expr: &expressions.Filter{
+			Span:     expressions.Span{Start: 0, End: 13},
+			Input:    &expressions.Variable{Span: expressions.Span{Start: 0, End: 4}, Name: "name"},
+			Name:     "upcase",
+			NameSpan: expressions.Span{Start: 7, End: 13},
+		},
+	}, visited[1])
+	require.Equal(t, &TagNode{
+		Location: Location{"", 2, 1},
+		Source:   "{% assign n = 2 %}",
+		Name:     "assign",
+		Args:     "n = 2",
+		Exprs:    []Expr{&expressions.Literal{Span: expressions.Span{Start: 4, End: 5}, Value: 2}},
+	}, visited[3])
+
+	block := visited[4].(*BlockNode)
+	require.Equal(t, "if", block.Name)
+	require.Equal(t, Location{"", 2, 19}, block.Position())
+	require.Equal(t, []Expr{&expressions.Comparison{
+		Span:  expressions.Span{Start: 0, End: 5},
+		Op:    ">",
+		Left:  &expressions.Variable{Span: expressions.Span{Start: 0, End: 1}, Name: "n"},
+		Right: &expressions.Literal{Span: expressions.Span{Start: 4, End: 5}, Value: 1},
+	}}, block.Exprs)
+	require.Len(t, block.Clauses, 1)
+
+	loop := visited[5].(*BlockNode)
+	require.Equal(t, "for", loop.Name)
+	require.Equal(t, []Expr{&expressions.Variable{Span: expressions.Span{Start: 5, End: 7}, Name: "xs"}}, loop.Exprs)
+	require.IsType(t, &ObjectNode{}, visited[6])
+	require.Equal(t, "else", visited[7].(*BlockNode).Name)
+	require.Equal(t, &RawNode{Location{"", 2, 79}, "{% raw %}", "{{ r }}"}, visited[8])
+
+	// Returning false skips the body and clauses of a block
+	var names []string
+	tpl.Walk(func(n Node) bool {
+		if b, ok := n.(*BlockNode); ok {
+			names = append(names, b.Name)
+		}
+		return false
+	})
+	require.Equal(t, []string{"if"}, names)
+}